@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/auth"
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/oauth"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type Auth struct {
+	ConfigFile configfile.ConfigFileInterface
+	Debug      bool
+	Executor   executor.ExecutorInterface
+}
+
+// forgeHosts lists the forges and hosts Git Helper has credentials for.
+var forgeHosts = [][2]string{
+	{credentials.GitHub, "github.com"},
+	{credentials.GitLab, "gitlab.com"},
+}
+
+var newProvider = func(forge, host, clientID string) *oauth.Provider {
+	if forge == credentials.GitLab {
+		return oauth.GitLabProvider(host, clientID)
+	}
+
+	return oauth.GitHubProvider(host, clientID)
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		clientID string
+		debug    bool
+		github   bool
+		gitlab   bool
+		host     string
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "auth",
+		Short:                 "Manages GitHub and GitLab credentials",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+	}
+
+	cmd.PersistentFlags().BoolVar(&debug, "debug", false, "enables debug mode")
+
+	newAuthFromFlags := func() *Auth {
+		return newAuth(debug, executor.NewExecutor(debug), configfile.NewConfigFile(debug))
+	}
+
+	statusCmd := &cobra.Command{
+		Use:                   "status",
+		Short:                 "Checks that each configured token works, and shows its user, scopes and expiry",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newAuthFromFlags().status()
+			return nil
+		},
+	}
+
+	loginCmd := &cobra.Command{
+		Use:                   "login",
+		Short:                 "Logs in to GitHub or GitLab in the browser with the OAuth device flow",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			forge, forgeHost := determineForge(github, gitlab, host)
+			newAuthFromFlags().login(forge, forgeHost, clientID)
+			return nil
+		},
+	}
+
+	logoutCmd := &cobra.Command{
+		Use:                   "logout",
+		Short:                 "Removes the stored GitHub or GitLab credentials",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			forge, forgeHost := determineForge(github, gitlab, host)
+			newAuthFromFlags().logout(forge, forgeHost)
+			return nil
+		},
+	}
+
+	refreshCmd := &cobra.Command{
+		Use:                   "refresh",
+		Short:                 "Exchanges the stored OAuth refresh token for a new token",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			forge, forgeHost := determineForge(github, gitlab, host)
+			newAuthFromFlags().refresh(forge, forgeHost, clientID)
+			return nil
+		},
+	}
+
+	for _, c := range []*cobra.Command{loginCmd, logoutCmd, refreshCmd} {
+		c.Flags().BoolVar(&github, "github", false, "uses GitHub")
+		c.Flags().BoolVar(&gitlab, "gitlab", false, "uses GitLab")
+		c.Flags().StringVar(&host, "host", "", "the forge's hostname, defaults to github.com or gitlab.com")
+		c.MarkFlagsOneRequired("github", "gitlab")
+		c.MarkFlagsMutuallyExclusive("github", "gitlab")
+	}
+
+	for _, c := range []*cobra.Command{loginCmd, refreshCmd} {
+		c.Flags().StringVar(&clientID, "client-id", "", "the OAuth application's client ID, defaults to the github_oauth_client_id or gitlab_oauth_client_id config key")
+	}
+
+	cmd.AddCommand(statusCmd, loginCmd, logoutCmd, refreshCmd)
+
+	return cmd
+}
+
+func newAuth(debug bool, executor executor.ExecutorInterface, configFile configfile.ConfigFileInterface) *Auth {
+	return &Auth{
+		ConfigFile: configFile,
+		Debug:      debug,
+		Executor:   executor,
+	}
+}
+
+func determineForge(github, gitlab bool, host string) (string, string) {
+	forge := credentials.GitHub
+	if gitlab {
+		forge = credentials.GitLab
+	}
+
+	if host == "" {
+		host = credentials.DefaultHost(forge)
+	}
+
+	return forge, host
+}
+
+func (a *Auth) status() {
+	config, err := a.ConfigFile.Load()
+	if err != nil {
+		utils.HandleError(err, a.Debug, nil)
+		return
+	}
+
+	loggedIn := 0
+	failed := 0
+	creds := a.credentials()
+	covered := map[string]bool{}
+
+	for _, account := range config.Accounts {
+		covered[account.ForgeName()+"/"+account.Host] = true
+		fmt.Printf("%s (account %s)\n", account.Host, account.Name)
+
+		token, source, err := creds.AccountToken(&account)
+		a.checkStatus(account.ForgeName(), account.Host, token, source, err, &loggedIn, &failed)
+	}
+
+	for _, forgeHost := range forgeHosts {
+		forge, host := forgeHost[0], forgeHost[1]
+		if covered[forge+"/"+host] {
+			continue
+		}
+
+		fmt.Println(host)
+		token, source, err := creds.HostToken(forge, host)
+		a.checkStatus(forge, host, token, source, err, &loggedIn, &failed)
+	}
+
+	if failed > 0 {
+		utils.HandleError(errors.New("some tokens are invalid"), a.Debug, nil)
+		return
+	}
+
+	if loggedIn == 0 {
+		utils.HandleError(errors.New("not logged in to GitHub or GitLab, please run git-helper auth login or git-helper setup"), a.Debug, nil)
+		return
+	}
+}
+
+func (a *Auth) checkStatus(forge, host, token, source string, err error, loggedIn, failed *int) {
+	if err != nil {
+		fmt.Println("  Not logged in")
+		return
+	}
+
+	status, err := auth.CheckToken(a.Debug, forge, host, token)
+	if err != nil {
+		fmt.Printf("  %s (token from %s)\n", err, source)
+		*failed++
+		return
+	}
+
+	*loggedIn++
+	fmt.Printf("%s  Token source: %s\n", status, source)
+}
+
+func (a *Auth) login(forge, host, clientID string) {
+	provider := a.provider(forge, host, clientID)
+	if provider == nil {
+		return
+	}
+
+	code, err := provider.RequestDeviceCode()
+	if err != nil {
+		utils.HandleError(err, a.Debug, nil)
+		return
+	}
+
+	fmt.Printf("First copy your one-time code: %s\n", code.UserCode)
+	fmt.Printf("Then open %s in your browser and enter the code to authorize Git Helper.\n", code.VerificationURI)
+	fmt.Println("Waiting for authorization...")
+
+	token, err := provider.PollToken(code)
+	if err != nil {
+		utils.HandleError(err, a.Debug, nil)
+		return
+	}
+
+	a.store(forge, host, token, "")
+}
+
+func (a *Auth) logout(forge, host string) {
+	err := a.credentials().Erase(forge, host)
+	if err != nil {
+		utils.HandleError(err, a.Debug, nil)
+		return
+	}
+
+	fmt.Printf("Logged out of %s\n", host)
+}
+
+func (a *Auth) refresh(forge, host, clientID string) {
+	refreshToken := a.credentials().RefreshToken(forge, host)
+	if refreshToken == "" {
+		utils.HandleError(errors.New("no refresh token is stored for "+host+", please run git-helper auth login"), a.Debug, nil)
+		return
+	}
+
+	provider := a.provider(forge, host, clientID)
+	if provider == nil {
+		return
+	}
+
+	token, err := provider.Refresh(refreshToken)
+	if err != nil {
+		utils.HandleError(err, a.Debug, nil)
+		return
+	}
+
+	a.store(forge, host, token, refreshToken)
+}
+
+// store saves a token from the device flow, keeping the previous refresh
+// token when the server didn't rotate it.
+func (a *Auth) store(forge, host string, token *oauth.Token, previousRefreshToken string) {
+	status, err := auth.CheckToken(a.Debug, forge, host, token.AccessToken)
+	if err != nil {
+		utils.HandleError(err, a.Debug, nil)
+		return
+	}
+
+	refreshToken := token.RefreshToken
+	if refreshToken == "" {
+		refreshToken = previousRefreshToken
+	}
+
+	location, err := a.credentials().Store(forge, credentials.Credential{
+		ExpiresAt:    token.ExpiresAt(time.Now()),
+		Host:         host,
+		RefreshToken: refreshToken,
+		Token:        token.AccessToken,
+		Username:     status.Username,
+	})
+	if err != nil {
+		utils.HandleError(err, a.Debug, nil)
+		return
+	}
+
+	fmt.Print(status)
+	fmt.Printf("  Token stored in %s\n", location)
+}
+
+func (a *Auth) provider(forge, host, clientID string) *oauth.Provider {
+	if clientID == "" {
+		config, err := a.ConfigFile.Load()
+		if err != nil {
+			utils.HandleError(err, a.Debug, nil)
+			return nil
+		}
+
+		clientID, _ = config.Get(forge + "_oauth_client_id")
+	}
+
+	if clientID == "" {
+		utils.HandleError(fmt.Errorf("no OAuth client ID for %s, please pass --client-id or run git-helper config set %s_oauth_client_id [clientID]", host, forge), a.Debug, nil)
+		return nil
+	}
+
+	return newProvider(forge, host, clientID)
+}
+
+func (a *Auth) credentials() *credentials.Credentials {
+	return credentials.NewCredentials(a.Debug, a.ConfigFile, a.Executor)
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/auth"
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/oauth"
+)
+
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Output  []byte
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	return me.Output, nil
+}
+
+func Test_status(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITLAB_TOKEN", "")
+	os.MkdirAll(filepath.Join(tempDir, ".git-helper"), 0700)
+	os.WriteFile(filepath.Join(tempDir, ".git-helper", "config.yml"), []byte("github_token: token123\n"), 0600)
+
+	originalGitCredential := credentials.GitCredential
+	originalCheckToken := auth.CheckToken
+	t.Cleanup(func() {
+		credentials.GitCredential = originalGitCredential
+		auth.CheckToken = originalCheckToken
+	})
+	credentials.GitCredential = func(action, input string) (string, error) {
+		return "", errors.New("terminal prompts disabled")
+	}
+
+	checked := map[string]string{}
+	auth.CheckToken = func(debug bool, forge, host, token string) (*auth.Status, error) {
+		checked[host] = token
+		return &auth.Status{Forge: forge, Host: host, Username: "octocat", Warnings: []string{}}, nil
+	}
+
+	executor := &MockExecutor{Debug: true}
+	cf := configfile.NewConfigFile(true)
+	cf.Executor = executor
+	newAuth(true, executor, cf).status()
+
+	if len(checked) != 1 || checked["github.com"] != "token123" {
+		t.Errorf("expected only the github.com token to be checked, got %v", checked)
+	}
+}
+
+func Test_login(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	os.MkdirAll(filepath.Join(tempDir, ".git-helper"), 0700)
+	os.WriteFile(filepath.Join(tempDir, ".git-helper", "config.yml"), []byte("github_oauth_client_id: client123\n"), 0600)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_id") != "client123" {
+			t.Errorf("expected the configured client ID, got %v", r.Form)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device":
+			fmt.Fprint(w, `{"device_code":"device123","user_code":"ABCD-1234","verification_uri":"https://github.com/login/device","interval":1}`)
+		case "/token":
+			fmt.Fprint(w, `{"access_token":"token123","refresh_token":"refresh123","expires_in":28800}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	originalNewProvider := newProvider
+	originalCheckToken := auth.CheckToken
+	t.Cleanup(func() {
+		newProvider = originalNewProvider
+		auth.CheckToken = originalCheckToken
+	})
+	newProvider = func(forge, host, clientID string) *oauth.Provider {
+		return &oauth.Provider{
+			ClientID:      clientID,
+			DeviceCodeURL: server.URL + "/device",
+			HTTPClient:    server.Client(),
+			Sleep:         func(time.Duration) {},
+			TokenURL:      server.URL + "/token",
+		}
+	}
+	auth.CheckToken = func(debug bool, forge, host, token string) (*auth.Status, error) {
+		if token != "token123" {
+			t.Errorf("expected the new token to be checked, got %s", token)
+		}
+		return &auth.Status{Forge: forge, Host: host, Username: "octocat", Warnings: []string{}}, nil
+	}
+
+	executor := &MockExecutor{Debug: true}
+	cf := configfile.NewConfigFile(true)
+	cf.Executor = executor
+	a := newAuth(true, executor, cf)
+	a.login(credentials.GitHub, "github.com", "")
+
+	config, _ := cf.LoadFile(cf.ConfigFile())
+	if config.GitHubUsername != "octocat" || config.GitHubToken != "token123" || config.GitHubRefreshToken != "refresh123" {
+		t.Errorf("expected the token to be stored, got %+v", config)
+	}
+
+	a.logout(credentials.GitHub, "github.com")
+
+	config, _ = cf.LoadFile(cf.ConfigFile())
+	if config.GitHubToken != "" || config.GitHubRefreshToken != "" {
+		t.Errorf("expected the token to be erased, got %+v", config)
+	}
+}
+
+func Test_determineForge(t *testing.T) {
+	tests := []struct {
+		github, gitlab bool
+		host           string
+		expectedForge  string
+		expectedHost   string
+	}{
+		{github: true, expectedForge: "github", expectedHost: "github.com"},
+		{gitlab: true, expectedForge: "gitlab", expectedHost: "gitlab.com"},
+		{gitlab: true, host: "gitlab.example.com", expectedForge: "gitlab", expectedHost: "gitlab.example.com"},
+	}
+
+	for _, test := range tests {
+		forge, host := determineForge(test.github, test.gitlab, test.host)
+		if forge != test.expectedForge || host != test.expectedHost {
+			t.Errorf("expected %s %s, got %s %s", test.expectedForge, test.expectedHost, forge, host)
+		}
+	}
+}
+
+func Test_status_Accounts(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITLAB_TOKEN", "")
+	os.MkdirAll(filepath.Join(tempDir, ".git-helper"), 0700)
+	os.WriteFile(filepath.Join(tempDir, ".git-helper", "config.yml"), []byte(`github_token: personaltoken
+accounts:
+  - name: enterprise
+    forge: github
+    host: git.example.com
+    token: enterprisetoken
+`), 0600)
+
+	originalGitCredential := credentials.GitCredential
+	originalCheckToken := auth.CheckToken
+	t.Cleanup(func() {
+		credentials.GitCredential = originalGitCredential
+		auth.CheckToken = originalCheckToken
+	})
+	credentials.GitCredential = func(action, input string) (string, error) {
+		return "", errors.New("terminal prompts disabled")
+	}
+
+	checked := map[string]string{}
+	auth.CheckToken = func(debug bool, forge, host, token string) (*auth.Status, error) {
+		checked[host] = token
+		return &auth.Status{Forge: forge, Host: host, Username: "octocat", Warnings: []string{}}, nil
+	}
+
+	executor := &MockExecutor{Debug: true}
+	cf := configfile.NewConfigFile(true)
+	cf.Executor = executor
+	newAuth(true, executor, cf).status()
+
+	expected := map[string]string{"git.example.com": "enterprisetoken", "github.com": "personaltoken"}
+	if len(checked) != len(expected) || checked["git.example.com"] != "enterprisetoken" || checked["github.com"] != "personaltoken" {
+		t.Errorf("expected %v to be checked, got %v", expected, checked)
+	}
+}
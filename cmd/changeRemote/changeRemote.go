@@ -3,47 +3,122 @@ package changeRemote
 import (
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
-	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/emmahsax/go-git-helper/internal/commandline"
 	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/git"
 	"github.com/emmahsax/go-git-helper/internal/utils"
 	"github.com/spf13/cobra"
 )
 
 type ChangeRemote struct {
 	Debug    bool
+	Depth    int
+	DryRun   bool
 	Executor executor.ExecutorInterface
+	Host     string
 	NewOwner string
 	OldOwner string
+	Protocol string
+	Remotes  []string
+	Repo     string
+	Yes      bool
+}
+
+// change is one remote URL to rewrite. Push changes are for remotes with a
+// push URL of their own.
+type change struct {
+	dir    string
+	name   string
+	newURL string
+	oldURL string
+	push   bool
+	remote string
+}
+
+// rewrite is what to change in each remote URL. Empty old values match
+// anything, and empty new values keep what's there.
+type rewrite struct {
+	newHost, oldHost   string
+	newOwner, oldOwner string
+	newRepo, oldRepo   string
+	protocol           string
 }
 
 func NewCommand() *cobra.Command {
 	var (
-		debug bool
+		debug     bool
+		depth     int
+		dryRun    bool
+		host      string
+		protocol  string
+		recursive bool
+		remotes   []string
+		repo      string
+		yes       bool
 	)
 
 	cmd := &cobra.Command{
-		Use:                   "change-remote [oldOwner] [newOwner]",
-		Short:                 "Change the git remote owners for multiple cloned git repositories",
-		Args:                  cobra.ExactArgs(2),
+		Use:                   "change-remote [oldOwner newOwner]",
+		Short:                 "Change the git remote owners, hosts, protocols or names for multiple cloned git repositories",
+		Args:                  ownerArgs,
 		DisableFlagsInUseLine: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			newChangeRemote(args[0], args[1], debug, executor.NewExecutor(debug)).execute()
+			oldOwner, newOwner := "", ""
+			if len(args) == 2 {
+				oldOwner, newOwner = args[0], args[1]
+			}
+
+			cr := newChangeRemote(oldOwner, newOwner, debug, executor.NewExecutor(debug))
+			cr.Depth = depth
+			if recursive {
+				cr.Depth = 0
+			}
+			cr.DryRun = dryRun
+			cr.Host = host
+			cr.Protocol = protocol
+			cr.Remotes = remotes
+			cr.Repo = repo
+			cr.Yes = yes
+			cr.execute()
 			return nil
 		},
 	}
 
 	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().IntVar(&depth, "depth", 1, "how many directories deep to look for repositories")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show the remote URLs that would change")
+	cmd.Flags().StringVar(&host, "host", "", "change the host, as old=new (e.g. github.com=github.example.com)")
+	cmd.Flags().StringVar(&protocol, "protocol", "", "switch the remote URLs to ssh or https")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "look for repositories in every nested directory")
+	cmd.Flags().StringArrayVar(&remotes, "remote", nil, "only change the remote with this name (repeatable)")
+	cmd.Flags().StringVar(&repo, "repo", "", "rename the repository, as old=new")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "change the remote URLs without asking")
+	cmd.MarkFlagsMutuallyExclusive("depth", "recursive")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "yes")
 
 	return cmd
 }
 
+func ownerArgs(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 && len(args) != 2 {
+		return errors.New("pass both the old and the new owner, or neither")
+	}
+
+	return nil
+}
+
 func newChangeRemote(oldOwner, newOwner string, debug bool, executor executor.ExecutorInterface) *ChangeRemote {
 	return &ChangeRemote{
 		Debug:    debug,
+		Depth:    1,
 		Executor: executor,
 		NewOwner: newOwner,
 		OldOwner: oldOwner,
@@ -51,117 +126,234 @@ func newChangeRemote(oldOwner, newOwner string, debug bool, executor executor.Ex
 }
 
 func (cr *ChangeRemote) execute() {
-	originalDir, _ := os.Getwd()
-	nestedDirs, _ := os.ReadDir(originalDir)
-
-	for _, entry := range nestedDirs {
-		if entry.IsDir() && entry.Name() != "." && entry.Name() != ".." {
-			cr.processDir(entry.Name(), originalDir)
-		}
+	err := cr.changeRemote(os.Stdout)
+	if err != nil {
+		utils.HandleError(err, cr.Debug, nil)
+		return
 	}
 }
 
-func (cr *ChangeRemote) processDir(currentDir, originalDir string) {
-	_ = os.Chdir(currentDir)
-	defer os.Chdir(originalDir)
+func (cr *ChangeRemote) changeRemote(w io.Writer) error {
+	rw, err := cr.rewrite()
+	if err != nil {
+		return err
+	}
+
+	baseDir, err := executor.BaseDir()
+	if err != nil {
+		return err
+	}
+
+	repos, err := git.FindRepos(baseDir, cr.Depth)
+	if err != nil {
+		return err
+	}
 
-	current, _ := os.Getwd()
-	gitDir := filepath.Join(current, ".git")
+	changes := []change{}
+	for _, dir := range repos {
+		changes = append(changes, cr.changes(dir, git.RepoName(baseDir, dir), rw)...)
+	}
 
-	if _, err := os.Stat(gitDir); err == nil {
-		fullRemoteInfo := cr.processGitRepository()
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No remote URLs need changing")
+		return nil
+	}
 
-		if len(fullRemoteInfo) > 0 {
-			fmt.Println("Found git directory: " + currentDir + ".")
-		}
+	printChanges(w, changes)
+	if cr.DryRun {
+		return nil
+	}
 
-		for repo, inner := range fullRemoteInfo {
-			answer := commandline.AskYesNoQuestion(
-				"Do you wish to proceed in updating the " + inner["plainRemote"] + " remote URL?",
-			)
+	if !cr.Yes && !commandline.AskYesNoQuestion(fmt.Sprintf("Do you wish to change these %d remote URL(s)?", len(changes))) {
+		fmt.Fprintln(w, "No remote URLs were changed")
+		return nil
+	}
 
-			if answer {
-				cr.processRemote(inner["plainRemote"], inner["host"], repo, inner["remoteName"])
-			}
+	failed := 0
+	for _, c := range changes {
+		args := []string{"-C", c.dir, "remote", "set-url", c.remote, c.newURL}
+		if c.push {
+			args = []string{"-C", c.dir, "remote", "set-url", "--push", c.remote, c.newURL, "^" + regexp.QuoteMeta(c.oldURL) + "$"}
 		}
+
+		_, err := cr.Executor.Exec("actionAndOutput", "git", args...)
+		if err != nil {
+			fmt.Fprintf(w, "Could not change %s in %s: %v\n", c.remote, c.name, err)
+			failed++
+		}
+	}
+
+	fmt.Fprintf(w, "Changed %d remote URL(s)\n", len(changes)-failed)
+	if failed > 0 {
+		return fmt.Errorf("could not change %d remote URL(s)", failed)
 	}
+
+	return nil
 }
 
-func (cr *ChangeRemote) processGitRepository() map[string]map[string]string {
-	fullRemoteInfo := make(map[string]map[string]string)
+// rewrite checks the options, and returns what they change.
+func (cr *ChangeRemote) rewrite() (*rewrite, error) {
+	rw := &rewrite{newOwner: cr.NewOwner, oldOwner: cr.OldOwner, protocol: cr.Protocol}
 
-	output, err := cr.Executor.Exec("actionAndOutput", "git", "remote", "-v")
+	var err error
+	rw.oldHost, rw.newHost, err = pair("--host", cr.Host)
 	if err != nil {
-		utils.HandleError(err, cr.Debug, nil)
-		return fullRemoteInfo
+		return nil, err
+	}
+
+	rw.oldRepo, rw.newRepo, err = pair("--repo", cr.Repo)
+	if err != nil {
+		return nil, err
 	}
 
-	remotes := strings.Split(string(output), "\n")
+	if rw.protocol != "" && rw.protocol != "ssh" && rw.protocol != "https" {
+		return nil, errors.New("--protocol must be ssh or https")
+	}
+
+	if rw.newOwner == "" && rw.newHost == "" && rw.newRepo == "" && rw.protocol == "" {
+		return nil, errors.New("pass an old and new owner, --host, --repo or --protocol to say what to change")
+	}
+
+	return rw, nil
+}
 
-	for _, remote := range remotes {
-		if remote == "" {
+// pair splits a flag's old=new value.
+func pair(flag, value string) (string, string, error) {
+	if value == "" {
+		return "", "", nil
+	}
+
+	oldValue, newValue, found := strings.Cut(value, "=")
+	if !found || oldValue == "" || newValue == "" {
+		return "", "", fmt.Errorf("%s must be old=new, got %s", flag, value)
+	}
+
+	return oldValue, newValue, nil
+}
+
+// changes returns the repository's remote URLs that the rewrite changes,
+// limited to the --remote names when there are any. Push URLs are only
+// changed on their own when they differ from the fetch URL, since otherwise
+// they're the fetch URL.
+func (cr *ChangeRemote) changes(dir, name string, rw *rewrite) []change {
+	changes := []change{}
+	output, err := cr.Executor.Exec("actionAndOutput", "git", "-C", dir, "remote", "-v")
+	if err != nil {
+		return changes
+	}
+
+	fetchURLs := map[string]string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || (fields[2] != "(fetch)" && fields[2] != "(push)") {
+			continue
+		}
+
+		push := fields[2] == "(push)"
+		if push && fetchURLs[fields[0]] == fields[1] {
 			continue
 		}
+		if !push {
+			fetchURLs[fields[0]] = fields[1]
+		}
 
-		plainRemote := strings.Fields(remote)[1]
-		remoteName := strings.Fields(remote)[0]
-		host, owner, repo := cr.remoteInfo(plainRemote)
+		if len(cr.Remotes) > 0 && !slices.Contains(cr.Remotes, fields[0]) {
+			continue
+		}
 
-		if owner == cr.OldOwner {
-			inner := make(map[string]string)
-			inner["plainRemote"] = plainRemote
-			inner["remoteName"] = remoteName
-			inner["host"] = host
-			fullRemoteInfo[repo] = inner
+		remote, err := git.ParseRemoteURL(fields[1])
+		if err != nil {
+			continue
+		}
+
+		newURL, ok := rw.apply(remote)
+		if ok && newURL != remote.URL {
+			changes = append(changes, change{dir: dir, name: name, newURL: newURL, oldURL: remote.URL, push: push, remote: fields[0]})
 		}
 	}
-	return fullRemoteInfo
+
+	return changes
 }
 
-func (cr *ChangeRemote) processRemote(remote, host, repo, remoteName string) {
-	var newRemote string
+// apply returns the remote's rewritten URL, or false when the remote doesn't
+// match the rewrite's old owner, host or repository. URLs keep their .git
+// suffix, and their username and port unless the protocol or host changes.
+// An HTTPS port doesn't carry over to SSH, and a new host with a port uses
+// the ssh:// form, since scp-like URLs can't have one.
+func (rw *rewrite) apply(remote *git.Remote) (string, bool) {
+	if !matches(remote.Owner, rw.oldOwner) || !matches(remote.Host, rw.oldHost) || !matches(remote.Repo, rw.oldRepo) {
+		return "", false
+	}
 
-	if strings.Contains(remote, "git@") {
-		newRemote = fmt.Sprintf("%s:%s/%s", host, cr.NewOwner, repo)
-	} else if strings.Contains(remote, "https://") {
-		newRemote = fmt.Sprintf("%s/%s/%s", host, cr.NewOwner, repo)
+	host := replace(remote.Host, rw.newHost)
+	repoPath := replace(remote.Owner, rw.newOwner) + "/" + replace(remote.Repo, rw.newRepo)
+	if strings.HasSuffix(strings.TrimSuffix(remote.URL, "/"), ".git") {
+		repoPath += ".git"
 	}
 
-	fmt.Printf("  Changing the remote URL '%s' to be '%s'.\n", remote, newRemote)
+	protocol := remote.Protocol
+	if rw.protocol != "" {
+		protocol = rw.protocol
+	}
 
-	output, err := cr.Executor.Exec("actionAndOutput", "git", "remote", "set-url", remoteName, newRemote)
-	if err != nil {
-		utils.HandleError(err, cr.Debug, nil)
-		return
+	if protocol == remote.Protocol && strings.Contains(remote.URL, "://") {
+		u, err := url.Parse(remote.URL)
+		if err != nil {
+			return "", false
+		}
+
+		if !strings.EqualFold(host, remote.Host) {
+			u.Host = host
+		}
+		u.Path = "/" + repoPath
+		return u.String(), true
 	}
-	fmt.Println(string(output))
-}
 
-func (cr *ChangeRemote) remoteInfo(remote string) (string, string, string) {
-	if strings.Contains(remote, "git@") {
-		remoteSplit := strings.SplitN(remote, ":", 2)
-		if len(remoteSplit) != 2 {
-			err := errors.New("invalid remote URL format")
-			utils.HandleError(err, cr.Debug, nil)
-			return "", "", ""
+	if protocol == "ssh" {
+		user := "git"
+		if protocol == remote.Protocol {
+			hostPart, _, _ := strings.Cut(remote.URL, ":")
+			if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+				user = hostPart[:at]
+			}
 		}
 
-		parts := strings.SplitN(remoteSplit[1], "/", 2)
-		if len(parts) != 2 {
-			err := errors.New("invalid remote URL format")
-			utils.HandleError(err, cr.Debug, nil)
-			return "", "", ""
+		if rw.newHost == "" {
+			host = (&url.URL{Host: host}).Hostname()
 		}
 
-		return remoteSplit[0], parts[0], parts[1]
-	} else if strings.Contains(remote, "https://") {
-		remoteSplit := strings.SplitN(remote, "/", -1)
-		host := remoteSplit[0] + "//" + remoteSplit[2]
+		if (&url.URL{Host: host}).Port() != "" {
+			return "ssh://" + user + "@" + host + "/" + repoPath, true
+		}
 
-		return host, remoteSplit[3], remoteSplit[4]
-	} else {
-		err := errors.New("invalid remote URL format")
-		utils.HandleError(err, cr.Debug, nil)
-		return "", "", ""
+		return user + "@" + host + ":" + repoPath, true
+	}
+
+	return protocol + "://" + host + "/" + repoPath, true
+}
+
+func matches(value, old string) bool {
+	return old == "" || strings.EqualFold(value, old)
+}
+
+func replace(value, newValue string) string {
+	if newValue == "" {
+		return value
+	}
+
+	return newValue
+}
+
+func printChanges(w io.Writer, changes []change) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tREMOTE\tOLD URL\tNEW URL")
+	for _, c := range changes {
+		remote := c.remote
+		if c.push {
+			remote += " (push)"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.name, remote, c.oldURL, c.newURL)
 	}
+	tw.Flush()
 }
@@ -1,211 +1,283 @@
 package changeRemote
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
-	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/emmahsax/go-git-helper/internal/commandline"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/git"
 )
 
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
 type MockExecutor struct {
 	Args    []string
 	Command string
 	Debug   bool
-	Output  []byte
+	Outputs map[string]string
+	Run     []string
 }
 
 func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
 	me.Command = command
 	me.Args = args
-	return me.Output, nil
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
 }
 
-func Test_execute(t *testing.T) {
-	tmpDir := t.TempDir()
-	err := os.Chdir(tmpDir)
+// newTestChangeRemote makes a directory of repositories with the given
+// remotes, keyed by the repository's path and then the remote's name.
+func newTestChangeRemote(t *testing.T, oldOwner, newOwner string, repos map[string]map[string]string) (*ChangeRemote, *MockExecutor, string) {
+	root := t.TempDir()
+	originalWorkingDir := executor.WorkingDir
+	t.Cleanup(func() {
+		executor.WorkingDir = originalWorkingDir
+	})
+	executor.WorkingDir = root
+
+	outputs := map[string]string{}
+	for name, remotes := range repos {
+		dir := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+
+		names := []string{}
+		for remote := range remotes {
+			names = append(names, remote)
+		}
+		sort.Strings(names)
 
-	if err != nil {
-		t.Errorf("error was found: %s", err.Error())
+		output := ""
+		for _, remote := range names {
+			url := remotes[remote]
+			output += remote + "\t" + url + " (fetch)\n" + remote + "\t" + url + " (push)\n"
+			outputs["git -C "+dir+" remote set-url "+remote+" "+strings.ReplaceAll(url, oldOwner, newOwner)] = ""
+		}
+		outputs["git -C "+dir+" remote -v"] = output
 	}
 
-	cr := newChangeRemote("oldOwner", "newOwner", true, &MockExecutor{Debug: true})
-	cr.execute()
+	executor := &MockExecutor{Debug: true, Outputs: outputs}
+	return newChangeRemote(oldOwner, newOwner, true, executor), executor, root
 }
 
-func Test_processDir(t *testing.T) {
-	tempDir, err := os.MkdirTemp("", "test")
-	if err != nil {
-		t.Fatal(err)
+func setURLs(executor *MockExecutor) []string {
+	lines := []string{}
+	for _, line := range executor.Run {
+		if strings.Contains(line, " set-url ") {
+			lines = append(lines, line)
+		}
 	}
-	defer os.RemoveAll(tempDir)
+	return lines
+}
 
-	gitDir := filepath.Join(tempDir, ".git")
-	err = os.Mkdir(gitDir, 0755)
-	if err != nil {
-		t.Fatal(err)
-	}
+var testRepos = map[string]map[string]string{
+	"hello":      {"origin": "git@github.com:oldOwner/hello.git", "upstream": "https://github.com/oldOwner/hello.git"},
+	"other":      {"origin": "git@github.com:someoneElse/other.git"},
+	"work/tools": {"origin": "https://github.com/oldOwner/tools"},
+}
+
+func Test_changeRemote(t *testing.T) {
+	cr, executor, root := newTestChangeRemote(t, "oldOwner", "newOwner", testRepos)
 
 	originalAskYesNoQuestion := commandline.AskYesNoQuestion
 	t.Cleanup(func() {
 		commandline.AskYesNoQuestion = originalAskYesNoQuestion
 	})
+	asked := 0
 	commandline.AskYesNoQuestion = func(question string) bool {
+		asked++
 		return true
 	}
 
-	executor := &MockExecutor{Debug: true}
-	cr := newChangeRemote("oldOwner", "newOwner", true, executor)
-	cr.processDir(tempDir, "")
-	args := []string{"remote", "-v"}
+	var out bytes.Buffer
+	if err := cr.changeRemote(&out); err != nil {
+		t.Fatal(err)
+	}
 
-	if cr.Executor.(*MockExecutor).Command != "git" {
-		t.Errorf("unexpected command received: expected %s, but got %s", "git", cr.Executor.(*MockExecutor).Command)
+	if asked != 1 {
+		t.Errorf("expected to be asked once, got %d", asked)
 	}
 
-	if len(executor.Args) != len(args) {
-		t.Errorf("unexpected args received: expected %v, but got %v", args, executor.Args)
+	expected := []string{
+		"git -C " + filepath.Join(root, "hello") + " remote set-url origin git@github.com:newOwner/hello.git",
+		"git -C " + filepath.Join(root, "hello") + " remote set-url upstream https://github.com/newOwner/hello.git",
+	}
+	if strings.Join(setURLs(executor), "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected only the top-level repository's matching remotes to change, got %v", setURLs(executor))
 	}
 
-	for i, v := range executor.Args {
-		if v != args[i] {
-			t.Errorf("unexpected args received: expected %v, but got %v", args, executor.Args)
+	for _, line := range []string{
+		"REPOSITORY  REMOTE    OLD URL",
+		"hello       origin    git@github.com:oldOwner/hello.git      git@github.com:newOwner/hello.git",
+		"Changed 2 remote URL(s)",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("expected the output to contain %q, got:\n%s", line, out.String())
 		}
 	}
 }
 
-func Test_processGitRepository(t *testing.T) {
+func Test_changeRemote_Options(t *testing.T) {
 	tests := []struct {
-		name           string
-		executorOutput []byte
-		expected       map[string]map[string]string
+		name     string
+		setup    func(cr *ChangeRemote)
+		expected int
 	}{
-		{
-			name: "SSH remote",
-			executorOutput: []byte(`origin  git@github.com:oldOwner/repository.git (fetch)
-origin  git@github.com:oldOwner/repository.git (push)
-`),
-			expected: map[string]map[string]string{
-				"repository.git": {
-					"plainRemote": "git@github.com:oldOwner/repository.git",
-					"remoteName":  "origin",
-					"host":        "git@github.com",
-				},
-			},
-		},
-		{
-			name: "HTTP remote",
-			executorOutput: []byte(`origin  https://github.com/oldOwner/repository.git (fetch)
-origin  https://github.com/oldOwner/repository.git (push)
-`),
-			expected: map[string]map[string]string{
-				"repository.git": {
-					"plainRemote": "https://github.com/oldOwner/repository.git",
-					"remoteName":  "origin",
-					"host":        "https://github.com",
-				},
-			},
-		},
-		{
-			"wrong owner",
-			[]byte(`origin  https://github.com/randomOwner/repository.git (fetch)
-origin  https://github.com/randomOwner/repository.git (push)
-`),
-			map[string]map[string]string{},
-		},
+		{name: "yes", setup: func(cr *ChangeRemote) { cr.Yes = true }, expected: 2},
+		{name: "recursive", setup: func(cr *ChangeRemote) { cr.Yes, cr.Depth = true, 0 }, expected: 3},
+		{name: "depth", setup: func(cr *ChangeRemote) { cr.Yes, cr.Depth = true, 2 }, expected: 3},
+		{name: "remote", setup: func(cr *ChangeRemote) { cr.Yes, cr.Depth, cr.Remotes = true, 0, []string{"upstream"} }, expected: 1},
+		{name: "dry run", setup: func(cr *ChangeRemote) { cr.DryRun = true }, expected: 0},
+		{name: "declined", setup: func(cr *ChangeRemote) {}, expected: 0},
 	}
 
+	originalAskYesNoQuestion := commandline.AskYesNoQuestion
+	t.Cleanup(func() {
+		commandline.AskYesNoQuestion = originalAskYesNoQuestion
+	})
+
 	for _, test := range tests {
-		executor := &MockExecutor{
-			Debug:  true,
-			Output: test.executorOutput,
+		cr, executor, _ := newTestChangeRemote(t, "oldOwner", "newOwner", testRepos)
+		test.setup(cr)
+
+		asked := false
+		commandline.AskYesNoQuestion = func(question string) bool {
+			asked = true
+			return false
+		}
+
+		var out bytes.Buffer
+		if err := cr.changeRemote(&out); err != nil {
+			t.Fatal(err)
 		}
 
-		cr := newChangeRemote("oldOwner", "newOwner", true, executor)
-		fullRemoteInfo := cr.processGitRepository()
+		if len(setURLs(executor)) != test.expected {
+			t.Errorf("%s: expected %d remotes to change, got %v", test.name, test.expected, setURLs(executor))
+		}
+
+		if asked != (test.name == "declined") {
+			t.Errorf("%s: expected to be asked only when neither --yes nor --dry-run is passed", test.name)
+		}
 
-		if !reflect.DeepEqual(fullRemoteInfo, test.expected) {
-			t.Errorf("expected %v, but got %v", test.expected, fullRemoteInfo)
+		if !strings.Contains(out.String(), "OLD URL") {
+			t.Errorf("%s: expected a preview, got:\n%s", test.name, out.String())
 		}
 	}
 }
 
-func Test_processRemote(t *testing.T) {
-	tests := []struct {
-		name         string
-		url          string
-		host         string
-		repo         string
-		remoteName   string
-		expectedArgs []string
-	}{
-		{
-			name:         "SSH remote",
-			url:          "git@github.com:oldOwner/repo.git",
-			host:         "git@github.com",
-			repo:         "repo.git",
-			remoteName:   "origin",
-			expectedArgs: []string{"remote", "set-url", "origin", "git@github.com:newOwner/repo.git"},
-		},
-		{
-			name:         "HTTP remote",
-			url:          "https://github.com/oldOwner/repo.git",
-			host:         "https://github.com",
-			repo:         "repo.git",
-			remoteName:   "origin",
-			expectedArgs: []string{"remote", "set-url", "origin", "https://github.com/newOwner/repo.git"},
-		},
-	}
-
-	executor := &MockExecutor{Debug: true}
-	cr := newChangeRemote("oldOwner", "newOwner", true, executor)
+func Test_changeRemote_PushURLs(t *testing.T) {
+	cr, executor, root := newTestChangeRemote(t, "oldOwner", "newOwner", map[string]map[string]string{})
+	dir := filepath.Join(root, "hello")
+	os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	executor.Outputs = map[string]string{
+		"git -C " + dir + " remote -v": "origin\tgit@github.com:oldOwner/hello.git (fetch)\n" +
+			"origin\tgit@github.com:oldOwner/hello-push.git (push)\n" +
+			"upstream\tgit@github.com:oldOwner/hello.git (fetch)\n" +
+			"upstream\tgit@github.com:oldOwner/hello.git (push)\n",
+		"git -C " + dir + " remote set-url origin git@github.com:newOwner/hello.git":                                                          "",
+		"git -C " + dir + " remote set-url --push origin git@github.com:newOwner/hello-push.git ^git@github\\.com:oldOwner/hello-push\\.git$": "",
+		"git -C " + dir + " remote set-url upstream git@github.com:newOwner/hello.git":                                                        "",
+	}
+	cr.Yes = true
 
-	for _, test := range tests {
-		cr.processRemote(test.url, test.host, test.repo, test.remoteName)
+	var out bytes.Buffer
+	if err := cr.changeRemote(&out); err != nil {
+		t.Fatal(err)
+	}
 
-		if executor.Command != "git" {
-			t.Errorf("unexpected command received: expected %s, but got %s", "git", executor.Command)
-		}
+	expected := []string{
+		"git -C " + dir + " remote set-url origin git@github.com:newOwner/hello.git",
+		"git -C " + dir + " remote set-url --push origin git@github.com:newOwner/hello-push.git ^git@github\\.com:oldOwner/hello-push\\.git$",
+		"git -C " + dir + " remote set-url upstream git@github.com:newOwner/hello.git",
+	}
+	if strings.Join(setURLs(executor), "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected separate push URLs to change too, got %v", setURLs(executor))
+	}
 
-		if len(executor.Args) != len(test.expectedArgs) {
-			t.Errorf("unexpected args received: expected %v, but got %v", test.expectedArgs, executor.Args)
-		}
+	if !strings.Contains(out.String(), "origin (push)") {
+		t.Errorf("expected the push URL to be labelled, got:\n%s", out.String())
+	}
+}
 
-		for i, v := range executor.Args {
-			if v != test.expectedArgs[i] {
-				t.Errorf("unexpected args received: expected %v, but got %v", test.expectedArgs, executor.Args)
-			}
-		}
+func Test_changeRemote_InsideRepository(t *testing.T) {
+	cr, executor, root := newTestChangeRemote(t, "oldOwner", "newOwner", testRepos)
+	os.MkdirAll(filepath.Join(root, ".git"), 0755)
+	executor.Outputs["git -C "+root+" remote -v"] = "origin\tgit@github.com:oldOwner/root.git (fetch)\norigin\tgit@github.com:oldOwner/root.git (push)\n"
+	executor.Outputs["git -C "+root+" remote set-url origin git@github.com:newOwner/root.git"] = ""
+	cr.Yes = true
+
+	var out bytes.Buffer
+	if err := cr.changeRemote(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"git -C " + root + " remote set-url origin git@github.com:newOwner/root.git"}
+	if strings.Join(setURLs(executor), "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected only the repository's own remotes to change when run inside one, got %v", setURLs(executor))
 	}
 }
 
-func Test_remoteInfo(t *testing.T) {
+func Test_rewrite(t *testing.T) {
 	tests := []struct {
-		name     string
-		remote   string
-		expected []string
+		cr  *ChangeRemote
+		err string
 	}{
-		{
-			name:     "SSH remote",
-			remote:   "git@github.com:oldOwner/repo.git",
-			expected: []string{"git@github.com", "oldOwner", "repo.git"},
-		},
-		{
-			name:     "HTTPS remote",
-			remote:   "https://github.com/oldOwner/repo.git",
-			expected: []string{"https://github.com", "oldOwner", "repo.git"},
-		},
+		{cr: &ChangeRemote{}, err: "to say what to change"},
+		{cr: &ChangeRemote{Protocol: "ftp"}, err: "--protocol must be ssh or https"},
+		{cr: &ChangeRemote{Host: "github.com"}, err: "--host must be old=new"},
+		{cr: &ChangeRemote{Repo: "=hello"}, err: "--repo must be old=new"},
+		{cr: &ChangeRemote{Host: "github.com=github.example.com", Protocol: "https"}},
+	}
+
+	for _, test := range tests {
+		_, err := test.cr.rewrite()
+		if (test.err == "" && err != nil) || (test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err))) {
+			t.Errorf("expected %q for %+v, got %v", test.err, test.cr, err)
+		}
 	}
+}
 
-	cr := newChangeRemote("oldOwner", "newOwner", false, &MockExecutor{Debug: false})
+func Test_apply(t *testing.T) {
+	tests := []struct {
+		url      string
+		rw       rewrite
+		expected string
+	}{
+		{url: "git@github.com:oldOwner/repo.git", rw: rewrite{oldOwner: "oldowner", newOwner: "newOwner"}, expected: "git@github.com:newOwner/repo.git"},
+		{url: "https://github.com/oldOwner/repo", rw: rewrite{oldOwner: "oldOwner", newOwner: "newOwner"}, expected: "https://github.com/newOwner/repo"},
+		{url: "git@github.com:octocat/repo.git", rw: rewrite{oldOwner: "oldOwner", newOwner: "newOwner"}},
+		{url: "git@github.com:octocat/repo.git", rw: rewrite{oldHost: "github.com", newHost: "github.example.com"}, expected: "git@github.example.com:octocat/repo.git"},
+		{url: "ssh://git@gitlab.com:2222/group/sub/repo.git", rw: rewrite{oldHost: "gitlab.com", newHost: "gitlab.example.com"}, expected: "ssh://git@gitlab.example.com/group/sub/repo.git"},
+		{url: "ssh://git@gitlab.com:2222/group/sub/repo.git", rw: rewrite{oldOwner: "group/sub", newOwner: "group/other"}, expected: "ssh://git@gitlab.com:2222/group/other/repo.git"},
+		{url: "git@github.com:octocat/repo.git", rw: rewrite{protocol: "https"}, expected: "https://github.com/octocat/repo.git"},
+		{url: "https://github.com/octocat/repo", rw: rewrite{protocol: "ssh"}, expected: "git@github.com:octocat/repo"},
+		{url: "org-123@github.com:octocat/repo.git", rw: rewrite{oldRepo: "repo", newRepo: "renamed"}, expected: "org-123@github.com:octocat/renamed.git"},
+		{url: "https://github.com/octocat/repo.git", rw: rewrite{oldRepo: "other", newRepo: "renamed"}},
+		{url: "git@github.com:oldOwner/repo.git", rw: rewrite{oldOwner: "oldOwner", newOwner: "newOwner", oldHost: "github.com", newHost: "ghe.example.com", protocol: "https"}, expected: "https://ghe.example.com/newOwner/repo.git"},
+		{url: "https://gitlab.example.com:8443/group/repo.git", rw: rewrite{protocol: "ssh"}, expected: "git@gitlab.example.com:group/repo.git"},
+		{url: "https://gitlab.example.com:8443/group/repo.git", rw: rewrite{oldHost: "gitlab.example.com:8443", newHost: "gitlab.example.com:2222", protocol: "ssh"}, expected: "ssh://git@gitlab.example.com:2222/group/repo.git"},
+	}
 
 	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			host, owner, repo := cr.remoteInfo(test.remote)
-			if host != test.expected[0] || owner != test.expected[1] || repo != test.expected[2] {
-				t.Errorf("expected %v, but got %v, %v, %v", test.expected, host, owner, repo)
-			}
-		})
+		remote, err := git.ParseRemoteURL(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, ok := test.rw.apply(remote)
+		if actual != test.expected || ok != (test.expected != "") {
+			t.Errorf("expected %q for %s with %+v, got %q", test.expected, test.url, test.rw, actual)
+		}
 	}
 }
@@ -0,0 +1,214 @@
+package checkoutPr
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// codeRequestPath matches the end of a pull request or merge request URL's
+// path, after the repository.
+var codeRequestPath = regexp.MustCompile(`^/(.+?)(?:/-)?/(?:pull|merge_requests)/(\d+)(?:/.*)?$`)
+
+type CheckoutPr struct {
+	Debug    bool
+	Executor executor.ExecutorInterface
+	Target   string
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "checkout-pr [number|url]",
+		Short:                 "Checks out a pull request or merge request locally, or updates it if it's already checked out",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newCheckoutPr(args[0], debug, executor.NewExecutor(debug)).execute()
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+
+	return cmd
+}
+
+func newCheckoutPr(target string, debug bool, executor executor.ExecutorInterface) *CheckoutPr {
+	return &CheckoutPr{
+		Debug:    debug,
+		Executor: executor,
+		Target:   target,
+	}
+}
+
+func (c *CheckoutPr) execute() {
+	err := c.checkout()
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+}
+
+func (c *CheckoutPr) checkout() error {
+	g := git.NewGit(c.Debug, c.Executor)
+	number, remote, err := c.resolve(g)
+	if err != nil {
+		return err
+	}
+
+	client, err := forge.NewClient(c.Debug, remote)
+	if err != nil {
+		return err
+	}
+
+	cr, err := client.CodeRequest(number)
+	if err != nil {
+		return err
+	}
+
+	upstreamRemote, upstreamMerge := tracking(cr, remote)
+	branch := c.branchName(g, cr, upstreamRemote, upstreamMerge)
+
+	err = g.FetchRef(remote.Name, cr.HeadRef)
+	if err != nil {
+		return fmt.Errorf("could not fetch %s from %s: %w", cr.HeadRef, remote.Name, err)
+	}
+
+	if g.BranchExists(branch) {
+		if g.CurrentBranch() != branch {
+			g.Checkout(branch)
+		}
+
+		err = g.FastForward("FETCH_HEAD")
+		if err != nil {
+			return fmt.Errorf("%s has commits that aren't in %s, so it couldn't be updated; push or reset them first", branch, cr.URL)
+		}
+	} else {
+		err = g.CreateBranchAt(branch, "FETCH_HEAD")
+		if err != nil {
+			return err
+		}
+		g.Checkout(branch)
+	}
+
+	err = g.SetUpstream(branch, upstreamRemote, upstreamMerge)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Checked out %s as %s\n", cr.URL, branch)
+	if isFork(cr, remote) && !cr.MaintainerCanModify {
+		fmt.Println("It's from a fork that doesn't allow edits from maintainers, so you won't be able to push to it")
+	} else if branch != cr.Head {
+		// git push refuses to push a branch to one with a different name
+		fmt.Printf("Its name is different from %s, so push to it with:\n  git push %s HEAD:%s\n", cr.Head, upstreamRemote, cr.Head)
+	}
+
+	return nil
+}
+
+// resolve returns the code request's number and the remote it belongs to,
+// which is origin unless a URL for another remote's repository is given.
+func (c *CheckoutPr) resolve(g *git.Git) (int, *git.Remote, error) {
+	if number, err := strconv.Atoi(strings.TrimLeft(c.Target, "#!")); err == nil && number > 0 {
+		remote, err := g.Remote("origin")
+		return number, remote, err
+	}
+
+	u, err := url.Parse(c.Target)
+	if err != nil || u.Host == "" {
+		return 0, nil, fmt.Errorf("%s isn't a number or a pull request or merge request URL", c.Target)
+	}
+
+	match := codeRequestPath.FindStringSubmatch(u.Path)
+	if match == nil {
+		return 0, nil, fmt.Errorf("%s isn't a pull request or merge request URL", c.Target)
+	}
+
+	number, _ := strconv.Atoi(match[2])
+	for _, remote := range g.PushRemotes() {
+		if strings.EqualFold(remote.Host, u.Host) && strings.EqualFold(remote.FullName(), match[1]) {
+			return number, remote, nil
+		}
+	}
+
+	return 0, nil, errors.New("no remote points at " + u.Host + "/" + match[1] + ", add one with git remote add first")
+}
+
+// branchName names the local branch after the head branch. It's prefixed with
+// the head repository's owner when it would otherwise be the default branch,
+// or a local branch that tracks something else, whether or not it's a fork,
+// and numbered when the prefixed name is taken too.
+func (c *CheckoutPr) branchName(g *git.Git, cr *forge.CodeRequest, upstreamRemote, upstreamMerge string) string {
+	if !taken(g, cr.Head, upstreamRemote, upstreamMerge) && cr.Head != g.DefaultBranch() {
+		return cr.Head
+	}
+
+	owner := path.Dir(cr.HeadRepo)
+	if cr.HeadRepo == "" {
+		owner = "fork"
+	}
+
+	prefixed := strings.ReplaceAll(owner, "/", "-") + "-" + cr.Head
+	branch := prefixed
+	for i := 2; taken(g, branch, upstreamRemote, upstreamMerge); i++ {
+		branch = prefixed + "-" + strconv.Itoa(i)
+	}
+
+	return branch
+}
+
+// taken reports whether a local branch with the name exists and tracks
+// something other than the code request's head.
+func taken(g *git.Git, branch, upstreamRemote, upstreamMerge string) bool {
+	if !g.BranchExists(branch) {
+		return false
+	}
+
+	existingRemote, existingMerge := g.Upstream(branch)
+	return existingRemote != upstreamRemote || existingMerge != upstreamMerge
+}
+
+// tracking returns the remote and ref the local branch should pull from and
+// push to. A fork that maintainers can edit is tracked by URL, so pushing
+// goes straight to the fork.
+func tracking(cr *forge.CodeRequest, remote *git.Remote) (string, string) {
+	if !isFork(cr, remote) {
+		return remote.Name, "refs/heads/" + cr.Head
+	}
+
+	if cr.MaintainerCanModify && cr.HeadRepo != "" {
+		return forkURL(remote, cr.HeadRepo), "refs/heads/" + cr.Head
+	}
+
+	return remote.Name, cr.HeadRef
+}
+
+func isFork(cr *forge.CodeRequest, remote *git.Remote) bool {
+	return !strings.EqualFold(cr.HeadRepo, remote.FullName())
+}
+
+// forkURL swaps the repository in the remote's URL for the fork, keeping the
+// host and protocol.
+func forkURL(remote *git.Remote, fork string) string {
+	i := strings.LastIndex(remote.URL, remote.FullName())
+	if i < 0 {
+		return remote.URL
+	}
+
+	return remote.URL[:i] + fork + remote.URL[i+len(remote.FullName()):]
+}
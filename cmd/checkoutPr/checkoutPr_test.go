@@ -0,0 +1,261 @@
+package checkoutPr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Outputs map[string]string
+	Run     []string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+type fakeClient struct {
+	forge.Client
+	requests map[int]*forge.CodeRequest
+}
+
+func (c *fakeClient) CodeRequest(number int) (*forge.CodeRequest, error) {
+	cr, ok := c.requests[number]
+	if !ok {
+		return nil, errors.New("404 Not Found")
+	}
+	return cr, nil
+}
+
+func newTestCheckoutPr(t *testing.T, target string, cr *forge.CodeRequest, outputs map[string]string) (*CheckoutPr, *MockExecutor) {
+	client := &fakeClient{requests: map[int]*forge.CodeRequest{cr.Number: cr}}
+	originalNewClient := forge.NewClient
+	t.Cleanup(func() {
+		forge.NewClient = originalNewClient
+	})
+	forge.NewClient = func(debug bool, remote *git.Remote) (forge.Client, error) {
+		return client, nil
+	}
+
+	base := map[string]string{
+		"git remote get-url --push origin":          "git@github.com:octocat/hello.git\n",
+		"git remote -v":                             "origin\tgit@github.com:octocat/hello.git (fetch)\norigin\tgit@github.com:octocat/hello.git (push)\n",
+		"git symbolic-ref refs/remotes/origin/HEAD": "refs/remotes/origin/main\n",
+		"git branch":                                "* main\n",
+	}
+	for line, output := range outputs {
+		base[line] = output
+	}
+
+	executor := &MockExecutor{Debug: true, Outputs: base}
+	return newCheckoutPr(target, true, executor), executor
+}
+
+func ran(executor *MockExecutor, line string) bool {
+	for _, run := range executor.Run {
+		if run == line {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_checkout(t *testing.T) {
+	cr := &forge.CodeRequest{Head: "feature", HeadRef: "refs/pull/2/head", HeadRepo: "octocat/hello", Number: 2, URL: "https://github.com/octocat/hello/pull/2"}
+	c, executor := newTestCheckoutPr(t, "2", cr, map[string]string{
+		"git fetch origin refs/pull/2/head":                  "",
+		"git branch --no-track feature FETCH_HEAD":           "",
+		"git checkout feature":                               "",
+		"git config branch.feature.remote origin":            "",
+		"git config branch.feature.merge refs/heads/feature": "",
+	})
+
+	if err := c.checkout(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"git fetch origin refs/pull/2/head", "git branch --no-track feature FETCH_HEAD", "git checkout feature", "git config branch.feature.merge refs/heads/feature"} {
+		if !ran(executor, line) {
+			t.Errorf("expected %q to be run, got %v", line, executor.Run)
+		}
+	}
+}
+
+func Test_checkout_Update(t *testing.T) {
+	cr := &forge.CodeRequest{Head: "feature", HeadRef: "refs/merge-requests/4/head", HeadRepo: "octocat/hello", Number: 4}
+	c, executor := newTestCheckoutPr(t, "https://github.com/octocat/hello/pull/4/files", cr, map[string]string{
+		"git fetch origin refs/merge-requests/4/head":        "",
+		"git rev-parse --verify -q refs/heads/feature":       "abc123\n",
+		"git config --get branch.feature.remote":             "origin\n",
+		"git config --get branch.feature.merge":              "refs/heads/feature\n",
+		"git branch":                                         "  main\n* feature\n",
+		"git merge --ff-only FETCH_HEAD":                     "",
+		"git config branch.feature.remote origin":            "",
+		"git config branch.feature.merge refs/heads/feature": "",
+	})
+
+	if err := c.checkout(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ran(executor, "git merge --ff-only FETCH_HEAD") || ran(executor, "git checkout feature") {
+		t.Errorf("expected the checked out branch to be fast-forwarded, got %v", executor.Run)
+	}
+
+	delete(executor.Outputs, "git merge --ff-only FETCH_HEAD")
+	if err := c.checkout(); err == nil || !strings.Contains(err.Error(), "has commits that aren't in") {
+		t.Errorf("expected an error about diverged commits, got %v", err)
+	}
+}
+
+func Test_checkout_Fork(t *testing.T) {
+	cr := &forge.CodeRequest{Head: "main", HeadRef: "refs/pull/3/head", HeadRepo: "hubot/hello", MaintainerCanModify: true, Number: 3}
+	c, executor := newTestCheckoutPr(t, "#3", cr, map[string]string{
+		"git fetch origin refs/pull/3/head":                                  "",
+		"git branch --no-track hubot-main FETCH_HEAD":                        "",
+		"git checkout hubot-main":                                            "",
+		"git config branch.hubot-main.remote git@github.com:hubot/hello.git": "",
+		"git config branch.hubot-main.merge refs/heads/main":                 "",
+	})
+
+	if err := c.checkout(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ran(executor, "git config branch.hubot-main.remote git@github.com:hubot/hello.git") {
+		t.Errorf("expected the fork's branch to track the fork, got %v", executor.Run)
+	}
+}
+
+func Test_checkout_SameRepositoryNameTaken(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    string
+		outputs map[string]string
+	}{
+		{
+			name: "default branch",
+			head: "main",
+		},
+		{
+			name: "branch tracking something else",
+			head: "feature",
+			outputs: map[string]string{
+				"git rev-parse --verify -q refs/heads/feature": "abc123\n",
+				"git config --get branch.feature.remote":       "origin\n",
+				"git config --get branch.feature.merge":        "refs/heads/other\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := &forge.CodeRequest{Head: test.head, HeadRef: "refs/pull/5/head", HeadRepo: "octocat/hello", Number: 5}
+			outputs := map[string]string{
+				"git fetch origin refs/pull/5/head":                                         "",
+				"git branch --no-track octocat-" + test.head + " FETCH_HEAD":                "",
+				"git checkout octocat-" + test.head:                                         "",
+				"git config branch.octocat-" + test.head + ".remote origin":                 "",
+				"git config branch.octocat-" + test.head + ".merge refs/heads/" + test.head: "",
+			}
+			for line, output := range test.outputs {
+				outputs[line] = output
+			}
+
+			c, executor := newTestCheckoutPr(t, "5", cr, outputs)
+			if err := c.checkout(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !ran(executor, "git checkout octocat-"+test.head) || ran(executor, "git merge --ff-only FETCH_HEAD") {
+				t.Errorf("expected a new octocat-%s branch, got %v", test.head, executor.Run)
+			}
+		})
+	}
+}
+
+func Test_checkout_PrefixedNameTaken(t *testing.T) {
+	cr := &forge.CodeRequest{Head: "main", HeadRef: "refs/pull/6/head", HeadRepo: "hubot/hello", MaintainerCanModify: true, Number: 6}
+	c, executor := newTestCheckoutPr(t, "6", cr, map[string]string{
+		"git fetch origin refs/pull/6/head":                                    "",
+		"git rev-parse --verify -q refs/heads/hubot-main":                      "abc123\n",
+		"git config --get branch.hubot-main.remote":                            "origin\n",
+		"git config --get branch.hubot-main.merge":                             "refs/heads/other\n",
+		"git branch --no-track hubot-main-2 FETCH_HEAD":                        "",
+		"git checkout hubot-main-2":                                            "",
+		"git config branch.hubot-main-2.remote git@github.com:hubot/hello.git": "",
+		"git config branch.hubot-main-2.merge refs/heads/main":                 "",
+	})
+
+	if err := c.checkout(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ran(executor, "git checkout hubot-main-2") || ran(executor, "git merge --ff-only FETCH_HEAD") {
+		t.Errorf("expected a new hubot-main-2 branch, got %v", executor.Run)
+	}
+}
+
+func Test_resolve(t *testing.T) {
+	tests := []struct {
+		target   string
+		expected int
+		err      bool
+	}{
+		{target: "12", expected: 12},
+		{target: "https://github.com/octocat/hello/pull/12", expected: 12},
+		{target: "https://gitlab.com/group/sub/project/-/merge_requests/7", expected: 7},
+		{target: "https://github.com/octocat/other/pull/12", err: true},
+		{target: "https://github.com/octocat/hello/issues/12", err: true},
+		{target: "feature", err: true},
+	}
+
+	for _, test := range tests {
+		cr := &forge.CodeRequest{Number: 1}
+		c, executor := newTestCheckoutPr(t, test.target, cr, nil)
+		executor.Outputs["git remote -v"] += "upstream\thttps://gitlab.com/group/sub/project.git (push)\n"
+
+		number, _, err := c.resolve(git.NewGit(true, executor))
+		if (err != nil) != test.err || number != test.expected {
+			t.Errorf("expected %d for %s, got %d (%v)", test.expected, test.target, number, err)
+		}
+	}
+}
+
+func Test_tracking(t *testing.T) {
+	remote := &git.Remote{Name: "origin", Owner: "octocat", Repo: "hello", URL: "https://github.com/octocat/hello.git"}
+	tests := []struct {
+		cr             *forge.CodeRequest
+		expectedRemote string
+		expectedMerge  string
+	}{
+		{cr: &forge.CodeRequest{Head: "feature", HeadRepo: "octocat/hello"}, expectedRemote: "origin", expectedMerge: "refs/heads/feature"},
+		{cr: &forge.CodeRequest{Head: "feature", HeadRepo: "hubot/hello", MaintainerCanModify: true}, expectedRemote: "https://github.com/hubot/hello.git", expectedMerge: "refs/heads/feature"},
+		{cr: &forge.CodeRequest{Head: "feature", HeadRef: "refs/pull/2/head", HeadRepo: "hubot/hello"}, expectedRemote: "origin", expectedMerge: "refs/pull/2/head"},
+	}
+
+	for _, test := range tests {
+		remoteName, merge := tracking(test.cr, remote)
+		if remoteName != test.expectedRemote || merge != test.expectedMerge {
+			t.Errorf("expected %s %s, got %s %s", test.expectedRemote, test.expectedMerge, remoteName, merge)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package checks
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/commandline"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// logLines is how much of a failed check's log to print.
+	logLines = 20
+	// maxEmptyPolls is how many times to look for checks while watching
+	// before deciding the commit doesn't have any.
+	maxEmptyPolls = 6
+	// requestsPerPoll is how many API requests each poll makes.
+	requestsPerPoll = 2
+)
+
+type Checks struct {
+	Debug    bool
+	Executor executor.ExecutorInterface
+	Interval time.Duration
+	Logs     bool
+	Sleep    func(time.Duration)
+	Watch    bool
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug    bool
+		interval time.Duration
+		logs     bool
+		watch    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "checks",
+		Short:                 "Shows the CI checks or pipeline jobs for the current commit",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := newChecks(watch, logs, debug, executor.NewExecutor(debug))
+			c.Interval = interval
+			c.execute()
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().DurationVar(&interval, "interval", 10*time.Second, "how often to poll while watching, at the least")
+	cmd.Flags().BoolVar(&logs, "logs", false, "prints the end of each failed check's log")
+	cmd.Flags().BoolVar(&watch, "watch", false, "keeps polling until every check has finished")
+
+	return cmd
+}
+
+func newChecks(watch, logs, debug bool, executor executor.ExecutorInterface) *Checks {
+	return &Checks{
+		Debug:    debug,
+		Executor: executor,
+		Interval: 10 * time.Second,
+		Logs:     logs,
+		Sleep:    time.Sleep,
+		Watch:    watch,
+	}
+}
+
+func (c *Checks) execute() {
+	update := func(text string) { fmt.Print(text) }
+	stop := func() {}
+	if c.Watch {
+		update, stop = commandline.NewLiveArea()
+	}
+
+	err := c.checks(os.Stdout, update, stop)
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+}
+
+// checks shows the checks through update, which redraws them in place while
+// watching, and calls stop once they're final.
+func (c *Checks) checks(w io.Writer, update func(string), stop func()) error {
+	g := git.NewGit(c.Debug, c.Executor)
+	sha, err := g.HeadCommit()
+	if err != nil {
+		return err
+	}
+
+	remote, err := g.Remote("origin")
+	if err != nil {
+		return err
+	}
+
+	client, err := forge.NewClient(c.Debug, remote)
+	if err != nil {
+		return err
+	}
+
+	var results *forge.CheckResults
+	for polls := 1; ; polls++ {
+		results, err = client.Checks(sha)
+		if err != nil {
+			until, limited := forge.RetryAfter(err)
+			if !c.Watch || !limited {
+				stop()
+				return err
+			}
+
+			update(fmt.Sprintf("Rate limited, trying again at %s\n", until.Format(time.Kitchen)))
+			c.Sleep(time.Until(until))
+			continue
+		}
+
+		update(summary(sha, results.Checks))
+		if !c.Watch || !waiting(results.Checks, polls) {
+			break
+		}
+
+		c.Sleep(pollInterval(c.Interval, results.RateLimit, time.Now()))
+	}
+	stop()
+
+	failed := []forge.Check{}
+	for _, check := range results.Checks {
+		if check.State == forge.CheckFailure {
+			failed = append(failed, check)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	if c.Logs {
+		for _, check := range failed {
+			c.printLog(w, client, check)
+		}
+	}
+
+	return fmt.Errorf("%d check(s) failed", len(failed))
+}
+
+func (c *Checks) printLog(w io.Writer, client forge.Client, check forge.Check) {
+	log, err := client.CheckLog(check)
+	if err != nil {
+		fmt.Fprintf(w, "\nCould not get the log for %s: %v\n", check.Name, err)
+		return
+	}
+
+	fmt.Fprintf(w, "\nLast %d lines of %s:\n%s\n", logLines, check.Name, tail(log, logLines))
+}
+
+// waiting reports whether there are checks still to finish, or none yet even
+// though they're probably about to start.
+func waiting(checks []forge.Check, polls int) bool {
+	if len(checks) == 0 {
+		return polls < maxEmptyPolls
+	}
+
+	for _, check := range checks {
+		if check.State == forge.CheckPending {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pollInterval waits at least base between polls, and longer when polling
+// that often would use more than half of what's left of the rate limit
+// before it resets. Once it's used up, it waits for the reset.
+func pollInterval(base time.Duration, rate *forge.RateLimit, now time.Time) time.Duration {
+	if rate == nil {
+		return base
+	}
+
+	untilReset := rate.Reset.Sub(now)
+	if untilReset <= 0 {
+		return base
+	}
+
+	polls := rate.Remaining / (2 * requestsPerPoll)
+	if polls < 1 {
+		return untilReset + time.Second
+	}
+
+	return max(base, untilReset/time.Duration(polls))
+}
+
+func summary(sha string, checks []forge.Check) string {
+	if len(checks) == 0 {
+		return fmt.Sprintf("No checks for %s yet\n", shortSHA(sha))
+	}
+
+	counts := map[string]int{}
+	for _, check := range checks {
+		counts[check.State]++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Checks for %s: %d passed, %d failed, %d pending\n", shortSHA(sha), counts[forge.CheckSuccess], counts[forge.CheckFailure], counts[forge.CheckPending])
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, check := range checks {
+		fmt.Fprintf(tw, "  [%s]\t%s\t%s\n", label(check.State), check.Name, check.URL)
+	}
+	tw.Flush()
+
+	return b.String()
+}
+
+func label(state string) string {
+	switch state {
+	case forge.CheckSuccess:
+		return "pass"
+	case forge.CheckFailure:
+		return "fail"
+	default:
+		return "wait"
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+
+	return sha
+}
+
+func tail(log string, lines int) string {
+	all := strings.Split(strings.TrimRight(log, "\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+
+	return strings.Join(all, "\n")
+}
@@ -0,0 +1,169 @@
+package checks
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Outputs map[string]string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+// fakeClient returns each of polls in turn, then the last one from then on.
+type fakeClient struct {
+	forge.Client
+	calls int
+	polls []*forge.CheckResults
+}
+
+func (c *fakeClient) Checks(sha string) (*forge.CheckResults, error) {
+	poll := c.polls[min(c.calls, len(c.polls)-1)]
+	c.calls++
+	if poll == nil {
+		return nil, errors.New("403 API rate limit exceeded")
+	}
+	return poll, nil
+}
+
+func (c *fakeClient) CheckLog(check forge.Check) (string, error) {
+	return strings.Repeat("setup\n", 30) + "FAIL: " + check.Name + "\n", nil
+}
+
+func newTestChecks(t *testing.T, watch bool, polls ...*forge.CheckResults) (*Checks, *fakeClient, *[]time.Duration) {
+	client := &fakeClient{polls: polls}
+	originalNewClient := forge.NewClient
+	t.Cleanup(func() {
+		forge.NewClient = originalNewClient
+	})
+	forge.NewClient = func(debug bool, remote *git.Remote) (forge.Client, error) {
+		return client, nil
+	}
+
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git rev-parse HEAD":               "abc1234567890\n",
+		"git remote get-url --push origin": "git@github.com:octocat/hello.git\n",
+	}}
+
+	slept := []time.Duration{}
+	c := newChecks(watch, true, true, executor)
+	c.Sleep = func(d time.Duration) { slept = append(slept, d) }
+	return c, client, &slept
+}
+
+func Test_checks(t *testing.T) {
+	c, client, _ := newTestChecks(t, false, &forge.CheckResults{Checks: []forge.Check{
+		{Name: "lint", State: forge.CheckSuccess},
+		{Name: "test", State: forge.CheckPending, URL: "https://ci.example.com/1"},
+	}})
+
+	var out bytes.Buffer
+	var shown string
+	if err := c.checks(&out, func(text string) { shown = text }, func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.calls != 1 {
+		t.Errorf("expected a single poll without --watch, got %d", client.calls)
+	}
+
+	for _, expected := range []string{"Checks for abc1234: 1 passed, 0 failed, 1 pending", "[pass]  lint", "[wait]  test  https://ci.example.com/1"} {
+		if !strings.Contains(shown, expected) {
+			t.Errorf("expected the summary to contain %q, got:\n%s", expected, shown)
+		}
+	}
+}
+
+func Test_checks_Watch(t *testing.T) {
+	c, client, slept := newTestChecks(t, true,
+		&forge.CheckResults{Checks: []forge.Check{}},
+		&forge.CheckResults{Checks: []forge.Check{{Name: "test", State: forge.CheckPending}}},
+		&forge.CheckResults{Checks: []forge.Check{{Name: "test", State: forge.CheckFailure}, {Name: "lint", State: forge.CheckFailure}}},
+	)
+
+	var out bytes.Buffer
+	updates := 0
+	stopped := false
+	err := c.checks(&out, func(string) { updates++ }, func() { stopped = true })
+	if err == nil || err.Error() != "2 check(s) failed" {
+		t.Errorf("expected an error about the failed checks, got %v", err)
+	}
+
+	if client.calls != 3 || updates != 3 || len(*slept) != 2 || !stopped {
+		t.Errorf("expected to poll until the checks finished, got %d polls, %d updates, %v sleeps", client.calls, updates, *slept)
+	}
+
+	if !strings.Contains(out.String(), "Last 20 lines of test:") || !strings.Contains(out.String(), "FAIL: lint") || strings.Count(out.String(), "setup") != 38 {
+		t.Errorf("expected the end of each failed check's log, got:\n%s", out.String())
+	}
+}
+
+func Test_checks_NoChecks(t *testing.T) {
+	c, client, _ := newTestChecks(t, true, &forge.CheckResults{Checks: []forge.Check{}})
+
+	var shown string
+	if err := c.checks(&bytes.Buffer{}, func(text string) { shown = text }, func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.calls != maxEmptyPolls || !strings.Contains(shown, "No checks for abc1234") {
+		t.Errorf("expected to give up after %d polls, got %d: %s", maxEmptyPolls, client.calls, shown)
+	}
+}
+
+func Test_checks_Error(t *testing.T) {
+	c, _, _ := newTestChecks(t, true, nil)
+
+	if err := c.checks(&bytes.Buffer{}, func(string) {}, func() {}); err == nil {
+		t.Error("expected errors other than GitHub's rate limit errors to stop watching")
+	}
+}
+
+func Test_pollInterval(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		rate     *forge.RateLimit
+		expected time.Duration
+	}{
+		{rate: nil, expected: 10 * time.Second},
+		{rate: &forge.RateLimit{Remaining: 0, Reset: now.Add(-time.Minute)}, expected: 10 * time.Second},
+		{rate: &forge.RateLimit{Remaining: 4000, Reset: now.Add(time.Hour)}, expected: 10 * time.Second},
+		{rate: &forge.RateLimit{Remaining: 40, Reset: now.Add(time.Hour)}, expected: 6 * time.Minute},
+		{rate: &forge.RateLimit{Remaining: 2, Reset: now.Add(time.Minute)}, expected: time.Minute + time.Second},
+	}
+
+	for _, test := range tests {
+		interval := pollInterval(10*time.Second, test.rate, now)
+		if interval != test.expected {
+			t.Errorf("expected %v for %+v, got %v", test.expected, test.rate, interval)
+		}
+	}
+}
+
+func Test_tail(t *testing.T) {
+	if tail("a\nb\nc\n", 2) != "b\nc" || tail("a\n", 5) != "a" {
+		t.Error("expected the last lines of the log")
+	}
+}
@@ -8,24 +8,30 @@ import (
 
 	"github.com/emmahsax/go-git-helper/internal/commandline"
 	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
 	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/forge"
 	"github.com/emmahsax/go-git-helper/internal/git"
 	"github.com/emmahsax/go-git-helper/internal/githubPullRequest"
 	"github.com/emmahsax/go-git-helper/internal/gitlabMergeRequest"
+	"github.com/emmahsax/go-git-helper/internal/stack"
 	"github.com/emmahsax/go-git-helper/internal/utils"
 	"github.com/spf13/cobra"
 )
 
 type CodeRequest struct {
+	Branch          string
 	Debug           bool
 	Executor        executor.ExecutorInterface
 	InteractiveMode bool
+	Stack           bool
 }
 
 func NewCommand() *cobra.Command {
 	var (
 		debug           bool
 		interactiveMode bool
+		stackB          bool
 	)
 
 	cmd := &cobra.Command{
@@ -34,13 +40,16 @@ func NewCommand() *cobra.Command {
 		Args:                  cobra.ExactArgs(0),
 		DisableFlagsInUseLine: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			newCodeRequest(debug, interactiveMode, executor.NewExecutor(debug)).execute()
+			cr := newCodeRequest(debug, interactiveMode, executor.NewExecutor(debug))
+			cr.Stack = stackB
+			cr.execute()
 			return nil
 		},
 	}
 
 	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
 	cmd.Flags().BoolVarP(&interactiveMode, "interactive", "i", true, "interactive mode")
+	cmd.Flags().BoolVar(&stackB, "stack", false, "create one code request per branch in the current stack, each based on its parent")
 
 	return cmd
 }
@@ -54,20 +63,37 @@ func newCodeRequest(debug, interactiveMode bool, executor executor.ExecutorInter
 }
 
 func (cr *CodeRequest) execute() {
-	if cr.isGitHub() && cr.isGitLab() {
-		cr.askForClarification()
-	} else if cr.isGitHub() {
-		cr.createGitHub()
-	} else if cr.isGitLab() {
-		cr.createGitLab()
+	github := cr.remote(credentials.GitHub)
+	gitlab := cr.remote(credentials.GitLab)
+
+	var remote *git.Remote
+	var forgeName string
+	if github != nil && gitlab != nil {
+		forgeName = cr.askForClarification()
+		if forgeName == credentials.GitHub {
+			remote = github
+		} else {
+			remote = gitlab
+		}
+	} else if github != nil {
+		remote, forgeName = github, credentials.GitHub
+	} else if gitlab != nil {
+		remote, forgeName = gitlab, credentials.GitLab
 	} else {
 		err := errors.New("could not locate GitHub or GitLab remote URLs")
 		utils.HandleError(err, cr.Debug, nil)
 		return
 	}
+
+	if cr.Stack {
+		cr.createStack(remote, forgeName)
+		return
+	}
+
+	cr.create(remote, forgeName, cr.baseBranch())
 }
 
-func (cr *CodeRequest) askForClarification() {
+func (cr *CodeRequest) askForClarification() string {
 	var answer string
 
 	if cr.InteractiveMode {
@@ -77,36 +103,100 @@ func (cr *CodeRequest) askForClarification() {
 	}
 
 	if answer == "GitHub" {
-		cr.createGitHub()
+		return credentials.GitHub
+	}
+
+	return credentials.GitLab
+}
+
+func (cr *CodeRequest) create(remote *git.Remote, forgeName, base string) {
+	if forgeName == credentials.GitLab {
+		cr.createGitLab(remote, base)
 	} else {
-		cr.createGitLab()
+		cr.createGitHub(remote, base)
 	}
 }
 
-func (cr *CodeRequest) createGitHub() {
+func (cr *CodeRequest) createGitHub(remote *git.Remote, base string) {
 	options := make(map[string]string)
-	options["baseBranch"] = cr.baseBranch()
+	options["baseBranch"] = base
 	options["draft"] = cr.draft()
 	options["newPrTitle"] = cr.newPrTitle()
 	g := git.NewGit(cr.Debug, cr.Executor)
 	options["gitRootDir"] = g.GetGitRootDir()
-	options["localBranch"] = g.CurrentBranch()
-	options["localRepo"] = g.RepoName()
+	options["host"] = remote.Host
+	options["localBranch"] = cr.branch()
+	options["localRepo"] = remote.FullName()
 	githubPullRequest.NewGitHubPullRequest(options, cr.Debug, cr.InteractiveMode).Create()
 }
 
-func (cr *CodeRequest) createGitLab() {
+func (cr *CodeRequest) createGitLab(remote *git.Remote, base string) {
 	options := make(map[string]string)
-	options["baseBranch"] = cr.baseBranch()
+	options["baseBranch"] = base
 	options["draft"] = cr.draft()
 	options["newMrTitle"] = cr.newMrTitle()
 	g := git.NewGit(cr.Debug, cr.Executor)
 	options["gitRootDir"] = g.GetGitRootDir()
-	options["localBranch"] = g.CurrentBranch()
-	options["localProject"] = g.RepoName()
+	options["host"] = remote.Host
+	options["localBranch"] = cr.branch()
+	options["localProject"] = remote.FullName()
 	gitlabMergeRequest.NewGitLabMergeRequest(options, cr.Debug, cr.InteractiveMode).Create()
 }
 
+// createStack opens a code request for each branch from the bottom of the
+// current branch's stack up to it, each based on its parent. Branches that
+// already have an open one are retargeted to their parent when needed.
+func (cr *CodeRequest) createStack(remote *git.Remote, forgeName string) {
+	g := git.NewGit(cr.Debug, cr.Executor)
+	st := stack.NewStack(cr.Debug, cr.Executor)
+	current := g.CurrentBranch()
+	lineage := st.Lineage(current)
+	if len(lineage) == 0 {
+		utils.HandleError(fmt.Errorf("%s isn't stacked on another branch, create one with git-helper new-branch --stacked", current), cr.Debug, nil)
+		return
+	}
+
+	client, err := forge.NewClient(cr.Debug, remote)
+	if err != nil {
+		utils.HandleError(err, cr.Debug, nil)
+		return
+	}
+
+	for _, branch := range lineage {
+		parent := st.Parent(branch)
+		existing, err := client.FindCodeRequest(branch)
+		if err != nil {
+			utils.HandleError(err, cr.Debug, nil)
+			return
+		}
+
+		if existing != nil && existing.State == forge.StateOpen {
+			if existing.Base == parent {
+				fmt.Printf("%s already has %s\n", branch, existing.URL)
+				continue
+			}
+
+			err = client.Retarget(existing.Number, parent)
+			if err != nil {
+				utils.HandleError(err, cr.Debug, nil)
+				return
+			}
+
+			fmt.Printf("Retargeted %s to %s\n", existing.URL, parent)
+			continue
+		}
+
+		err = g.ForcePushBranch(branch)
+		if err != nil {
+			utils.HandleError(err, cr.Debug, nil)
+			return
+		}
+
+		cr.Branch = branch
+		cr.create(remote, forgeName, parent)
+	}
+}
+
 func (cr *CodeRequest) baseBranch() string {
 	defaultBranch := git.NewGit(cr.Debug, cr.Executor).DefaultBranch()
 
@@ -144,8 +234,7 @@ func (cr *CodeRequest) newPrTitle() string {
 }
 
 func (cr *CodeRequest) autogeneratedTitle() string {
-	g := git.NewGit(cr.Debug, cr.Executor)
-	branchArr := strings.FieldsFunc(g.CurrentBranch(), func(r rune) bool {
+	branchArr := strings.FieldsFunc(cr.branch(), func(r rune) bool {
 		return r == '-' || r == '_'
 	})
 
@@ -173,6 +262,16 @@ func (cr *CodeRequest) autogeneratedTitle() string {
 	return cr.applySpecialCapitalization(result)
 }
 
+// branch returns the branch the code request is for, which is the current
+// branch unless a stack is being created.
+func (cr *CodeRequest) branch() string {
+	if cr.Branch != "" {
+		return cr.Branch
+	}
+
+	return git.NewGit(cr.Debug, cr.Executor).CurrentBranch()
+}
+
 func (cr *CodeRequest) checkAllLetters(s string) bool {
 	match, _ := regexp.MatchString("^[a-zA-Z]+$", s)
 	return match
@@ -238,20 +337,20 @@ func (cr *CodeRequest) applySpecialCapitalization(title string) string {
 	return result
 }
 
-func (cr *CodeRequest) isGitHub() bool {
-	return cr.containsSubstring(git.NewGit(cr.Debug, cr.Executor).Remotes(), "github.com")
-}
-
-func (cr *CodeRequest) isGitLab() bool {
-	return cr.containsSubstring(git.NewGit(cr.Debug, cr.Executor).Remotes(), "gitlab.com")
-}
+// remote returns the first push remote hosted on the forge, preferring origin.
+// Hosts that don't name their forge, like GitHub Enterprise servers, are
+// recognized from the configured accounts.
+func (cr *CodeRequest) remote(forge string) *git.Remote {
+	config, err := configfile.NewConfigFile(cr.Debug).Load()
+	if err != nil {
+		config = &configfile.Config{}
+	}
 
-func (cr *CodeRequest) containsSubstring(strs []string, substring string) bool {
-	for _, str := range strs {
-		if strings.Contains(str, substring) {
-			return true
+	for _, remote := range git.NewGit(cr.Debug, cr.Executor).PushRemotes() {
+		if config.Forge(remote.Host) == forge {
+			return remote
 		}
 	}
 
-	return false
+	return nil
 }
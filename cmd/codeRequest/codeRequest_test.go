@@ -275,51 +275,43 @@ func Test_titleize(t *testing.T) {
 	}
 }
 
-func Test_isGitHub(t *testing.T) {
+func Test_remote(t *testing.T) {
 	tests := []struct {
+		forge    string
 		remotes  string
-		expected bool
+		expected string
 	}{
 		{
+			forge: "github",
 			remotes: `origin  git@github.com:emmahsax/go-git-helper.git (fetch)
 origin  git@github.com:emmahsax/go-git-helper.git (push)`,
-			expected: true,
+			expected: "emmahsax/go-git-helper",
 		},
 		{
+			forge: "github",
 			remotes: `origin  git@gitlab.com:emmahsax/github-project.git (fetch)
 origin  git@gitlab.com:emmahsax/github-project.git (push)`,
-			expected: false,
+			expected: "",
 		},
-	}
-
-	for _, test := range tests {
-		executor := &MockExecutor{
-			Debug:  true,
-			Output: []byte(test.remotes),
-		}
-		cr := newCodeRequest(true, true, executor)
-		resp := cr.isGitHub()
-
-		if resp != test.expected {
-			t.Fatalf(`should have been %v, but was %v`, test.expected, resp)
-		}
-	}
-}
-
-func Test_isGitLab(t *testing.T) {
-	tests := []struct {
-		remotes  string
-		expected bool
-	}{
 		{
+			forge: "gitlab",
 			remotes: `origin  git@gitlab.com:emmahsax/go-git-helper.git (fetch)
 origin  git@gitlab.com:emmahsax/go-git-helper.git (push)`,
-			expected: true,
+			expected: "emmahsax/go-git-helper",
 		},
 		{
+			forge: "gitlab",
 			remotes: `origin  git@github.com:emmahsax/gitlab-project.git (fetch)
 origin  git@github.com:emmahsax/gitlab-project.git (push)`,
-			expected: false,
+			expected: "",
+		},
+		{
+			forge: "gitlab",
+			remotes: `github  git@github.com:emmahsax/go-git-helper.git (fetch)
+github  git@github.com:emmahsax/go-git-helper.git (push)
+origin  https://gitlab.com/group/subgroup/project.git (fetch)
+origin  https://gitlab.com/group/subgroup/project.git (push)`,
+			expected: "group/subgroup/project",
 		},
 	}
 
@@ -329,28 +321,16 @@ origin  git@github.com:emmahsax/gitlab-project.git (push)`,
 			Output: []byte(test.remotes),
 		}
 		cr := newCodeRequest(true, true, executor)
-		resp := cr.isGitLab()
+		remote := cr.remote(test.forge)
 
-		if resp != test.expected {
-			t.Fatalf(`should have been %v, but was %v`, test.expected, resp)
+		name := ""
+		if remote != nil {
+			name = remote.FullName()
 		}
-	}
-}
-
-func Test_containsSubstring(t *testing.T) {
-	executor := &MockExecutor{Debug: true}
-	cr := newCodeRequest(true, true, executor)
-	strs := []string{"string1", "string3", "string18"}
-	resp := cr.containsSubstring(strs, "string3")
-
-	if resp == false {
-		t.Fatalf(`string %v should be present in %v`, "string3", strs)
-	}
-
-	resp = cr.containsSubstring(strs, "string2")
 
-	if resp == true {
-		t.Fatalf(`string %v should not be present in %v`, "string2", strs)
+		if name != test.expected {
+			t.Fatalf(`should have been '%v', but was '%v'`, test.expected, name)
+		}
 	}
 }
 
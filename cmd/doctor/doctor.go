@@ -0,0 +1,449 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/auth"
+	"github.com/emmahsax/go-git-helper/internal/commandline"
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/plugins"
+	"github.com/emmahsax/go-git-helper/internal/release"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	Pass = "pass"
+	Warn = "warn"
+	Fail = "fail"
+)
+
+// minGitVersion is the oldest git with everything Git Helper runs, like
+// `git --list-cmds`.
+const minGitVersion = "2.20.0"
+
+type Doctor struct {
+	ConfigFile configfile.ConfigFileInterface
+	Debug      bool
+	Executor   executor.ExecutorInterface
+	Root       *cobra.Command
+}
+
+// Result is the outcome of one check. Apply is set when the fix is safe to
+// run without looking, like regenerating files Git Helper owns.
+type Result struct {
+	Apply   func() error
+	Check   string
+	Fix     string
+	Message string
+	Status  string
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug bool
+		fix   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "doctor [--fix]",
+		Short:                 "Checks the config, credentials, git and plugin setup for problems",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newDoctor(debug, executor.NewExecutor(debug), configfile.NewConfigFile(debug), cmd.Root()).execute(fix)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().BoolVar(&fix, "fix", false, "apply the safe fixes without asking")
+
+	return cmd
+}
+
+func newDoctor(debug bool, executor executor.ExecutorInterface, configFile configfile.ConfigFileInterface, root *cobra.Command) *Doctor {
+	return &Doctor{
+		ConfigFile: configFile,
+		Debug:      debug,
+		Executor:   executor,
+		Root:       root,
+	}
+}
+
+func (d *Doctor) execute(fix bool) {
+	results := d.checkGit()
+	results = append(results, d.checkConfig()...)
+	results = append(results, d.checkCredentials()...)
+
+	if d.inRepo() {
+		results = append(results, d.checkSymbolicRef()...)
+		results = append(results, d.checkRemotes()...)
+	} else {
+		fmt.Println("Not in a git repository, so skipping the repository checks")
+	}
+
+	results = append(results, d.checkPlugins()...)
+	results = append(results, d.checkCompletion()...)
+
+	printResults(results)
+
+	fixable := 0
+	for _, result := range results {
+		if result.Status != Pass && result.Apply != nil {
+			fixable++
+		}
+	}
+
+	if fixable > 0 && (fix || commandline.AskYesNoQuestion(fmt.Sprintf("Apply the %d safe fix(es)?", fixable))) {
+		for i, result := range results {
+			if result.Status != Pass && result.Apply != nil {
+				results[i] = applyFix(result)
+				printResults(results[i : i+1])
+			}
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == Fail {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		utils.HandleError(fmt.Errorf("found %d failing check(s)", failed), d.Debug, nil)
+	}
+}
+
+func applyFix(result Result) Result {
+	err := result.Apply()
+	if err != nil {
+		result.Message = "fix failed: " + err.Error()
+		return result
+	}
+
+	return Result{Check: result.Check, Message: "fixed", Status: Pass}
+}
+
+func printResults(results []Result) {
+	for _, result := range results {
+		fmt.Printf("[%s] %s: %s\n", result.Status, result.Check, result.Message)
+		if result.Status != Pass && result.Fix != "" {
+			suffix := ""
+			if result.Apply != nil {
+				suffix = " (can be applied automatically)"
+			}
+			fmt.Printf("       fix: %s%s\n", result.Fix, suffix)
+		}
+	}
+}
+
+func (d *Doctor) checkGit() []Result {
+	output, err := d.Executor.Exec("actionAndOutput", "git", "--version")
+	if err != nil {
+		return []Result{{Check: "git", Status: Fail, Message: "git isn't installed or isn't on your PATH", Fix: "install git " + minGitVersion + " or newer"}}
+	}
+
+	version := strings.TrimPrefix(strings.TrimSpace(string(output)), "git version ")
+	version, _, _ = strings.Cut(version, " ")
+	if release.Compare(version, minGitVersion) < 0 {
+		return []Result{{Check: "git", Status: Fail, Message: "git " + version + " is older than " + minGitVersion, Fix: "upgrade git to " + minGitVersion + " or newer"}}
+	}
+
+	return []Result{{Check: "git", Status: Pass, Message: "git " + version}}
+}
+
+func (d *Doctor) checkConfig() []Result {
+	if !d.ConfigFile.ConfigFileExists() {
+		return []Result{{Check: "config", Status: Warn, Message: d.ConfigFile.ConfigFile() + " doesn't exist", Fix: "git-helper setup"}}
+	}
+
+	results := []Result{}
+	layers, err := d.ConfigFile.Layers()
+	if err != nil {
+		return []Result{{Check: "config", Status: Fail, Message: err.Error(), Fix: "git-helper config edit"}}
+	}
+
+	merged, err := d.ConfigFile.Load()
+	if err != nil {
+		return []Result{{Check: "config", Status: Fail, Message: err.Error(), Fix: "git-helper config edit"}}
+	}
+
+	problems := []string{}
+	for _, layer := range layers {
+		for _, problem := range layer.Config.Validate() {
+			problems = append(problems, layer.Origin+": "+problem.Error())
+		}
+	}
+	for _, problem := range merged.ValidateAccounts() {
+		problems = append(problems, "merged config: "+problem.Error())
+	}
+
+	if len(problems) > 0 {
+		results = append(results, Result{Check: "config", Status: Fail, Message: strings.Join(problems, "; "), Fix: "git-helper config validate, then git-helper config edit"})
+	} else {
+		results = append(results, Result{Check: "config", Status: Pass, Message: d.ConfigFile.ConfigFile() + " is valid"})
+	}
+
+	path := d.ConfigFile.ConfigFile()
+	info, err := os.Stat(path)
+	if err == nil && info.Mode().Perm()&0077 != 0 {
+		results = append(results, Result{
+			Check:   "config permissions",
+			Status:  Warn,
+			Message: fmt.Sprintf("%s is readable by other users (%#o), and may hold tokens", path, info.Mode().Perm()),
+			Fix:     "chmod 600 " + path,
+			Apply:   func() error { return os.Chmod(path, 0600) },
+		})
+	}
+
+	return results
+}
+
+func (d *Doctor) checkCredentials() []Result {
+	config, err := d.ConfigFile.Load()
+	if err != nil {
+		return []Result{}
+	}
+
+	creds := credentials.NewCredentials(d.Debug, d.ConfigFile, d.Executor)
+	results := []Result{}
+	covered := map[string]bool{}
+
+	for _, account := range config.Accounts {
+		covered[account.ForgeName()+"/"+account.Host] = true
+		token, _, err := creds.AccountToken(&account)
+		check := "credentials " + account.Host + " (account " + account.Name + ")"
+		if err != nil {
+			results = append(results, Result{Check: check, Status: Fail, Message: err.Error(), Fix: "git-helper auth login --" + account.ForgeName() + " --host " + account.Host})
+			continue
+		}
+
+		results = append(results, d.checkToken(check, account.ForgeName(), account.Host, token))
+	}
+
+	for _, forge := range []string{credentials.GitHub, credentials.GitLab} {
+		host := credentials.DefaultHost(forge)
+		if covered[forge+"/"+host] {
+			continue
+		}
+
+		token, _, err := creds.HostToken(forge, host)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, d.checkToken("credentials "+host, forge, host, token))
+	}
+
+	if len(results) == 0 {
+		results = append(results, Result{Check: "credentials", Status: Warn, Message: "not logged in to GitHub or GitLab", Fix: "git-helper auth login --github (or --gitlab)"})
+	}
+
+	return results
+}
+
+func (d *Doctor) checkToken(check, forge, host, token string) Result {
+	status, err := auth.CheckToken(d.Debug, forge, host, token)
+	if err != nil {
+		return Result{Check: check, Status: Fail, Message: err.Error(), Fix: "git-helper auth login --" + forge + " --host " + host}
+	}
+
+	if len(status.Warnings) > 0 {
+		return Result{Check: check, Status: Warn, Message: "logged in as " + status.Username + ", but " + strings.Join(status.Warnings, "; "), Fix: "git-helper auth refresh --" + forge + " --host " + host + ", or create a new token"}
+	}
+
+	return Result{Check: check, Status: Pass, Message: "logged in as " + status.Username}
+}
+
+func (d *Doctor) inRepo() bool {
+	_, err := d.Executor.Exec("actionAndOutput", "git", "rev-parse", "--git-dir")
+	return err == nil
+}
+
+func (d *Doctor) checkSymbolicRef() []Result {
+	if _, err := d.Executor.Exec("actionAndOutput", "git", "remote", "get-url", "origin"); err != nil {
+		return []Result{{Check: "symbolic ref", Status: Warn, Message: "there's no origin remote, so commands can't find the default branch", Fix: "git remote add origin [url]"}}
+	}
+
+	output, err := d.Executor.Exec("actionAndOutput", "git", "symbolic-ref", "refs/remotes/origin/HEAD")
+	if err == nil {
+		return []Result{{Check: "symbolic ref", Status: Pass, Message: "origin/HEAD points to " + strings.TrimPrefix(strings.TrimSpace(string(output)), "refs/remotes/origin/")}}
+	}
+
+	return []Result{{
+		Check:   "symbolic ref",
+		Status:  Fail,
+		Message: "origin/HEAD isn't set, so commands can't find the default branch",
+		Fix:     "git-helper set-head-ref",
+		Apply: func() error {
+			output, err := d.Executor.Exec("actionAndOutput", "git", "remote", "set-head", "origin", "--auto")
+			if err != nil {
+				return errors.New(strings.TrimSpace(string(output) + " " + err.Error()))
+			}
+			return nil
+		},
+	}}
+}
+
+func (d *Doctor) checkRemotes() []Result {
+	remotes := git.NewGit(d.Debug, d.Executor).PushRemotes()
+	if len(remotes) == 0 {
+		return []Result{{Check: "remotes", Status: Fail, Message: "no remote has a GitHub or GitLab style URL", Fix: "git remote add origin [url]"}}
+	}
+
+	config, err := d.ConfigFile.Load()
+	if err != nil {
+		config = &configfile.Config{}
+	}
+
+	results := []Result{}
+	for _, remote := range remotes {
+		check := "remote " + remote.Name
+		forge := config.Forge(remote.Host)
+		if forge == "" {
+			results = append(results, Result{Check: check, Status: Warn, Message: "can't tell whether " + remote.Host + " is GitHub or GitLab", Fix: "git-helper config set accounts.[name].host " + remote.Host + " and accounts.[name].forge github (or gitlab)"})
+			continue
+		}
+
+		results = append(results, Result{Check: check, Status: Pass, Message: fmt.Sprintf("%s repository %s on %s", forge, remote.FullName(), remote.Host)})
+	}
+
+	return results
+}
+
+// checkPlugins compares the installed links or scripts against the current
+// commands. Plugins are optional, so not having them is only a warning.
+func (d *Doctor) checkPlugins() []Result {
+	p := plugins.NewPlugins(d.Debug, "", d.Executor)
+	commands := p.Commands(d.Root)
+
+	target, err := plugins.Executable()
+	if err == nil {
+		p.Dir = filepath.Dir(target)
+		if links := p.Links(target); len(links) > 0 {
+			return []Result{d.comparePlugins(commands, links, func() error {
+				_, err := p.Link(commands, target)
+				return err
+			}, "git-helper install-links")}
+		}
+	}
+
+	shimDir := filepath.Join(d.ConfigFile.ConfigDir(), "plugins")
+	if _, err := os.Stat(shimDir); err != nil {
+		return []Result{{Check: "plugins", Status: Warn, Message: "not set up, so commands only run as git-helper <command>", Fix: "git-helper install-links"}}
+	}
+
+	shimPlugins := plugins.NewPlugins(d.Debug, shimDir, d.Executor)
+	result := d.comparePlugins(commands, shimPlugins.Shims(), func() error {
+		_, err := shimPlugins.Install(commands)
+		return err
+	}, "git-helper setup --plugins")
+
+	if result.Status == Pass && !slices.Contains(filepath.SplitList(os.Getenv("PATH")), shimDir) {
+		result = Result{Check: "plugins", Status: Warn, Message: shimDir + " isn't on your PATH", Fix: "add export PATH=\"$HOME/.git-helper/plugins:$PATH\" to your shell's rc file, or run git-helper install-links"}
+	}
+
+	return []Result{result}
+}
+
+// comparePlugins reports the commands without a plugin, and the plugins left
+// behind for commands that no longer exist, which installing them again
+// removes.
+func (d *Doctor) comparePlugins(commands, installed []string, apply func() error, fix string) Result {
+	wanted := []string{}
+	missing := []string{}
+	for _, command := range commands {
+		wanted = append(wanted, "git-"+command)
+		if !slices.Contains(installed, "git-"+command) {
+			missing = append(missing, "git-"+command)
+		}
+	}
+
+	stale := []string{}
+	for _, name := range installed {
+		if !slices.Contains(wanted, name) {
+			stale = append(stale, name)
+		}
+	}
+
+	problems := []string{}
+	if len(missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(missing, ", "))
+	}
+	if len(stale) > 0 {
+		problems = append(problems, "left over from removed commands "+strings.Join(stale, ", "))
+	}
+
+	if len(problems) > 0 {
+		return Result{Check: "plugins", Status: Warn, Message: strings.Join(problems, "; "), Fix: fix, Apply: apply}
+	}
+
+	return Result{Check: "plugins", Status: Pass, Message: fmt.Sprintf("%d commands installed", len(commands))}
+}
+
+// checkCompletion looks for the completion script for the current $SHELL,
+// and for a line sourcing it in the shell's rc file.
+func (d *Doctor) checkCompletion() []Result {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	home, _ := os.UserHomeDir()
+	rcFiles := map[string]string{
+		"bash": filepath.Join(home, ".bashrc"),
+		"fish": filepath.Join(home, ".config", "fish", "config.fish"),
+		"zsh":  filepath.Join(home, ".zshrc"),
+	}
+
+	rcFile, ok := rcFiles[shell]
+	if !ok {
+		return []Result{{Check: "completion", Status: Pass, Message: "skipped for shell " + utils.ValueOr(shell, "unknown")}}
+	}
+
+	script := filepath.Join(d.ConfigFile.ConfigDir(), "completions", "completion."+shell)
+	if _, err := os.Stat(script); err != nil {
+		return []Result{{
+			Check:   "completion",
+			Status:  Warn,
+			Message: script + " doesn't exist",
+			Fix:     "git-helper completion " + shell + " > " + script,
+			Apply:   func() error { return d.writeCompletion(shell, script) },
+		}}
+	}
+
+	content, _ := os.ReadFile(rcFile)
+	if !strings.Contains(string(content), "completion."+shell) && !strings.Contains(string(content), "git-helper completion") {
+		return []Result{{Check: "completion", Status: Warn, Message: rcFile + " doesn't source " + script, Fix: fmt.Sprintf("add [ -f %s ] && source %s to %s", script, script, rcFile)}}
+	}
+
+	return []Result{{Check: "completion", Status: Pass, Message: rcFile + " sources " + script}}
+}
+
+func (d *Doctor) writeCompletion(shell, script string) error {
+	err := os.MkdirAll(filepath.Dir(script), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(script)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	switch shell {
+	case "bash":
+		return d.Root.GenBashCompletionV2(file, true)
+	case "fish":
+		return d.Root.GenFishCompletion(file, true)
+	default:
+		return d.Root.GenZshCompletion(file)
+	}
+}
@@ -0,0 +1,271 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/auth"
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/plugins"
+	"github.com/spf13/cobra"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Outputs map[string]string
+	Run     []string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+func newTestDoctor(t *testing.T, config string, outputs map[string]string) (*Doctor, *MockExecutor, string) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITLAB_TOKEN", "")
+	if config != "" {
+		os.MkdirAll(filepath.Join(home, ".git-helper"), 0700)
+		os.WriteFile(filepath.Join(home, ".git-helper", "config.yml"), []byte(config), 0600)
+	}
+
+	originalGitCredential := credentials.GitCredential
+	t.Cleanup(func() {
+		credentials.GitCredential = originalGitCredential
+	})
+	credentials.GitCredential = func(action, input string) (string, error) {
+		return "", errors.New("terminal prompts disabled")
+	}
+
+	executor := &MockExecutor{Debug: true, Outputs: outputs}
+	cf := configfile.NewConfigFile(true)
+	cf.Executor = executor
+
+	root := &cobra.Command{Use: "git-helper"}
+	root.AddCommand(&cobra.Command{Use: "code-request"}, &cobra.Command{Use: "new-branch"})
+	return newDoctor(true, executor, cf, root), executor, home
+}
+
+func Test_checkGit(t *testing.T) {
+	tests := []struct {
+		output string
+		status string
+	}{
+		{output: "git version 2.43.0\n", status: Pass},
+		{output: "git version 2.39.5 (Apple Git-154)\n", status: Pass},
+		{output: "git version 2.17.1\n", status: Fail},
+		{output: "", status: Fail},
+	}
+
+	for _, test := range tests {
+		outputs := map[string]string{}
+		if test.output != "" {
+			outputs["git --version"] = test.output
+		}
+
+		d, _, _ := newTestDoctor(t, "", outputs)
+		if result := d.checkGit()[0]; result.Status != test.status {
+			t.Errorf("expected %s for %q, got %+v", test.status, test.output, result)
+		}
+	}
+}
+
+func Test_checkConfig(t *testing.T) {
+	d, _, _ := newTestDoctor(t, "", map[string]string{})
+	if result := d.checkConfig()[0]; result.Status != Warn || result.Fix != "git-helper setup" {
+		t.Errorf("expected a warning without a config file, got %+v", result)
+	}
+
+	d, _, _ = newTestDoctor(t, "github_username: octocat\naccounts:\n  - name: work\n    forge: bitbucket\n", map[string]string{})
+	if result := d.checkConfig()[0]; result.Status != Fail || !strings.Contains(result.Message, "forge") {
+		t.Errorf("expected an account with an unknown forge to fail, got %+v", result)
+	}
+
+	d, _, home := newTestDoctor(t, "github_username: octocat\n", map[string]string{})
+	configPath := filepath.Join(home, ".git-helper", "config.yml")
+	os.Chmod(configPath, 0644)
+
+	results := d.checkConfig()
+	if len(results) != 2 || results[0].Status != Pass || results[1].Status != Warn || results[1].Apply == nil {
+		t.Fatalf("expected a valid config with loose permissions, got %+v", results)
+	}
+
+	if err := results[1].Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, _ := os.Stat(configPath)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the fix to chmod the config to 0600, got %#o", info.Mode().Perm())
+	}
+}
+
+func Test_checkCredentials(t *testing.T) {
+	originalCheckToken := auth.CheckToken
+	t.Cleanup(func() {
+		auth.CheckToken = originalCheckToken
+	})
+	auth.CheckToken = func(debug bool, forge, host, token string) (*auth.Status, error) {
+		if token == "bad" {
+			return nil, errors.New("GitHub rejected the token for " + host)
+		}
+		return &auth.Status{Forge: forge, Host: host, Username: "octocat"}, nil
+	}
+
+	d, _, _ := newTestDoctor(t, "", map[string]string{})
+	if results := d.checkCredentials(); len(results) != 1 || results[0].Status != Warn {
+		t.Errorf("expected a warning when not logged in, got %+v", results)
+	}
+
+	config := "github_token: good\naccounts:\n  - name: work\n    host: github.example.com\n    forge: github\n    token: bad\n"
+	d, _, _ = newTestDoctor(t, config, map[string]string{})
+	results := d.checkCredentials()
+	if len(results) != 2 {
+		t.Fatalf("expected the account and github.com to be checked, got %+v", results)
+	}
+
+	if results[0].Status != Fail || !strings.Contains(results[0].Fix, "--host github.example.com") {
+		t.Errorf("expected the work account to fail, got %+v", results[0])
+	}
+
+	if results[1].Status != Pass || results[1].Message != "logged in as octocat" {
+		t.Errorf("expected github.com to pass, got %+v", results[1])
+	}
+}
+
+func Test_checkSymbolicRef(t *testing.T) {
+	d, _, _ := newTestDoctor(t, "", map[string]string{})
+	if result := d.checkSymbolicRef()[0]; result.Status != Warn || result.Apply != nil {
+		t.Errorf("expected an unfixable warning without origin, got %+v", result)
+	}
+
+	d, _, _ = newTestDoctor(t, "", map[string]string{
+		"git remote get-url origin":                 "git@github.com:octocat/hello.git\n",
+		"git symbolic-ref refs/remotes/origin/HEAD": "refs/remotes/origin/main\n",
+	})
+	if result := d.checkSymbolicRef()[0]; result.Status != Pass || result.Message != "origin/HEAD points to main" {
+		t.Errorf("expected a pass, got %+v", result)
+	}
+
+	d, executor, _ := newTestDoctor(t, "", map[string]string{
+		"git remote get-url origin":         "git@github.com:octocat/hello.git\n",
+		"git remote set-head origin --auto": "origin/HEAD set to main\n",
+	})
+	result := d.checkSymbolicRef()[0]
+	if result.Status != Fail || result.Apply == nil {
+		t.Fatalf("expected a fixable failure, got %+v", result)
+	}
+
+	if fixed := applyFix(result); fixed.Status != Pass {
+		t.Errorf("expected the fix to pass, got %+v", fixed)
+	}
+
+	if executor.Run[len(executor.Run)-1] != "git remote set-head origin --auto" {
+		t.Errorf("expected the fix to run git remote set-head, got %v", executor.Run)
+	}
+}
+
+func Test_checkRemotes(t *testing.T) {
+	d, _, _ := newTestDoctor(t, "", map[string]string{
+		"git remote -v": "origin\tgit@github.com:octocat/hello.git (fetch)\norigin\tgit@github.com:octocat/hello.git (push)\nmirror\thttps://git.example.com/octocat/hello.git (push)\n",
+	})
+
+	results := d.checkRemotes()
+	if len(results) != 2 {
+		t.Fatalf("expected both remotes to be checked, got %+v", results)
+	}
+
+	if results[0].Status != Pass || results[0].Message != "github repository octocat/hello on github.com" {
+		t.Errorf("expected origin to pass, got %+v", results[0])
+	}
+
+	if results[1].Status != Warn {
+		t.Errorf("expected an unknown forge to warn, got %+v", results[1])
+	}
+}
+
+func Test_checkPlugins(t *testing.T) {
+	binDir := t.TempDir()
+	target := filepath.Join(binDir, "git-helper")
+	originalExecutable := plugins.Executable
+	t.Cleanup(func() {
+		plugins.Executable = originalExecutable
+	})
+	plugins.Executable = func() (string, error) {
+		return target, nil
+	}
+
+	d, _, _ := newTestDoctor(t, "", map[string]string{"git --list-cmds=builtins": "add\ncommit\n"})
+	if result := d.checkPlugins()[0]; result.Status != Warn || result.Apply != nil {
+		t.Errorf("expected an unfixable warning without plugins, got %+v", result)
+	}
+
+	os.Symlink(target, filepath.Join(binDir, "git-code-request"))
+	result := d.checkPlugins()[0]
+	if result.Status != Warn || result.Message != "missing git-new-branch" || result.Apply == nil {
+		t.Fatalf("expected a fixable warning for the missing link, got %+v", result)
+	}
+
+	if fixed := applyFix(result); fixed.Status != Pass {
+		t.Errorf("expected the fix to pass, got %+v", fixed)
+	}
+
+	if result := d.checkPlugins()[0]; result.Status != Pass {
+		t.Errorf("expected a pass once the links are installed, got %+v", result)
+	}
+
+	os.Symlink(target, filepath.Join(binDir, "git-removed-command"))
+	result = d.checkPlugins()[0]
+	if result.Status != Warn || result.Message != "left over from removed commands git-removed-command" || result.Apply == nil {
+		t.Fatalf("expected a fixable warning for the stale link, got %+v", result)
+	}
+
+	if fixed := applyFix(result); fixed.Status != Pass {
+		t.Errorf("expected the fix to remove the stale link, got %+v", fixed)
+	}
+
+	if _, err := os.Lstat(filepath.Join(binDir, "git-removed-command")); !os.IsNotExist(err) {
+		t.Errorf("expected the stale link to be removed, got %v", err)
+	}
+}
+
+func Test_checkCompletion(t *testing.T) {
+	d, _, home := newTestDoctor(t, "", map[string]string{})
+	t.Setenv("SHELL", "/bin/zsh")
+
+	result := d.checkCompletion()[0]
+	if result.Status != Warn || result.Apply == nil {
+		t.Fatalf("expected a fixable warning without a completion script, got %+v", result)
+	}
+
+	if fixed := applyFix(result); fixed.Status != Pass {
+		t.Errorf("expected the fix to pass, got %+v", fixed)
+	}
+
+	if result := d.checkCompletion()[0]; result.Status != Warn || !strings.Contains(result.Message, "doesn't source") {
+		t.Errorf("expected a warning when .zshrc doesn't source the script, got %+v", result)
+	}
+
+	os.WriteFile(filepath.Join(home, ".zshrc"), []byte("source ~/.git-helper/completions/completion.zsh\n"), 0644)
+	if result := d.checkCompletion()[0]; result.Status != Pass {
+		t.Errorf("expected a pass, got %+v", result)
+	}
+}
@@ -0,0 +1,114 @@
+package done
+
+import (
+	"fmt"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type Done struct {
+	Debug    bool
+	Executor executor.ExecutorInterface
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "done",
+		Aliases:               []string{"finish"},
+		Short:                 "Switches to the default branch and deletes the current branch once its code request has merged",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newDone(debug, executor.NewExecutor(debug)).execute()
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+
+	return cmd
+}
+
+func newDone(debug bool, executor executor.ExecutorInterface) *Done {
+	return &Done{
+		Debug:    debug,
+		Executor: executor,
+	}
+}
+
+func (d *Done) execute() {
+	err := d.finish()
+	if err != nil {
+		utils.HandleError(err, d.Debug, nil)
+		return
+	}
+}
+
+func (d *Done) finish() error {
+	g := git.NewGit(d.Debug, d.Executor)
+	branch := g.CurrentBranch()
+	defaultBranch := g.DefaultBranch()
+	if branch == defaultBranch {
+		return fmt.Errorf("already on the default branch %s, check out the merged branch first", defaultBranch)
+	}
+
+	remote, err := g.Remote("origin")
+	if err != nil {
+		return err
+	}
+
+	client, err := forge.NewClient(d.Debug, remote)
+	if err != nil {
+		return err
+	}
+
+	cr, err := client.FindCodeRequest(branch)
+	if err != nil {
+		return err
+	}
+
+	if cr == nil {
+		return fmt.Errorf("%s doesn't have a code request", branch)
+	}
+
+	switch cr.State {
+	case forge.StateOpen:
+		return fmt.Errorf("%s is still open", cr.URL)
+	case forge.StateClosed:
+		return fmt.Errorf("%s was closed without merging", cr.URL)
+	}
+
+	err = forge.CheckPushed(g, branch, cr)
+	if err != nil {
+		return err
+	}
+
+	forge.MoveChildren(d.Debug, d.Executor, client, branch, defaultBranch)
+
+	g.Checkout(defaultBranch)
+	g.Pull()
+
+	err = g.DeleteBranch(branch)
+	if err != nil {
+		return err
+	}
+
+	if g.RemoteBranchExists(branch) {
+		err = g.DeleteRemoteBranch(branch)
+		if err != nil {
+			return err
+		}
+	}
+
+	g.Fetch()
+	fmt.Printf("Cleaned up %s, which merged in %s\n", branch, cr.URL)
+	return nil
+}
@@ -0,0 +1,139 @@
+package done
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Outputs map[string]string
+	Run     []string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+// fakeClient embeds forge.Client so it only has to implement what's used.
+type fakeClient struct {
+	forge.Client
+	requests map[string]*forge.CodeRequest
+}
+
+func (c *fakeClient) FindCodeRequest(branch string) (*forge.CodeRequest, error) {
+	return c.requests[branch], nil
+}
+
+func newTestDone(t *testing.T, cr *forge.CodeRequest, outputs map[string]string) (*Done, *MockExecutor) {
+	client := &fakeClient{requests: map[string]*forge.CodeRequest{"feature": cr}}
+	originalNewClient := forge.NewClient
+	t.Cleanup(func() {
+		forge.NewClient = originalNewClient
+	})
+	forge.NewClient = func(debug bool, remote *git.Remote) (forge.Client, error) {
+		return client, nil
+	}
+
+	base := map[string]string{
+		"git branch": "  main\n* feature\n",
+		"git symbolic-ref refs/remotes/origin/HEAD": "refs/remotes/origin/main\n",
+		"git remote get-url --push origin":          "git@github.com:octocat/hello.git\n",
+		"git rev-list --count abc123..feature":      "0\n",
+		"git checkout main":                         "",
+		"git pull":                                  "",
+		"git branch -D feature":                     "",
+		"git fetch -p":                              "",
+	}
+	for line, output := range outputs {
+		base[line] = output
+	}
+
+	executor := &MockExecutor{Debug: true, Outputs: base}
+	return newDone(true, executor), executor
+}
+
+func ran(executor *MockExecutor, line string) bool {
+	for _, run := range executor.Run {
+		if run == line {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_finish(t *testing.T) {
+	merged := &forge.CodeRequest{HeadSHA: "abc123", Number: 1, State: forge.StateMerged, URL: "https://github.com/octocat/hello/pull/1"}
+	d, executor := newTestDone(t, merged, map[string]string{
+		"git ls-remote --heads origin refs/heads/feature": "abc123\trefs/heads/feature\n",
+		"git push origin --delete feature":                "",
+	})
+
+	if err := d.finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"git checkout main", "git pull", "git branch -D feature", "git push origin --delete feature", "git fetch -p"} {
+		if !ran(executor, line) {
+			t.Errorf("expected %q to be run, got %v", line, executor.Run)
+		}
+	}
+
+	d, executor = newTestDone(t, merged, map[string]string{
+		"git ls-remote --heads origin refs/heads/feature": "",
+	})
+	if err := d.finish(); err != nil || ran(executor, "git push origin --delete feature") {
+		t.Errorf("expected a deleted remote branch to be left alone, got %v after %v", err, executor.Run)
+	}
+}
+
+func Test_finish_Refuses(t *testing.T) {
+	tests := []struct {
+		cr       *forge.CodeRequest
+		outputs  map[string]string
+		expected string
+	}{
+		{cr: nil, expected: "doesn't have a code request"},
+		{cr: &forge.CodeRequest{HeadSHA: "abc123", State: forge.StateOpen, URL: "#1"}, expected: "still open"},
+		{cr: &forge.CodeRequest{HeadSHA: "abc123", State: forge.StateClosed, URL: "#1"}, expected: "closed without merging"},
+		{
+			cr:       &forge.CodeRequest{HeadSHA: "abc123", State: forge.StateMerged, URL: "#1"},
+			outputs:  map[string]string{"git rev-list --count abc123..feature": "2\n"},
+			expected: "2 commit(s)",
+		},
+		{
+			cr:       &forge.CodeRequest{HeadSHA: "abc123", State: forge.StateMerged, URL: "#1"},
+			outputs:  map[string]string{"git branch": "* main\n  feature\n"},
+			expected: "already on the default branch",
+		},
+	}
+
+	for _, test := range tests {
+		d, executor := newTestDone(t, test.cr, test.outputs)
+		err := d.finish()
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("expected an error containing %q, got %v", test.expected, err)
+		}
+
+		if ran(executor, "git branch -D feature") {
+			t.Errorf("expected nothing to be deleted, got %v", executor.Run)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package draft
+
+import (
+	"fmt"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type Draft struct {
+	Debug    bool
+	Executor executor.ExecutorInterface
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "draft",
+		Short:                 "Converts the current branch's code request to a draft",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newDraft(debug, executor.NewExecutor(debug)).execute()
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+
+	return cmd
+}
+
+func newDraft(debug bool, executor executor.ExecutorInterface) *Draft {
+	return &Draft{
+		Debug:    debug,
+		Executor: executor,
+	}
+}
+
+func (d *Draft) execute() {
+	err := d.draft()
+	if err != nil {
+		utils.HandleError(err, d.Debug, nil)
+		return
+	}
+}
+
+func (d *Draft) draft() error {
+	g := git.NewGit(d.Debug, d.Executor)
+	branch := g.CurrentBranch()
+
+	remote, err := g.Remote("origin")
+	if err != nil {
+		return err
+	}
+
+	client, err := forge.NewClient(d.Debug, remote)
+	if err != nil {
+		return err
+	}
+
+	cr, err := client.FindCodeRequest(branch)
+	if err != nil {
+		return err
+	}
+
+	if cr == nil || cr.State != forge.StateOpen {
+		return fmt.Errorf("%s doesn't have an open code request, run git-helper code-request to create one", branch)
+	}
+
+	if cr.Draft {
+		fmt.Println(cr.URL, "is already a draft")
+		return nil
+	}
+
+	err = client.SetDraft(cr.Number, true)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Converted", cr.URL, "to a draft")
+	return nil
+}
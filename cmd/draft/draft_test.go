@@ -0,0 +1,94 @@
+package draft
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Outputs map[string]string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+type fakeClient struct {
+	forge.Client
+	cr     *forge.CodeRequest
+	drafts []bool
+}
+
+func (c *fakeClient) FindCodeRequest(branch string) (*forge.CodeRequest, error) {
+	return c.cr, nil
+}
+
+func (c *fakeClient) SetDraft(number int, draft bool) error {
+	c.drafts = append(c.drafts, draft)
+	return nil
+}
+
+func newTestDraft(t *testing.T, cr *forge.CodeRequest) (*Draft, *fakeClient) {
+	client := &fakeClient{cr: cr}
+	originalNewClient := forge.NewClient
+	t.Cleanup(func() {
+		forge.NewClient = originalNewClient
+	})
+	forge.NewClient = func(debug bool, remote *git.Remote) (forge.Client, error) {
+		return client, nil
+	}
+
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git branch":                       "  main\n* feature\n",
+		"git remote get-url --push origin": "git@github.com:octocat/hello.git\n",
+	}}
+
+	return newDraft(true, executor), client
+}
+
+func Test_draft(t *testing.T) {
+	tests := []struct {
+		cr       *forge.CodeRequest
+		expected []bool
+	}{
+		{cr: &forge.CodeRequest{Number: 2, State: forge.StateOpen}, expected: []bool{true}},
+		{cr: &forge.CodeRequest{Draft: true, Number: 2, State: forge.StateOpen}, expected: nil},
+	}
+
+	for _, test := range tests {
+		d, client := newTestDraft(t, test.cr)
+		if err := d.draft(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(client.drafts, test.expected) {
+			t.Errorf("expected %v, got %v", test.expected, client.drafts)
+		}
+	}
+}
+
+func Test_draft_NoCodeRequest(t *testing.T) {
+	d, _ := newTestDraft(t, nil)
+
+	if err := d.draft(); err == nil || !strings.Contains(err.Error(), "doesn't have an open code request") {
+		t.Errorf("expected an error about the missing code request, got %v", err)
+	}
+}
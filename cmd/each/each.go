@@ -0,0 +1,345 @@
+package each
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// Kinds of filter.
+const (
+	filterGlob  = "glob"
+	filterHost  = "host"
+	filterOwner = "owner"
+)
+
+type Each struct {
+	Args     []string
+	Debug    bool
+	Depth    int
+	Executor executor.ExecutorInterface
+	Filters  []string
+	Jobs     int
+	Run      func(dir string, args []string, w io.Writer) error
+}
+
+type filter struct {
+	kind  string
+	value string
+}
+
+type result struct {
+	err  error
+	name string
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug   bool
+		depth   int
+		filters []string
+		jobs    int
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "each [--filter kind=value]... -- [subcommand] [args]...",
+		Short:                 "Runs a git-helper command in every repository under the current directory",
+		Args:                  subcommand,
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			e := newEach(args, filters, jobs, depth, debug, executor.NewExecutor(debug))
+			e.execute()
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().IntVar(&depth, "depth", 0, "how many directories deep to look for repositories, or 0 for no limit")
+	cmd.Flags().StringArrayVar(&filters, "filter", nil, "only run in repositories matching owner=NAME, host=NAME or glob=PATTERN (repeatable)")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", 4, "how many repositories to run in at once")
+
+	return cmd
+}
+
+// subcommand checks that the arguments start with another git-helper command.
+func subcommand(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return errors.New("pass the git-helper command to run after --, like git-helper each -- sync")
+	}
+
+	sub, _, err := cmd.Root().Find(args)
+	if err != nil || sub == cmd.Root() {
+		return fmt.Errorf("%s isn't a git-helper command", args[0])
+	}
+
+	if sub == cmd {
+		return errors.New("each can't run itself")
+	}
+
+	return nil
+}
+
+func newEach(args, filters []string, jobs, depth int, debug bool, executor executor.ExecutorInterface) *Each {
+	return &Each{
+		Args:     args,
+		Debug:    debug,
+		Depth:    depth,
+		Executor: executor,
+		Filters:  filters,
+		Jobs:     jobs,
+		Run:      runSelf,
+	}
+}
+
+func (e *Each) execute() {
+	err := e.each(os.Stdout)
+	if err != nil {
+		utils.HandleError(err, e.Debug, nil)
+		return
+	}
+}
+
+func (e *Each) each(w io.Writer) error {
+	filters, err := parseFilters(e.Filters)
+	if err != nil {
+		return err
+	}
+
+	baseDir, err := executor.BaseDir()
+	if err != nil {
+		return err
+	}
+
+	repos, err := git.FindRepos(baseDir, e.Depth)
+	if err != nil {
+		return err
+	}
+
+	dirs := []string{}
+	names := []string{}
+	for _, dir := range repos {
+		name := git.RepoName(baseDir, dir)
+		if e.matches(dir, name, filters) {
+			dirs = append(dirs, dir)
+			names = append(names, name)
+		}
+	}
+
+	if len(dirs) == 0 {
+		return errors.New("no repositories under " + baseDir + " match")
+	}
+
+	results := e.runAll(w, dirs, names)
+	return summarize(w, results)
+}
+
+// runAll runs the subcommand in each directory, at most Jobs at a time, and
+// prefixes every line of output with the repository's name.
+func (e *Each) runAll(w io.Writer, dirs, names []string) []result {
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make([]result, len(dirs))
+	slots := make(chan struct{}, max(e.Jobs, 1))
+
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			out := &prefixWriter{mu: &mu, out: w, prefix: fmt.Sprintf("%-*s | ", width, names[i])}
+			err := e.Run(dir, e.Args, out)
+			out.Flush()
+			results[i] = result{err: err, name: names[i]}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (e *Each) matches(dir, name string, filters []filter) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	var remotes []*git.Remote
+	kinds := map[string]bool{}
+	for _, f := range filters {
+		if f.kind != filterGlob && remotes == nil {
+			remotes = e.remotes(dir)
+		}
+
+		kinds[f.kind] = kinds[f.kind] || f.matches(name, remotes)
+	}
+
+	for _, matched := range kinds {
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *Each) remotes(dir string) []*git.Remote {
+	remotes := []*git.Remote{}
+	output, err := e.Executor.Exec("actionAndOutput", "git", "-C", dir, "remote", "-v")
+	if err != nil {
+		return remotes
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		remote, err := git.ParseRemoteURL(fields[1])
+		if err == nil {
+			remotes = append(remotes, remote)
+		}
+	}
+
+	return remotes
+}
+
+// matches reports whether the repository matches the filter. Owners match
+// their GitLab subgroups too, and globs match the repository's path or name.
+func (f filter) matches(name string, remotes []*git.Remote) bool {
+	if f.kind == filterGlob {
+		matched, _ := filepath.Match(f.value, name)
+		base, _ := filepath.Match(f.value, filepath.Base(name))
+		return matched || base
+	}
+
+	for _, remote := range remotes {
+		switch f.kind {
+		case filterHost:
+			if strings.EqualFold(remote.Host, f.value) {
+				return true
+			}
+		case filterOwner:
+			owner := strings.ToLower(remote.Owner)
+			value := strings.ToLower(f.value)
+			if owner == value || strings.HasPrefix(owner, value+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseFilters parses filters like owner=octocat, host=gitlab.com or
+// glob=work/*. A filter without a kind is a glob.
+func parseFilters(values []string) ([]filter, error) {
+	filters := []filter{}
+	for _, value := range values {
+		kind, pattern, found := strings.Cut(value, "=")
+		if !found {
+			kind, pattern = filterGlob, value
+		}
+
+		switch kind {
+		case filterGlob:
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s isn't a valid glob: %w", pattern, err)
+			}
+		case filterHost, filterOwner:
+		default:
+			return nil, fmt.Errorf("unknown filter %s, use owner=, host= or glob=", kind)
+		}
+
+		filters = append(filters, filter{kind: kind, value: pattern})
+	}
+
+	return filters, nil
+}
+
+func summarize(w io.Writer, results []result) error {
+	failed := []string{}
+	for _, r := range results {
+		if r.err != nil {
+			failed = append(failed, r.name)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d succeeded, %d failed\n", len(results)-len(failed), len(failed))
+	if len(failed) == 0 {
+		return nil
+	}
+
+	for _, name := range failed {
+		fmt.Fprintln(w, "  Failed:", name)
+	}
+
+	return fmt.Errorf("failed in %d of %d repositories", len(failed), len(results))
+}
+
+// runSelf runs git-helper as if it was started in dir. Its output goes to w,
+// and it can't prompt, since several can run at once.
+func runSelf(dir string, args []string, w io.Writer) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(self, append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
+
+// prefixWriter writes whole lines to out, each starting with prefix. Writers
+// sharing mu never interleave within a line.
+type prefixWriter struct {
+	mu      *sync.Mutex
+	out     io.Writer
+	partial []byte
+	prefix  string
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+
+		p.writeLine(p.partial[:i+1])
+		p.partial = p.partial[i+1:]
+	}
+}
+
+// Flush writes whatever is left without a trailing newline.
+func (p *prefixWriter) Flush() {
+	if len(p.partial) > 0 {
+		p.writeLine(append(p.partial, '\n'))
+		p.partial = nil
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.out, "%s%s", p.prefix, line)
+}
@@ -0,0 +1,217 @@
+package each
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/spf13/cobra"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Outputs map[string]string
+	mu      sync.Mutex
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+// newTestEach makes a directory of repositories, each with an origin remote.
+func newTestEach(t *testing.T, filters []string, remotes map[string]string) (*Each, string) {
+	root := t.TempDir()
+	originalWorkingDir := executor.WorkingDir
+	t.Cleanup(func() {
+		executor.WorkingDir = originalWorkingDir
+	})
+	executor.WorkingDir = root
+
+	outputs := map[string]string{}
+	for name, url := range remotes {
+		dir := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+		outputs["git -C "+dir+" remote -v"] = fmt.Sprintf("origin\t%s (fetch)\norigin\t%s (push)\n", url, url)
+	}
+
+	e := newEach([]string{"sync"}, filters, 2, 0, true, &MockExecutor{Debug: true, Outputs: outputs})
+	return e, root
+}
+
+var testRemotes = map[string]string{
+	"hello":        "git@github.com:octocat/hello.git",
+	"work/api":     "https://gitlab.com/acme/backend/api.git",
+	"work/web":     "git@gitlab.com:acme/web.git",
+	"forks/spoon":  "git@github.com:hubot/spoon-knife.git",
+	"forks/linter": "https://code.example.com/hubot/linter.git",
+}
+
+func Test_each(t *testing.T) {
+	e, root := newTestEach(t, nil, testRemotes)
+
+	var running, most atomic.Int32
+	e.Run = func(dir string, args []string, w io.Writer) error {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			previous := most.Load()
+			if now <= previous || most.CompareAndSwap(previous, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		fmt.Fprintf(w, "ran %s\nin %s", strings.Join(args, " "), filepath.Base(dir))
+		if strings.HasSuffix(dir, "web") {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	err := e.each(&out)
+	if err == nil || err.Error() != "failed in 1 of 5 repositories" {
+		t.Errorf("expected an error about the failed repository, got %v", err)
+	}
+
+	if most.Load() > 2 {
+		t.Errorf("expected at most 2 repositories at once, got %d", most.Load())
+	}
+
+	for _, expected := range []string{
+		"hello        | ran sync\n",
+		"work/web     | in web\n",
+		"forks/linter | in linter\n",
+		"\n4 succeeded, 1 failed\n  Failed: work/web\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected the output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+
+	if strings.Contains(out.String(), root) {
+		t.Errorf("expected repositories to be named relative to %s, got:\n%s", root, out.String())
+	}
+}
+
+func Test_each_Filters(t *testing.T) {
+	tests := []struct {
+		filters  []string
+		expected []string
+	}{
+		{filters: []string{"owner=acme"}, expected: []string{"work/api", "work/web"}},
+		{filters: []string{"owner=acme/backend"}, expected: []string{"work/api"}},
+		{filters: []string{"host=github.com"}, expected: []string{"forks/spoon", "hello"}},
+		{filters: []string{"owner=hubot", "owner=octocat", "host=github.com"}, expected: []string{"forks/spoon", "hello"}},
+		{filters: []string{"forks/*"}, expected: []string{"forks/linter", "forks/spoon"}},
+		{filters: []string{"glob=w*"}, expected: []string{"work/web"}},
+		{filters: []string{"glob=forks/*", "owner=octocat"}, expected: []string{}},
+	}
+
+	for _, test := range tests {
+		e, _ := newTestEach(t, test.filters, testRemotes)
+
+		var mu sync.Mutex
+		ran := []string{}
+		e.Run = func(dir string, args []string, w io.Writer) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, dir)
+			return nil
+		}
+
+		err := e.each(io.Discard)
+		if len(test.expected) == 0 {
+			if err == nil {
+				t.Errorf("%v: expected an error when nothing matches", test.filters)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names := []string{}
+		for _, dir := range ran {
+			names = append(names, git.RepoName(executor.WorkingDir, dir))
+		}
+		sort.Strings(names)
+
+		if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%v: expected %v, got %v", test.filters, test.expected, names)
+		}
+	}
+}
+
+func Test_parseFilters(t *testing.T) {
+	for _, value := range []string{"team=acme", "glob=[", "["} {
+		if _, err := parseFilters([]string{value}); err == nil {
+			t.Errorf("expected an error for %s", value)
+		}
+	}
+}
+
+func Test_prefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	p := &prefixWriter{mu: &sync.Mutex{}, out: &out, prefix: "hello | "}
+
+	fmt.Fprint(p, "one\ntw")
+	fmt.Fprint(p, "o\nthree")
+	if out.String() != "hello | one\nhello | two\n" {
+		t.Errorf("expected only whole lines before flushing, got %q", out.String())
+	}
+
+	p.Flush()
+	if out.String() != "hello | one\nhello | two\nhello | three\n" {
+		t.Errorf("expected the rest after flushing, got %q", out.String())
+	}
+}
+
+func Test_subcommand(t *testing.T) {
+	root := &cobra.Command{Use: "git-helper"}
+	each := NewCommand()
+	root.AddCommand(each, &cobra.Command{Use: "sync", Run: func(*cobra.Command, []string) {}})
+
+	tests := []struct {
+		args []string
+		err  bool
+	}{
+		{args: []string{"sync", "--push"}},
+		{args: []string{}, err: true},
+		{args: []string{"unknown"}, err: true},
+		{args: []string{"each", "--", "sync"}, err: true},
+	}
+
+	for _, test := range tests {
+		err := subcommand(each, test.args)
+		if (err != nil) != test.err {
+			t.Errorf("%v: expected an error to be %t, got %v", test.args, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package installLinks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/plugins"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type InstallLinks struct {
+	Debug    bool
+	Dir      string
+	Executor executor.ExecutorInterface
+	Root     *cobra.Command
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug bool
+		dir   string
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "install-links [--dir]",
+		Short:                 "Links git-<command> to git-helper for each command, so git runs them natively",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newInstallLinks(debug, dir, executor.NewExecutor(debug), cmd.Root()).execute()
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().StringVar(&dir, "dir", "", "directory to create the links in (defaults to the directory git-helper is in)")
+
+	return cmd
+}
+
+func newInstallLinks(debug bool, dir string, executor executor.ExecutorInterface, root *cobra.Command) *InstallLinks {
+	return &InstallLinks{
+		Debug:    debug,
+		Dir:      dir,
+		Executor: executor,
+		Root:     root,
+	}
+}
+
+func (il *InstallLinks) execute() {
+	target, err := plugins.Executable()
+	if err != nil {
+		utils.HandleError(err, il.Debug, nil)
+		return
+	}
+
+	dir := il.Dir
+	if dir == "" {
+		dir = filepath.Dir(target)
+	}
+
+	p := plugins.NewPlugins(il.Debug, dir, il.Executor)
+	commands := p.Commands(il.Root)
+	removed, err := p.Link(commands, target)
+	if err != nil {
+		utils.HandleError(err, il.Debug, nil)
+		return
+	}
+
+	for _, name := range removed {
+		fmt.Printf("Removed %s, which is no longer a Git Helper command\n", name)
+	}
+
+	fmt.Printf("Linked %d commands in %s to %s\n", len(commands), dir, target)
+
+	if !onPath(dir) {
+		fmt.Printf("\n%s isn't on your PATH, so git won't find the commands until it is\n", dir)
+	}
+}
+
+func onPath(dir string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+
+	return slices.ContainsFunc(filepath.SplitList(os.Getenv("PATH")), func(entry string) bool {
+		absEntry, err := filepath.Abs(entry)
+		return err == nil && absEntry == absDir
+	})
+}
@@ -0,0 +1,76 @@
+package installLinks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/plugins"
+	"github.com/spf13/cobra"
+)
+
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Output  []byte
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	return me.Output, nil
+}
+
+func Test_execute(t *testing.T) {
+	binDir := t.TempDir()
+	target := filepath.Join(binDir, "git-helper")
+	os.WriteFile(target, []byte("binary"), 0755)
+
+	originalExecutable := plugins.Executable
+	t.Cleanup(func() {
+		plugins.Executable = originalExecutable
+	})
+	plugins.Executable = func() (string, error) {
+		return target, nil
+	}
+
+	root := &cobra.Command{Use: "git-helper"}
+	root.AddCommand(&cobra.Command{Use: "code-request"}, &cobra.Command{Use: "new-branch"})
+
+	customDir := t.TempDir()
+	tests := []struct {
+		name    string
+		dir     string
+		linkDir string
+	}{
+		{name: "next to the binary", dir: "", linkDir: binDir},
+		{name: "custom directory", dir: customDir, linkDir: customDir},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			newInstallLinks(true, test.dir, &MockExecutor{Debug: true}, root).execute()
+
+			for _, name := range []string{"git-code-request", "git-new-branch"} {
+				dest, err := os.Readlink(filepath.Join(test.linkDir, name))
+				if err != nil || dest != target {
+					t.Errorf("expected %s to link to %s, got %s (%v)", name, target, dest, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_onPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", "/usr/bin"+string(os.PathListSeparator)+dir)
+
+	if !onPath(dir) {
+		t.Errorf("expected %s to be on the PATH", dir)
+	}
+
+	if onPath(t.TempDir()) {
+		t.Error("expected another directory not to be on the PATH")
+	}
+}
@@ -0,0 +1,278 @@
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/commandline"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type Merge struct {
+	Auto         bool
+	Debug        bool
+	DeleteBranch bool
+	Executor     executor.ExecutorInterface
+	Method       string
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		auto         bool
+		debug        bool
+		deleteBranch bool
+		merge        bool
+		rebase       bool
+		squash       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "merge",
+		Short:                 "Merges the current branch's code request once it's ready",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			method := ""
+			switch {
+			case merge:
+				method = forge.MethodMerge
+			case rebase:
+				method = forge.MethodRebase
+			case squash:
+				method = forge.MethodSquash
+			}
+
+			newMerge(method, auto, deleteBranch, debug, executor.NewExecutor(debug)).execute()
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&auto, "auto", false, "merges automatically once required checks and approvals pass")
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().BoolVar(&deleteBranch, "delete-branch", false, "deletes the branch locally and on the remote after merging")
+	cmd.Flags().BoolVar(&merge, "merge", false, "merges with a merge commit")
+	cmd.Flags().BoolVar(&rebase, "rebase", false, "rebases the commits onto the base branch")
+	cmd.Flags().BoolVar(&squash, "squash", false, "squashes the commits into one")
+	cmd.MarkFlagsMutuallyExclusive("merge", "rebase", "squash")
+
+	return cmd
+}
+
+func newMerge(method string, auto, deleteBranch, debug bool, executor executor.ExecutorInterface) *Merge {
+	return &Merge{
+		Auto:         auto,
+		Debug:        debug,
+		DeleteBranch: deleteBranch,
+		Executor:     executor,
+		Method:       method,
+	}
+}
+
+func (m *Merge) execute() {
+	err := m.merge()
+	if err != nil {
+		utils.HandleError(err, m.Debug, nil)
+		return
+	}
+}
+
+func (m *Merge) merge() error {
+	g := git.NewGit(m.Debug, m.Executor)
+	branch := g.CurrentBranch()
+	defaultBranch := g.DefaultBranch()
+	if branch == defaultBranch {
+		return fmt.Errorf("already on the default branch %s, check out the branch to merge first", defaultBranch)
+	}
+
+	remote, err := g.Remote("origin")
+	if err != nil {
+		return err
+	}
+
+	client, err := forge.NewClient(m.Debug, remote)
+	if err != nil {
+		return err
+	}
+
+	cr, err := client.FindCodeRequest(branch)
+	if err != nil {
+		return err
+	}
+
+	if cr == nil || cr.State != forge.StateOpen {
+		return fmt.Errorf("%s doesn't have an open code request", branch)
+	}
+
+	status, err := client.MergeStatus(cr.Number)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s has %d approval(s)\n", cr.URL, status.Approvals)
+	for _, warning := range status.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	if len(status.Blockers) > 0 {
+		return fmt.Errorf("%s can't be merged: %s", cr.URL, strings.Join(status.Blockers, "; "))
+	}
+
+	if len(status.Waiting) > 0 && !m.Auto {
+		return fmt.Errorf("%s can't be merged yet: %s (use --auto to merge it once it's ready)", cr.URL, strings.Join(status.Waiting, "; "))
+	}
+
+	// Check before merging, since the local commits wouldn't be merged and
+	// deleting the branch afterwards would lose them. Automatic merges leave
+	// the local branch for done, which checks again.
+	auto := m.Auto && !status.Ready()
+	if m.DeleteBranch && !auto {
+		if status.HeadSHA != "" {
+			cr.HeadSHA = status.HeadSHA
+		}
+
+		err = forge.CheckPushed(g, branch, cr)
+		if err != nil {
+			return fmt.Errorf("%w, or merge without --delete-branch", err)
+		}
+	}
+
+	method, err := m.method(status.Methods)
+	if err != nil {
+		return err
+	}
+
+	options := forge.MergeOptions{
+		Auto:         auto,
+		DeleteBranch: m.DeleteBranch,
+		Method:       method,
+		SHA:          status.HeadSHA,
+	}
+
+	if method == forge.MethodSquash {
+		options.Message, err = m.squashMessage(status.SquashMessage)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Merge(cr.Number, options)
+	if err != nil {
+		return err
+	}
+
+	if options.Auto {
+		fmt.Printf("%s will be merged once it's ready: %s\n", cr.URL, strings.Join(status.Waiting, "; "))
+		if m.DeleteBranch {
+			fmt.Printf("Run git-helper done once it's merged to delete %s locally\n", branch)
+		}
+		return nil
+	}
+
+	fmt.Printf("Merged %s\n", cr.URL)
+	if m.DeleteBranch {
+		return m.deleteBranch(g, client, branch, defaultBranch)
+	}
+
+	return nil
+}
+
+// method picks a merge method the forge allows, asking when there's more than
+// one to choose from.
+func (m *Merge) method(allowed []string) (string, error) {
+	if len(allowed) == 0 {
+		return "", errors.New("no merge methods are allowed for this repository")
+	}
+
+	if m.Method != "" {
+		if !slices.Contains(allowed, m.Method) {
+			return "", fmt.Errorf("%s isn't allowed for this repository, choose from: %s", m.Method, strings.Join(allowed, ", "))
+		}
+		return m.Method, nil
+	}
+
+	if len(allowed) == 1 {
+		return allowed[0], nil
+	}
+
+	return commandline.AskMultipleChoice("How should it be merged?", allowed), nil
+}
+
+// squashMessage offers to edit the default squash commit message, returning
+// an empty message to keep the forge's default.
+func (m *Merge) squashMessage(message string) (string, error) {
+	if !commandline.AskYesNoQuestion("Edit the squash commit message?") {
+		return "", nil
+	}
+
+	file, err := os.CreateTemp("", "git-helper-squash-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(message + "\n")
+	file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	editor := strings.Fields(m.editor())
+	_, err = m.Executor.Exec("waitAndStdout", editor[0], append(editor[1:], file.Name())...)
+	if err != nil {
+		return "", fmt.Errorf("could not edit the squash commit message: %w", err)
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+
+	message = strings.TrimSpace(string(edited))
+	if message == "" {
+		return "", errors.New("the squash commit message is empty, not merging")
+	}
+
+	return message, nil
+}
+
+// deleteBranch deletes the merged branch like done does, moving any branches
+// stacked on it onto its parent first.
+func (m *Merge) deleteBranch(g *git.Git, client forge.Client, branch, defaultBranch string) error {
+	forge.MoveChildren(m.Debug, m.Executor, client, branch, defaultBranch)
+
+	g.Checkout(defaultBranch)
+	g.Pull()
+
+	err := g.DeleteBranch(branch)
+	if err != nil {
+		return err
+	}
+
+	if g.RemoteBranchExists(branch) {
+		err = g.DeleteRemoteBranch(branch)
+		if err != nil {
+			return err
+		}
+	}
+
+	g.Fetch()
+	fmt.Printf("Deleted %s\n", branch)
+	return nil
+}
+
+func (m *Merge) editor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(env)); editor != "" {
+			return editor
+		}
+	}
+
+	return "vi"
+}
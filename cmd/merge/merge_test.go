@@ -0,0 +1,235 @@
+package merge
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/commandline"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there. Running the editor rewrites
+// the file it's given with Edited.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Edited  string
+	Outputs map[string]string
+	Run     []string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+
+	if command == "fake-editor" {
+		return []byte{}, os.WriteFile(args[len(args)-1], []byte(me.Edited), 0o600)
+	}
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+type fakeClient struct {
+	forge.Client
+	merged   map[int]forge.MergeOptions
+	requests map[string]*forge.CodeRequest
+	status   *forge.MergeStatus
+}
+
+func (c *fakeClient) FindCodeRequest(branch string) (*forge.CodeRequest, error) {
+	return c.requests[branch], nil
+}
+
+func (c *fakeClient) Merge(number int, options forge.MergeOptions) error {
+	c.merged[number] = options
+	return nil
+}
+
+func (c *fakeClient) MergeStatus(number int) (*forge.MergeStatus, error) {
+	return c.status, nil
+}
+
+func newTestMerge(t *testing.T, method string, auto, deleteBranch bool, status *forge.MergeStatus) (*Merge, *MockExecutor, *fakeClient) {
+	client := &fakeClient{
+		merged:   map[int]forge.MergeOptions{},
+		requests: map[string]*forge.CodeRequest{"feature": {Number: 1, State: forge.StateOpen, URL: "https://github.com/octocat/hello/pull/1"}},
+		status:   status,
+	}
+
+	originalNewClient := forge.NewClient
+	originalAskYesNoQuestion := commandline.AskYesNoQuestion
+	t.Cleanup(func() {
+		forge.NewClient = originalNewClient
+		commandline.AskYesNoQuestion = originalAskYesNoQuestion
+	})
+	forge.NewClient = func(debug bool, remote *git.Remote) (forge.Client, error) {
+		return client, nil
+	}
+	commandline.AskYesNoQuestion = func(question string) bool {
+		return false
+	}
+
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git branch": "  main\n* feature\n",
+		"git symbolic-ref refs/remotes/origin/HEAD":       "refs/remotes/origin/main\n",
+		"git remote get-url --push origin":                "git@github.com:octocat/hello.git\n",
+		"git rev-list --count abc123..feature":            "0\n",
+		"git checkout main":                               "",
+		"git pull":                                        "",
+		"git branch -D feature":                           "",
+		"git ls-remote --heads origin refs/heads/feature": "abc123\trefs/heads/feature\n",
+		"git push origin --delete feature":                "",
+		"git fetch -p":                                    "",
+	}}
+
+	return newMerge(method, auto, deleteBranch, true, executor), executor, client
+}
+
+func ran(executor *MockExecutor, line string) bool {
+	for _, run := range executor.Run {
+		if run == line {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_merge(t *testing.T) {
+	status := &forge.MergeStatus{HeadSHA: "abc123", Methods: []string{forge.MethodMerge}}
+	m, executor, client := newTestMerge(t, "", false, true, status)
+
+	if err := m.merge(); err != nil {
+		t.Fatal(err)
+	}
+
+	options, ok := client.merged[1]
+	if !ok || options.Method != forge.MethodMerge || options.SHA != "abc123" || options.Auto {
+		t.Errorf("expected a merge commit at abc123, got %+v", options)
+	}
+
+	for _, line := range []string{"git checkout main", "git branch -D feature", "git push origin --delete feature"} {
+		if !ran(executor, line) {
+			t.Errorf("expected %q to be run, got %v", line, executor.Run)
+		}
+	}
+}
+
+func Test_merge_Refuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		auto     bool
+		method   string
+		status   *forge.MergeStatus
+		expected string
+	}{
+		{name: "blocked", status: &forge.MergeStatus{Blockers: []string{"it's still a draft"}, Methods: []string{"merge"}}, expected: "it's still a draft"},
+		{name: "blocked with auto", auto: true, status: &forge.MergeStatus{Blockers: []string{"it has conflicts"}, Methods: []string{"merge"}}, expected: "it has conflicts"},
+		{name: "waiting", status: &forge.MergeStatus{Waiting: []string{"checks are still running"}, Methods: []string{"merge"}}, expected: "use --auto"},
+		{name: "method not allowed", method: "rebase", status: &forge.MergeStatus{Methods: []string{"merge", "squash"}}, expected: "rebase isn't allowed"},
+		{name: "no methods", status: &forge.MergeStatus{}, expected: "no merge methods"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, _, client := newTestMerge(t, test.method, test.auto, false, test.status)
+			err := m.merge()
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected an error containing %q, got %v", test.expected, err)
+			}
+
+			if len(client.merged) > 0 {
+				t.Errorf("expected nothing to be merged, got %v", client.merged)
+			}
+		})
+	}
+}
+
+func Test_merge_DeleteBranchRefusesUnpushedCommits(t *testing.T) {
+	status := &forge.MergeStatus{HeadSHA: "abc123", Methods: []string{forge.MethodMerge}}
+	m, executor, client := newTestMerge(t, "", false, true, status)
+	executor.Outputs["git rev-list --count abc123..feature"] = "2\n"
+
+	err := m.merge()
+	if err == nil || !strings.Contains(err.Error(), "2 commit(s)") {
+		t.Errorf("expected an error about unpushed commits, got %v", err)
+	}
+
+	if len(client.merged) > 0 || ran(executor, "git branch -D feature") {
+		t.Errorf("expected nothing to be merged or deleted, got %v and %v", client.merged, executor.Run)
+	}
+}
+
+func Test_merge_Auto(t *testing.T) {
+	status := &forge.MergeStatus{Methods: []string{forge.MethodRebase}, Waiting: []string{"the pipeline is still running"}}
+	m, executor, client := newTestMerge(t, "", true, true, status)
+
+	if err := m.merge(); err != nil {
+		t.Fatal(err)
+	}
+
+	options := client.merged[1]
+	if !options.Auto || !options.DeleteBranch || options.Method != forge.MethodRebase {
+		t.Errorf("expected an automatic rebase that deletes the branch, got %+v", options)
+	}
+
+	if ran(executor, "git branch -D feature") {
+		t.Errorf("expected the branch to be kept until it merges, got %v", executor.Run)
+	}
+}
+
+func Test_merge_ChoosesMethod(t *testing.T) {
+	originalAskMultipleChoice := commandline.AskMultipleChoice
+	t.Cleanup(func() {
+		commandline.AskMultipleChoice = originalAskMultipleChoice
+	})
+
+	var choices []string
+	commandline.AskMultipleChoice = func(question string, c []string) string {
+		choices = c
+		return forge.MethodRebase
+	}
+
+	status := &forge.MergeStatus{Methods: []string{forge.MethodMerge, forge.MethodRebase}}
+	m, _, client := newTestMerge(t, "", false, false, status)
+	if err := m.merge(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(choices) != 2 || client.merged[1].Method != forge.MethodRebase {
+		t.Errorf("expected to choose between %v and rebase, got %v and %+v", status.Methods, choices, client.merged[1])
+	}
+}
+
+func Test_merge_EditsSquashMessage(t *testing.T) {
+	t.Setenv("VISUAL", "fake-editor --wait")
+
+	status := &forge.MergeStatus{Methods: []string{forge.MethodSquash}, SquashMessage: "Add things (#1)\n\n* Add a thing"}
+	m, executor, client := newTestMerge(t, "", false, false, status)
+	executor.Edited = "Add the things\n\nAll of them.\n"
+	commandline.AskYesNoQuestion = func(question string) bool {
+		return true
+	}
+
+	if err := m.merge(); err != nil {
+		t.Fatal(err)
+	}
+
+	if executor.Command != "fake-editor" || executor.Args[0] != "--wait" {
+		t.Errorf("expected the editor to be run, got %v", executor.Run)
+	}
+
+	if message := client.merged[1].Message; message != "Add the things\n\nAll of them." {
+		t.Errorf("expected the edited message, got %q", message)
+	}
+}
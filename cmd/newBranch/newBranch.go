@@ -6,6 +6,8 @@ import (
 	"github.com/emmahsax/go-git-helper/internal/commandline"
 	"github.com/emmahsax/go-git-helper/internal/executor"
 	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/stack"
+	"github.com/emmahsax/go-git-helper/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -13,11 +15,13 @@ type NewBranch struct {
 	Branch   string
 	Debug    bool
 	Executor executor.ExecutorInterface
+	Stacked  bool
 }
 
 func NewCommand() *cobra.Command {
 	var (
-		debug bool
+		debug   bool
+		stacked bool
 	)
 
 	cmd := &cobra.Command{
@@ -26,21 +30,23 @@ func NewCommand() *cobra.Command {
 		Args:                  cobra.MaximumNArgs(1),
 		DisableFlagsInUseLine: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			newNewBranch(determineBranch(args), debug, executor.NewExecutor(debug)).execute()
+			newNewBranch(determineBranch(args), debug, stacked, executor.NewExecutor(debug)).execute()
 			return nil
 		},
 	}
 
 	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().BoolVar(&stacked, "stacked", false, "stack the new branch on the current branch instead of branching off the default branch")
 
 	return cmd
 }
 
-func newNewBranch(branch string, debug bool, executor executor.ExecutorInterface) *NewBranch {
+func newNewBranch(branch string, debug, stacked bool, executor executor.ExecutorInterface) *NewBranch {
 	return &NewBranch{
 		Branch:   branch,
 		Debug:    debug,
 		Executor: executor,
+		Stacked:  stacked,
 	}
 }
 
@@ -59,7 +65,12 @@ func askForBranch() string {
 func (nb *NewBranch) execute() {
 	fmt.Println("Attempting to create a new branch:", nb.Branch)
 	g := git.NewGit(nb.Debug, nb.Executor)
-	g.Pull()
+	parent := ""
+	if nb.Stacked {
+		parent = nb.stackParent(g)
+	} else {
+		g.Pull()
+	}
 
 	for {
 		err := g.CreateBranch(nb.Branch)
@@ -71,6 +82,28 @@ func (nb *NewBranch) execute() {
 		nb.Branch = askForBranch()
 	}
 
+	if parent != "" {
+		err := stack.NewStack(nb.Debug, nb.Executor).Track(nb.Branch, parent)
+		if err != nil {
+			utils.HandleError(err, nb.Debug, nil)
+			return
+		}
+
+		fmt.Printf("Stacked %s on %s\n", nb.Branch, parent)
+	}
+
 	g.Checkout(nb.Branch)
 	g.PushBranch(nb.Branch)
 }
+
+// stackParent returns the current branch, which the new branch is stacked on.
+// Stacking on the default branch is the same as not stacking at all.
+func (nb *NewBranch) stackParent(g *git.Git) string {
+	parent := g.CurrentBranch()
+	if parent == g.DefaultBranch() {
+		utils.HandleError(fmt.Errorf("%s is the default branch, check out the branch to stack on first", parent), nb.Debug, nil)
+		return ""
+	}
+
+	return parent
+}
@@ -1,21 +1,33 @@
 package newBranch
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/emmahsax/go-git-helper/internal/commandline"
 )
 
+// MockExecutor returns Output for every command, unless Outputs is set, in
+// which case it returns the output for each command line in Outputs and
+// nothing for the rest.
 type MockExecutor struct {
 	Args    []string
 	Command string
 	Debug   bool
 	Output  []byte
+	Outputs map[string]string
+	Run     []string
 }
 
 func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
 	me.Command = command
 	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+	if me.Outputs != nil {
+		return []byte(me.Outputs[line]), nil
+	}
+
 	return me.Output, nil
 }
 
@@ -86,7 +98,7 @@ func Test_execute(t *testing.T) {
 
 	for _, test := range tests {
 		executor := &MockExecutor{Debug: true}
-		nb := newNewBranch("hello-world", true, executor)
+		nb := newNewBranch("hello-world", true, false, executor)
 		nb.execute()
 
 		if executor.Command != "git" {
@@ -104,3 +116,27 @@ func Test_execute(t *testing.T) {
 		}
 	}
 }
+
+func Test_execute_Stacked(t *testing.T) {
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git branch": "  main\n* feature-a\n",
+		"git symbolic-ref refs/remotes/origin/HEAD": "refs/remotes/origin/main\n",
+	}}
+	nb := newNewBranch("feature-b", true, true, executor)
+	nb.execute()
+
+	for _, line := range executor.Run {
+		if line == "git pull" {
+			t.Errorf("expected a stacked branch not to pull, got %v", executor.Run)
+		}
+	}
+
+	expected := "git config branch.feature-b.git-helper-parent feature-a"
+	for _, line := range executor.Run {
+		if line == expected {
+			return
+		}
+	}
+
+	t.Errorf("expected %q to be run, got %v", expected, executor.Run)
+}
@@ -0,0 +1,135 @@
+package ready
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type Ready struct {
+	ConfigFile       configfile.ConfigFileInterface
+	Debug            bool
+	Executor         executor.ExecutorInterface
+	RequestReviewers bool
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug            bool
+		requestReviewers bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "ready",
+		Short:                 "Marks the current branch's code request as ready for review",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newReady(requestReviewers, debug, executor.NewExecutor(debug), configfile.NewConfigFile(debug)).execute()
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().BoolVar(&requestReviewers, "request-reviewers", false, "requests reviews from the reviewers in the config")
+
+	return cmd
+}
+
+func newReady(requestReviewers, debug bool, executor executor.ExecutorInterface, configFile configfile.ConfigFileInterface) *Ready {
+	return &Ready{
+		ConfigFile:       configFile,
+		Debug:            debug,
+		Executor:         executor,
+		RequestReviewers: requestReviewers,
+	}
+}
+
+func (r *Ready) execute() {
+	err := r.ready()
+	if err != nil {
+		utils.HandleError(err, r.Debug, nil)
+		return
+	}
+}
+
+func (r *Ready) ready() error {
+	reviewers, err := r.reviewers()
+	if err != nil {
+		return err
+	}
+
+	g := git.NewGit(r.Debug, r.Executor)
+	branch := g.CurrentBranch()
+
+	remote, err := g.Remote("origin")
+	if err != nil {
+		return err
+	}
+
+	client, err := forge.NewClient(r.Debug, remote)
+	if err != nil {
+		return err
+	}
+
+	cr, err := client.FindCodeRequest(branch)
+	if err != nil {
+		return err
+	}
+
+	if cr == nil || cr.State != forge.StateOpen {
+		return fmt.Errorf("%s doesn't have an open code request, run git-helper code-request to create one", branch)
+	}
+
+	if !cr.Draft {
+		fmt.Println(cr.URL, "is already ready for review")
+		if len(reviewers) > 0 {
+			fmt.Println("Not requesting reviews, since they're only requested when it becomes ready")
+		}
+		return nil
+	}
+
+	err = client.SetDraft(cr.Number, false)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Marked", cr.URL, "as ready for review")
+
+	if len(reviewers) == 0 {
+		return nil
+	}
+
+	err = client.RequestReviewers(cr.Number, reviewers)
+	if err != nil {
+		return fmt.Errorf("could not request reviews: %w", err)
+	}
+
+	fmt.Println("Requested reviews from", strings.Join(reviewers, ", "))
+	return nil
+}
+
+// reviewers returns who to request reviews from, which is no one unless
+// --request-reviewers is passed.
+func (r *Ready) reviewers() ([]string, error) {
+	if !r.RequestReviewers {
+		return nil, nil
+	}
+
+	config, err := r.ConfigFile.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.Reviewers) == 0 {
+		return nil, errors.New("no reviewers are configured, add some with git-helper config set reviewers octocat,my-org/my-team")
+	}
+
+	return config.Reviewers, nil
+}
@@ -0,0 +1,136 @@
+package ready
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Outputs map[string]string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+type fakeClient struct {
+	forge.Client
+	cr        *forge.CodeRequest
+	drafts    []bool
+	reviewers []string
+}
+
+func (c *fakeClient) FindCodeRequest(branch string) (*forge.CodeRequest, error) {
+	return c.cr, nil
+}
+
+func (c *fakeClient) SetDraft(number int, draft bool) error {
+	c.drafts = append(c.drafts, draft)
+	return nil
+}
+
+func (c *fakeClient) RequestReviewers(number int, reviewers []string) error {
+	c.reviewers = reviewers
+	return nil
+}
+
+func newTestReady(t *testing.T, config string, requestReviewers bool, cr *forge.CodeRequest) (*Ready, *fakeClient) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if config != "" {
+		os.MkdirAll(filepath.Join(home, ".git-helper"), 0700)
+		os.WriteFile(filepath.Join(home, ".git-helper", "config.yml"), []byte(config), 0600)
+	}
+
+	client := &fakeClient{cr: cr}
+	originalNewClient := forge.NewClient
+	t.Cleanup(func() {
+		forge.NewClient = originalNewClient
+	})
+	forge.NewClient = func(debug bool, remote *git.Remote) (forge.Client, error) {
+		return client, nil
+	}
+
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git branch":                       "  main\n* feature\n",
+		"git remote get-url --push origin": "git@github.com:octocat/hello.git\n",
+	}}
+	cf := configfile.NewConfigFile(true)
+	cf.Executor = executor
+
+	return newReady(requestReviewers, true, executor, cf), client
+}
+
+func Test_ready(t *testing.T) {
+	cr := &forge.CodeRequest{Draft: true, Number: 2, State: forge.StateOpen}
+	r, client := newTestReady(t, "", false, cr)
+
+	if err := r.ready(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(client.drafts, []bool{false}) || client.reviewers != nil {
+		t.Errorf("expected only the draft to be marked ready, got %v %v", client.drafts, client.reviewers)
+	}
+}
+
+func Test_ready_RequestReviewers(t *testing.T) {
+	cr := &forge.CodeRequest{Draft: true, Number: 2, State: forge.StateOpen}
+	r, client := newTestReady(t, "reviewers:\n  - hubot\n  - octo-org/reviewers\n", true, cr)
+
+	if err := r.ready(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(client.drafts, []bool{false}) || !reflect.DeepEqual(client.reviewers, []string{"hubot", "octo-org/reviewers"}) {
+		t.Errorf("expected reviews to be requested once the draft is ready, got %v %v", client.drafts, client.reviewers)
+	}
+
+	r, client = newTestReady(t, "reviewers:\n  - hubot\n", true, &forge.CodeRequest{Number: 2, State: forge.StateOpen})
+	if err := r.ready(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(client.drafts) != 0 || client.reviewers != nil {
+		t.Errorf("expected nothing to change for a code request that's already ready, got %v %v", client.drafts, client.reviewers)
+	}
+}
+
+func Test_ready_NoReviewers(t *testing.T) {
+	cr := &forge.CodeRequest{Draft: true, Number: 2, State: forge.StateOpen}
+	r, client := newTestReady(t, "", true, cr)
+
+	err := r.ready()
+	if err == nil || !strings.Contains(err.Error(), "no reviewers are configured") || len(client.drafts) != 0 {
+		t.Errorf("expected an error before changing anything, got %v %v", err, client.drafts)
+	}
+}
+
+func Test_ready_NotOpen(t *testing.T) {
+	r, _ := newTestReady(t, "", false, &forge.CodeRequest{Number: 2, State: forge.StateMerged})
+
+	if err := r.ready(); err == nil || !strings.Contains(err.Error(), "doesn't have an open code request") {
+		t.Errorf("expected an error about the merged code request, got %v", err)
+	}
+}
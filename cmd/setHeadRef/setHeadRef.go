@@ -1,8 +1,11 @@
 package setHeadRef
 
 import (
+	"fmt"
+
 	"github.com/emmahsax/go-git-helper/internal/executor"
 	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -20,10 +23,15 @@ func NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:                   "set-head-ref [defaultBranch]",
 		Short:                 "Sets the HEAD ref as a symbolic ref",
-		Args:                  cobra.ExactArgs(1),
+		Args:                  cobra.MaximumNArgs(1),
 		DisableFlagsInUseLine: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			newSetHeadRef(args[0], debug, executor.NewExecutor(debug)).execute()
+			var defaultBranch string
+			if len(args) > 0 {
+				defaultBranch = args[0]
+			}
+
+			newSetHeadRef(defaultBranch, debug, executor.NewExecutor(debug)).execute()
 			return nil
 		},
 	}
@@ -43,5 +51,17 @@ func newSetHeadRef(defaultBranch string, debug bool, executor executor.ExecutorI
 
 func (shr *SetHeadRef) execute() {
 	g := git.NewGit(shr.Debug, shr.Executor)
+
+	if shr.DefaultBranch == "" {
+		branch, err := g.DiscoverDefaultBranch(true)
+		if err != nil {
+			utils.HandleError(err, shr.Debug, nil)
+			return
+		}
+
+		fmt.Printf("Discovered origin's default branch: %s\n", branch)
+		shr.DefaultBranch = branch
+	}
+
 	g.SetHeadRef(shr.DefaultBranch)
 }
@@ -1,6 +1,7 @@
 package setHeadRef
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -108,3 +109,30 @@ func Test_execute(t *testing.T) {
 		})
 	}
 }
+
+func Test_execute_DiscoversDefaultBranch(t *testing.T) {
+	executor := &MockExecutor{Debug: false, Output: []byte("origin/main\n")}
+	shr := newSetHeadRef("", false, executor)
+	shr.execute()
+
+	if shr.DefaultBranch != "main" {
+		t.Errorf("Expected the discovered DefaultBranch 'main', got '%s'", shr.DefaultBranch)
+	}
+
+	expectedArgs := []string{"symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/main"}
+	if strings.Join(executor.Args, " ") != strings.Join(expectedArgs, " ") {
+		t.Errorf("Expected args %v, got %v", expectedArgs, executor.Args)
+	}
+}
+
+func Test_NewCommand_AcceptsNoArgs(t *testing.T) {
+	cmd := NewCommand()
+
+	if err := cmd.Args(cmd, []string{}); err != nil {
+		t.Errorf("Expected no args to be accepted, got %v", err)
+	}
+
+	if err := cmd.Args(cmd, []string{"main", "extra"}); err == nil {
+		t.Error("Expected two args to be rejected")
+	}
+}
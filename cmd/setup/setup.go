@@ -1,197 +1,429 @@
 package setup
 
 import (
-	"encoding/json"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/emmahsax/go-git-helper/internal/auth"
 	"github.com/emmahsax/go-git-helper/internal/commandline"
 	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
 	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/plugins"
 	"github.com/emmahsax/go-git-helper/internal/utils"
 	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
 )
 
 type Setup struct {
-	Debug      bool
-	Executor   executor.ExecutorInterface
-	Config     configfile.ConfigFileInterface
-	Owner      string
-	Repository string
+	Answers  *Answers
+	Debug    bool
+	Executor executor.ExecutorInterface
+	Config   configfile.ConfigFileInterface
+	Root     *cobra.Command
 }
 
-func NewCommand(packageOwner, packageRepository string) *cobra.Command {
+// Answers pre-fill setup's questions from flags or an answers file, so setup
+// can run unattended. Anything left unanswered is left as it is.
+type Answers struct {
+	GitHubUsername     string   `yaml:"github_username"`
+	GitHubToken        string   `yaml:"github_token"`
+	GitHubTokenCommand string   `yaml:"github_token_command"`
+	GitLabUsername     string   `yaml:"gitlab_username"`
+	GitLabToken        string   `yaml:"gitlab_token"`
+	GitLabTokenCommand string   `yaml:"gitlab_token_command"`
+	Plugins            bool     `yaml:"plugins"`
+	Completion         []string `yaml:"completion"`
+}
+
+type flagValues struct {
+	completion       []string
+	fromFile         string
+	githubTokenStdin bool
+	githubUsername   string
+	gitlabTokenStdin bool
+	gitlabUsername   string
+	plugins          bool
+}
+
+var shells = []string{"bash", "fish", "powershell", "zsh"}
+
+func NewCommand() *cobra.Command {
 	var (
 		debug bool
+		flags flagValues
 	)
 
 	cmd := &cobra.Command{
 		Use:                   "setup",
-		Short:                 "Creates a Git Helper config file at ~/.git-helper/config.yml",
+		Short:                 "Creates or updates the Git Helper config file at ~/.git-helper/config.yml",
 		Args:                  cobra.ExactArgs(0),
 		DisableFlagsInUseLine: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			newSetup(packageOwner, packageRepository, debug, executor.NewExecutor(debug), configfile.NewConfigFile(debug)).execute()
+			answers, err := answersFromFlags(cmd, flags, os.Stdin)
+			if err != nil {
+				utils.HandleError(err, debug, nil)
+				return nil
+			}
+
+			s := newSetup(debug, executor.NewExecutor(debug), configfile.NewConfigFile(debug), cmd.Root())
+			s.Answers = answers
+			s.execute()
 			return nil
 		},
 	}
 
 	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().StringSliceVar(&flags.completion, "completion", []string{}, "generates completion for these shells: "+strings.Join(shells, ", ")+" or all")
+	cmd.Flags().StringVar(&flags.fromFile, "from-file", "", "reads answers from a YAML file, with the same keys as the config file plus plugins and completion")
+	cmd.Flags().BoolVar(&flags.githubTokenStdin, "github-token-stdin", false, "reads the GitHub token from stdin")
+	cmd.Flags().StringVar(&flags.githubUsername, "github-username", "", "the GitHub username")
+	cmd.Flags().BoolVar(&flags.gitlabTokenStdin, "gitlab-token-stdin", false, "reads the GitLab token from stdin, after the GitHub token if both are read")
+	cmd.Flags().StringVar(&flags.gitlabUsername, "gitlab-username", "", "the GitLab username")
+	cmd.Flags().BoolVar(&flags.plugins, "plugins", false, "sets up the Git Helper plugins")
 
 	return cmd
 }
 
-func newSetup(owner, repository string, debug bool, executor executor.ExecutorInterface, config configfile.ConfigFileInterface) *Setup {
+func newSetup(debug bool, executor executor.ExecutorInterface, config configfile.ConfigFileInterface, root *cobra.Command) *Setup {
 	return &Setup{
-		Debug:      debug,
-		Executor:   executor,
-		Config:     config,
-		Owner:      owner,
-		Repository: repository,
+		Debug:    debug,
+		Executor: executor,
+		Config:   config,
+		Root:     root,
 	}
 }
 
-func (s *Setup) execute() {
-	s.setupConfig()
-	s.setupPlugins()
-	s.setupCompletion()
-}
+// answersFromFlags returns nil when setup should ask its questions, and
+// otherwise the answers file overridden by any flags that were set.
+func answersFromFlags(cmd *cobra.Command, flags flagValues, stdin io.Reader) (*Answers, error) {
+	changed := false
+	for _, name := range []string{"completion", "from-file", "github-token-stdin", "github-username", "gitlab-token-stdin", "gitlab-username", "plugins"} {
+		changed = changed || cmd.Flags().Changed(name)
+	}
 
-func (s *Setup) setupConfig() {
-	var create bool
+	if !changed {
+		return nil, nil
+	}
 
-	if s.Config.ConfigFileExists() {
-		create = commandline.AskYesNoQuestion("The " + s.Config.ConfigFile() + " file already exists. Do you wish to replace it?")
-	} else {
-		create = true
+	answers := &Answers{}
+	if flags.fromFile != "" {
+		var err error
+		answers, err = readAnswers(flags.fromFile)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	if create {
-		s.createOrUpdateConfig()
+	if cmd.Flags().Changed("github-username") {
+		answers.GitHubUsername = flags.githubUsername
 	}
-}
 
-func (s *Setup) createOrUpdateConfig() {
-	content := s.generateConfigFileContents()
+	if cmd.Flags().Changed("gitlab-username") {
+		answers.GitLabUsername = flags.gitlabUsername
+	}
 
-	if !s.Config.ConfigDirExists() {
-		err := os.Mkdir(s.Config.ConfigDir(), 0755)
+	if cmd.Flags().Changed("plugins") {
+		answers.Plugins = flags.plugins
+	}
+
+	if cmd.Flags().Changed("completion") {
+		answers.Completion = flags.completion
+	}
+
+	if flags.githubTokenStdin || flags.gitlabTokenStdin {
+		lines, err := readTokens(stdin)
 		if err != nil {
-			utils.HandleError(err, s.Debug, nil)
-			return
+			return nil, err
+		}
+
+		if flags.githubTokenStdin {
+			answers.GitHubToken, lines = lines[0], lines[1:]
+		}
+
+		if flags.gitlabTokenStdin {
+			if len(lines) == 0 {
+				return nil, errors.New("expected a GitLab token on the line after the GitHub token on stdin")
+			}
+			answers.GitLabToken = lines[0]
 		}
 	}
 
-	err := os.WriteFile(s.Config.ConfigFile(), []byte(content), 0644)
+	err := answers.validate()
 	if err != nil {
-		utils.HandleError(err, s.Debug, nil)
-		return
+		return nil, err
 	}
 
-	fmt.Printf("\nDone setting up %s!\n\n", s.Config.ConfigFile())
+	return answers, nil
 }
 
-func (s *Setup) generateConfigFileContents() string {
-	var contents string
+func readAnswers(path string) (*Answers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
 
-	github := commandline.AskYesNoQuestion("Do you wish to set up GitHub credentials?")
+	answers := &Answers{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
 
-	if github {
-		contents = contents + "github_username: " + commandline.AskOpenEndedQuestion("GitHub username", "", false) + "\n"
-		contents = contents + "github_token: " + commandline.AskOpenEndedQuestion("GitHub personal access token - navigate to https://github.com/settings/tokens to create a new personal access token", "", true) + "\n"
+	err = decoder.Decode(answers)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("could not parse %s: %s", path, err)
 	}
 
-	gitlab := commandline.AskYesNoQuestion("Do you wish to set up GitLab credentials?")
+	return answers, nil
+}
 
-	if gitlab {
-		contents = contents + "gitlab_username: " + commandline.AskOpenEndedQuestion("GitLab username", "", false) + "\n"
-		contents = contents + "gitlab_token: " + commandline.AskOpenEndedQuestion("GitLab personal access token - navigate to https://gitlab.com/-/profile/personal_access_tokens to create a new personal access token", "", true) + "\n"
+func readTokens(stdin io.Reader) ([]string, error) {
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, err
 	}
 
-	contents = strings.TrimSpace(contents) + "\n"
+	lines := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
 
-	return contents
+	if len(lines) == 0 {
+		return nil, errors.New("expected a token on stdin")
+	}
+
+	return lines, nil
 }
 
-func (s *Setup) setupPlugins() {
-	setup := commandline.AskYesNoQuestion("Do you wish to set up the Git Helper plugins?")
+func (a *Answers) validate() error {
+	completion := []string{}
 
-	if setup {
-		s.createOrUpdatePlugins(fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/plugins", s.Owner, s.Repository))
+	for _, sh := range a.Completion {
+		if sh == "all" {
+			completion = shells
+			break
+		}
+
+		if !slices.Contains(shells, sh) {
+			return fmt.Errorf("unknown completion shell %q, expected one of: %s or all", sh, strings.Join(shells, ", "))
+		}
+
+		completion = append(completion, sh)
 	}
+
+	a.Completion = completion
+	return nil
 }
 
-func (s *Setup) createOrUpdatePlugins(pluginsURL string) {
-	pluginsDir := s.Config.ConfigDir() + "/plugins"
+func (a *Answers) configValues() map[string]string {
+	values := map[string]string{}
+
+	for key, value := range map[string]string{
+		"github_username":      a.GitHubUsername,
+		"github_token":         a.GitHubToken,
+		"github_token_command": a.GitHubTokenCommand,
+		"gitlab_username":      a.GitLabUsername,
+		"gitlab_token":         a.GitLabToken,
+		"gitlab_token_command": a.GitLabTokenCommand,
+	} {
+		if value != "" {
+			values[key] = value
+		}
+	}
+
+	return values
+}
 
-	if err := os.MkdirAll(pluginsDir, 0755); err != nil {
+func (s *Setup) execute() {
+	s.setupConfig()
+	s.setupPlugins()
+	s.setupCompletion()
+}
+
+func (s *Setup) setupConfig() {
+	s.warnInsecureTokens()
+	s.createOrUpdateConfig()
+}
+
+// createOrUpdateConfig merges the answers into the existing config file,
+// keeping every key that wasn't answered.
+func (s *Setup) createOrUpdateConfig() {
+	config, err := s.Config.LoadFile(s.Config.ConfigFile())
+	if err != nil {
 		utils.HandleError(err, s.Debug, nil)
 		return
 	}
 
-	resp, err := http.Get(pluginsURL)
+	values := s.configValues(config)
+	if len(values) == 0 {
+		return
+	}
+
+	for key, value := range values {
+		err = config.Set(key, value)
+		if err != nil {
+			utils.HandleError(err, s.Debug, nil)
+			return
+		}
+	}
+
+	err = s.Config.Save(config)
 	if err != nil {
 		utils.HandleError(err, s.Debug, nil)
 		return
 	}
-	defer resp.Body.Close()
 
-	var allPlugins []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&allPlugins); err != nil {
-		utils.HandleError(err, s.Debug, nil)
+	fmt.Printf("\nDone setting up %s!\n\n", s.Config.ConfigFile())
+}
+
+func (s *Setup) warnInsecureTokens() {
+	if !s.Config.ConfigFileExists() {
 		return
 	}
 
-	for _, plugin := range allPlugins {
-		pluginURL := plugin["download_url"].(string)
-		pluginName := plugin["name"].(string)
+	info, err := os.Stat(s.Config.ConfigFile())
+	if err != nil {
+		return
+	}
 
-		resp, err := http.Get(pluginURL)
-		if err != nil {
-			utils.HandleError(err, s.Debug, nil)
-			continue
-		}
-		defer resp.Body.Close()
+	config, err := s.Config.LoadFile(s.Config.ConfigFile())
+	if err != nil {
+		return
+	}
 
-		pluginPath := filepath.Join(pluginsDir, pluginName)
-		file, err := os.Create(pluginPath)
-		if err != nil {
-			utils.HandleError(err, s.Debug, nil)
-			continue
+	tokens := []string{}
+	for _, key := range []string{"github_token", "gitlab_token"} {
+		if _, ok := config.Get(key); ok {
+			tokens = append(tokens, key)
 		}
-		defer file.Close()
+	}
 
-		_, err = io.Copy(file, resp.Body)
-		if err != nil {
-			utils.HandleError(err, s.Debug, nil)
-			continue
+	if len(tokens) == 0 {
+		return
+	}
+
+	fmt.Printf("\nWarning: %s stores %s in plaintext", s.Config.ConfigFile(), strings.Join(tokens, " and "))
+	if info.Mode().Perm()&0077 != 0 {
+		fmt.Printf(", and is readable by other users (permissions %#o). Run `chmod 600 %s` to fix that", info.Mode().Perm(), s.Config.ConfigFile())
+	}
+	fmt.Printf(".\nConsider moving tokens into the GITHUB_TOKEN or GITLAB_TOKEN environment variables, a git credential helper, or a github_token_command or gitlab_token_command (e.g. `pass show github`) instead.\n\n")
+}
+
+func (s *Setup) configValues(existing *configfile.Config) map[string]string {
+	if s.Answers != nil {
+		values := s.Answers.configValues()
+		if token, ok := values["github_token"]; ok {
+			s.checkToken(credentials.GitHub, "github.com", token)
+		}
+		if token, ok := values["gitlab_token"]; ok {
+			s.checkToken(credentials.GitLab, "gitlab.com", token)
 		}
 
-		err = os.Chmod(pluginPath, 0755)
-		if err != nil {
-			fmt.Println("Error:", err)
+		return values
+	}
+
+	values := map[string]string{}
+
+	if commandline.AskYesNoQuestion("Do you wish to set up GitHub credentials?") {
+		values["github_username"] = commandline.AskOpenEndedQuestion("GitHub username", existing.GitHubUsername, false)
+		token := commandline.AskOpenEndedQuestion("GitHub personal access token - navigate to https://github.com/settings/tokens to create a new personal access token", "", true)
+		s.checkToken(credentials.GitHub, "github.com", token)
+		values["github_token"] = token
+	}
+
+	if commandline.AskYesNoQuestion("Do you wish to set up GitLab credentials?") {
+		values["gitlab_username"] = commandline.AskOpenEndedQuestion("GitLab username", existing.GitLabUsername, false)
+		token := commandline.AskOpenEndedQuestion("GitLab personal access token - navigate to https://gitlab.com/-/profile/personal_access_tokens to create a new personal access token", "", true)
+		s.checkToken(credentials.GitLab, "gitlab.com", token)
+		values["gitlab_token"] = token
+	}
+
+	return values
+}
+
+func (s *Setup) checkToken(forge, host, token string) {
+	status, err := auth.CheckToken(s.Debug, forge, host, token)
+	if err != nil {
+		fmt.Printf("Warning: %s. The token will be saved anyway, check it with `git-helper auth status` later.\n", err)
+		return
+	}
+
+	fmt.Print(status)
+}
+
+func (s *Setup) setupPlugins() {
+	var setup bool
+	if s.Answers != nil {
+		setup = s.Answers.Plugins
+	} else {
+		setup = commandline.AskYesNoQuestion("Do you wish to set up the Git Helper plugins?")
+	}
+
+	if setup {
+		s.createOrUpdatePlugins()
+	}
+}
+
+// createOrUpdatePlugins links git-<command> to the binary for every command,
+// so git runs them without anything added to the PATH. If the binary's
+// directory can't be written to, it generates shim scripts instead.
+func (s *Setup) createOrUpdatePlugins() {
+	p := plugins.NewPlugins(s.Debug, "", s.Executor)
+	commands := p.Commands(s.Root)
+
+	target, err := plugins.Executable()
+	if err == nil {
+		p.Dir = filepath.Dir(target)
+		removed, err := p.Link(commands, target)
+		if err == nil {
+			printRemoved(removed)
+			fmt.Printf("\nDone linking plugins in %s!\n\n", p.Dir)
 			return
 		}
+
+		fmt.Printf("Could not link plugins in %s (%s), so generating scripts instead\n", p.Dir, err)
+	}
+
+	p.Dir = filepath.Join(s.Config.ConfigDir(), "plugins")
+	removed, err := p.Install(commands)
+	if err != nil {
+		utils.HandleError(err, s.Debug, nil)
+		return
 	}
 
-	fmt.Printf("\nDone setting up plugins at %s!\n", pluginsDir)
+	printRemoved(removed)
+	fmt.Printf("\nDone setting up plugins at %s!\n", p.Dir)
 	fmt.Printf("\nNow add this line to your Unix shell file (e.g. ~/.zshrc):\n  export PATH=\"$HOME/.git-helper/plugins:$PATH\"\n\n")
 }
 
+func printRemoved(removed []string) {
+	for _, name := range removed {
+		fmt.Printf("Removed %s, which is no longer a Git Helper command\n", name)
+	}
+}
+
 func (s *Setup) setupCompletion() {
+	if s.Answers != nil {
+		if len(s.Answers.Completion) > 0 {
+			s.createOrUpdateCompletion(s.Answers.Completion)
+		}
+		return
+	}
+
 	setup := commandline.AskYesNoQuestion("Do you wish to set up Git Helper completion?")
 
 	if setup {
-		s.createOrUpdateCompletion()
+		s.createOrUpdateCompletion(shells)
 	}
 }
 
-func (s *Setup) createOrUpdateCompletion() {
-	shes := []string{"bash", "fish", "powershell", "zsh"}
+func (s *Setup) createOrUpdateCompletion(shes []string) {
 	completionsDir := s.Config.ConfigDir() + "/completions"
 	if err := os.MkdirAll(completionsDir, 0755); err != nil {
 		utils.HandleError(err, s.Debug, nil)
@@ -221,5 +453,5 @@ func (s *Setup) createOrUpdateCompletion() {
 		}
 	}
 
-	fmt.Println("\nCompletions (for bash, fish, powershell, and zsh) generated in " + completionsDir + ". Please activate the proper completion for your Unix shell. E.g. add the following to your ~/.zshrc file:\n  [ -f ~/.git-helper/completions/completion.zsh ] && source ~/.git-helper/completions/completion.zsh\n")
+	fmt.Println("\nCompletions (for " + strings.Join(shes, ", ") + ") generated in " + completionsDir + ". Please activate the proper completion for your Unix shell. E.g. add the following to your ~/.zshrc file:\n  [ -f ~/.git-helper/completions/completion.zsh ] && source ~/.git-helper/completions/completion.zsh\n")
 }
@@ -1,13 +1,18 @@
 package setup
 
 import (
-	"encoding/json"
-	"net/http"
-	"net/http/httptest"
+	"errors"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/emmahsax/go-git-helper/internal/auth"
 	"github.com/emmahsax/go-git-helper/internal/commandline"
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/plugins"
+	"github.com/spf13/cobra"
 )
 
 type MockExecutor struct {
@@ -23,9 +28,21 @@ func (me *MockExecutor) Exec(execType string, command string, args ...string) ([
 	return me.Output, nil
 }
 
+func stubCheckToken(t *testing.T) {
+	originalCheckToken := auth.CheckToken
+	t.Cleanup(func() {
+		auth.CheckToken = originalCheckToken
+	})
+	auth.CheckToken = func(debug bool, forge, host, token string) (*auth.Status, error) {
+		return &auth.Status{Forge: forge, Host: host, Username: "hello_world"}, nil
+	}
+}
+
 type MockConfig struct {
 	Contents map[string]string
 	Debug    bool
+	Existing *configfile.Config
+	Saved    *configfile.Config
 }
 
 func (mc *MockConfig) ConfigDir() string {
@@ -60,173 +77,240 @@ func (mc *MockConfig) GitLabToken() string {
 	return "random-gitlab-token"
 }
 
+func (mc *MockConfig) Layers() ([]configfile.Layer, error) {
+	return []configfile.Layer{}, nil
+}
+
+func (mc *MockConfig) Load() (*configfile.Config, error) {
+	return &configfile.Config{}, nil
+}
+
+func (mc *MockConfig) LoadFile(path string) (*configfile.Config, error) {
+	if mc.Existing != nil {
+		return mc.Existing, nil
+	}
+
+	return &configfile.Config{}, nil
+}
+
+func (mc *MockConfig) Origins() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (mc *MockConfig) RepoConfigFile() string {
+	return ""
+}
+
+func (mc *MockConfig) Save(config *configfile.Config) error {
+	mc.Saved = config
+	return nil
+}
+
+func (mc *MockConfig) SaveFile(path string, config *configfile.Config) error {
+	return nil
+}
+
 func (mc *MockConfig) SpecialCapitalization() map[string]string {
 	return map[string]string{}
 }
 
 func Test_createOrUpdateConfig(t *testing.T) {
+	stubCheckToken(t)
+
 	tests := []struct {
 		name     string
-		replace  bool
-		expected string
+		answer   bool
+		answers  *Answers
+		expected *configfile.Config
 	}{
 		{
-			name:    "file exists, replacing",
-			replace: true,
-			expected: `github_username: hello_world
-github_token: hello_world
-gitlab_username: hello_world
-gitlab_token: hello_world
-`,
+			name:   "setting up both forges",
+			answer: true,
+			expected: &configfile.Config{
+				GitHubUsername:        "hello_world",
+				GitHubToken:           "hello_world",
+				GitLabUsername:        "hello_world",
+				GitLabToken:           "hello_world",
+				SpecialCapitalization: map[string]string{"api": "API"},
+			},
+		},
+		{
+			name:     "setting up neither forge",
+			answer:   false,
+			expected: nil,
 		},
 		{
-			name:     "file exists, not replacing",
-			replace:  false,
-			expected: "\n",
+			name:    "unattended",
+			answers: &Answers{GitLabUsername: "gitlab_user", GitLabTokenCommand: "pass show gitlab"},
+			expected: &configfile.Config{
+				GitHubUsername:        "test_user",
+				GitHubToken:           "test_token",
+				GitLabUsername:        "gitlab_user",
+				GitLabTokenCommand:    "pass show gitlab",
+				SpecialCapitalization: map[string]string{"api": "API"},
+			},
 		},
 	}
 
 	for _, test := range tests {
-		originalAskYesNoQuestion := commandline.AskYesNoQuestion
-		t.Cleanup(func() {
-			commandline.AskYesNoQuestion = originalAskYesNoQuestion
-		})
-		commandline.AskYesNoQuestion = func(question string) bool {
-			return test.replace
-		}
-
-		originalAskOpenEndedQuestion := commandline.AskOpenEndedQuestion
-		t.Cleanup(func() {
-			commandline.AskOpenEndedQuestion = originalAskOpenEndedQuestion
-		})
-		commandline.AskOpenEndedQuestion = func(question, defaultVal string, secret bool) string {
-			return "hello_world"
-		}
-
-		executor := &MockExecutor{Debug: true}
-		configFile := &MockConfig{
-			Debug: true,
-			Contents: map[string]string{
-				"github_username": "test_user",
-				"github_token":    "test_token",
-			},
-		}
-		s := newSetup("owner", "repo", true, executor, configFile)
-
-		_, err := os.Stat(configFile.ConfigFile())
-		if err != nil {
-			err := os.MkdirAll(configFile.ConfigDir(), 0755)
-			if err != nil {
-				t.Fatal(err)
+		t.Run(test.name, func(t *testing.T) {
+			originalAskYesNoQuestion := commandline.AskYesNoQuestion
+			t.Cleanup(func() {
+				commandline.AskYesNoQuestion = originalAskYesNoQuestion
+			})
+			commandline.AskYesNoQuestion = func(question string) bool {
+				if test.answers != nil {
+					t.Errorf("unexpected question: %s", question)
+				}
+				return test.answer
 			}
-			tempDir, err := os.MkdirTemp(configFile.ConfigDir(), "")
-			if err != nil {
-				t.Fatal(err)
+
+			originalAskOpenEndedQuestion := commandline.AskOpenEndedQuestion
+			t.Cleanup(func() {
+				commandline.AskOpenEndedQuestion = originalAskOpenEndedQuestion
+			})
+			commandline.AskOpenEndedQuestion = func(question, defaultVal string, secret bool) string {
+				return "hello_world"
 			}
-			tempFile, err := os.Create(configFile.ConfigFile())
-			if err != nil {
-				t.Fatal(err)
+
+			executor := &MockExecutor{Debug: true}
+			configFile := &MockConfig{
+				Debug: true,
+				Existing: &configfile.Config{
+					GitHubUsername:        "test_user",
+					GitHubToken:           "test_token",
+					SpecialCapitalization: map[string]string{"api": "API"},
+				},
 			}
-			defer os.RemoveAll(configFile.ConfigDir())
-			defer os.RemoveAll(tempDir)
-			defer os.Remove(tempFile.Name())
-		}
+			s := newSetup(true, executor, configFile, nil)
+			s.Answers = test.answers
 
-		s.createOrUpdateConfig()
-		res, _ := os.ReadFile(configFile.ConfigFile())
+			s.createOrUpdateConfig()
 
-		if string(res) != test.expected {
-			t.Errorf("expected output to be '%s', but got '%s'", test.expected, res)
-		}
+			if !reflect.DeepEqual(configFile.Saved, test.expected) {
+				t.Errorf("expected %+v to be saved, but got %+v", test.expected, configFile.Saved)
+			}
+		})
 	}
 }
 
-func Test_generateConfigFileContents(t *testing.T) {
-	originalAskYesNoQuestion := commandline.AskYesNoQuestion
-	t.Cleanup(func() {
-		commandline.AskYesNoQuestion = originalAskYesNoQuestion
-	})
-	commandline.AskYesNoQuestion = func(question string) bool {
-		return true
-	}
+func Test_answersFromFlags(t *testing.T) {
+	answersFile := filepath.Join(t.TempDir(), "answers.yml")
+	os.WriteFile(answersFile, []byte("github_username: fileuser\ngitlab_username: filegitlab\nplugins: true\ncompletion: [bash]\n"), 0600)
 
-	originalAskOpenEndedQuestion := commandline.AskOpenEndedQuestion
-	t.Cleanup(func() {
-		commandline.AskOpenEndedQuestion = originalAskOpenEndedQuestion
-	})
-	commandline.AskOpenEndedQuestion = func(question, defaultVal string, secret bool) string {
-		return "hello_world"
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		expected *Answers
+		err      bool
+	}{
+		{name: "no flags", args: []string{}, expected: nil},
+		{
+			name:     "flags override the answers file",
+			args:     []string{"--from-file", answersFile, "--github-username", "flaguser", "--plugins=false", "--completion=zsh"},
+			expected: &Answers{GitHubUsername: "flaguser", GitLabUsername: "filegitlab", Completion: []string{"zsh"}},
+		},
+		{
+			name:     "tokens from stdin",
+			args:     []string{"--github-token-stdin", "--gitlab-token-stdin", "--completion=all"},
+			stdin:    "ghp_token\nglpat_token\n",
+			expected: &Answers{GitHubToken: "ghp_token", GitLabToken: "glpat_token", Completion: shells},
+		},
+		{name: "missing token", args: []string{"--github-token-stdin", "--gitlab-token-stdin"}, stdin: "ghp_token\n", err: true},
+		{name: "unknown shell", args: []string{"--completion=tcsh"}, err: true},
 	}
 
-	executor := &MockExecutor{Debug: true}
-	configFile := &MockConfig{
-		Debug: true,
-		Contents: map[string]string{
-			"github_username": "test_user",
-			"github_token":    "test_token",
-		},
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var flags flagValues
+			cmd := NewCommand()
+			cmd.Flags().Parse(test.args)
+			flags.completion, _ = cmd.Flags().GetStringSlice("completion")
+			flags.fromFile, _ = cmd.Flags().GetString("from-file")
+			flags.githubTokenStdin, _ = cmd.Flags().GetBool("github-token-stdin")
+			flags.githubUsername, _ = cmd.Flags().GetString("github-username")
+			flags.gitlabTokenStdin, _ = cmd.Flags().GetBool("gitlab-token-stdin")
+			flags.gitlabUsername, _ = cmd.Flags().GetString("gitlab-username")
+			flags.plugins, _ = cmd.Flags().GetBool("plugins")
+
+			answers, err := answersFromFlags(cmd, flags, strings.NewReader(test.stdin))
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !test.err && !reflect.DeepEqual(answers, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, answers)
+			}
+		})
 	}
-	s := newSetup("owner", "repo", true, executor, configFile)
-	contents := s.generateConfigFileContents()
+}
+
+func Test_readAnswers_UnknownKey(t *testing.T) {
+	answersFile := filepath.Join(t.TempDir(), "answers.yml")
+	os.WriteFile(answersFile, []byte("github_user: typo\n"), 0600)
 
-	expectedContents := "github_username: hello_world\ngithub_token: hello_world\ngitlab_username: hello_world\ngitlab_token: hello_world\n"
-	if contents != expectedContents {
-		t.Errorf("Expected '%s', got '%s'", expectedContents, contents)
+	if _, err := readAnswers(answersFile); err == nil {
+		t.Error("expected an error for an unknown key")
 	}
 }
 
-func Test_CceateOrUpdatePlugins(t *testing.T) {
-	executor := &MockExecutor{Debug: true}
-	configFile := &MockConfig{
-		Debug: true,
-		Contents: map[string]string{
-			"github_username": "test_user",
-			"github_token":    "test_token",
-		},
+func stubExecutable(t *testing.T, path string, err error) {
+	originalExecutable := plugins.Executable
+	t.Cleanup(func() {
+		plugins.Executable = originalExecutable
+	})
+	plugins.Executable = func() (string, error) {
+		return path, err
 	}
-	s := newSetup("owner", "repo", true, executor, configFile)
+}
 
-	serverPlugin1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("plugin1 content"))
-	}))
-	defer serverPlugin1.Close()
+func Test_createOrUpdatePlugins(t *testing.T) {
+	root := &cobra.Command{Use: "git-helper"}
+	root.AddCommand(&cobra.Command{Use: "branch"}, &cobra.Command{Use: "new-branch"})
 
-	serverPlugin2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("plugin2 content"))
-	}))
-	defer serverPlugin2.Close()
+	t.Run("links next to the binary", func(t *testing.T) {
+		binDir := t.TempDir()
+		target := filepath.Join(binDir, "git-helper")
+		stubExecutable(t, target, nil)
 
-	response := []map[string]string{
-		{
-			"name":         "plugin1",
-			"download_url": serverPlugin1.URL,
-		},
-		{
-			"name":         "plugin2",
-			"download_url": serverPlugin2.URL,
-		},
-	}
+		executor := &MockExecutor{Debug: true, Output: []byte("branch\ncommit\n")}
+		s := newSetup(true, executor, &MockConfig{Debug: true}, root)
+		s.createOrUpdatePlugins()
 
-	jsonData, _ := json.Marshal(response)
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte(jsonData))
-	}))
-	defer server.Close()
-	defer os.RemoveAll(configFile.ConfigDir())
+		if dest, err := os.Readlink(filepath.Join(binDir, "git-new-branch")); err != nil || dest != target {
+			t.Errorf("expected git-new-branch to link to %s, got %s (%v)", target, dest, err)
+		}
+
+		if _, err := os.Lstat(filepath.Join(binDir, "git-branch")); !os.IsNotExist(err) {
+			t.Error("expected git-branch not to exist")
+		}
+	})
 
-	s.createOrUpdatePlugins(server.URL)
+	t.Run("falls back to scripts", func(t *testing.T) {
+		stubExecutable(t, "", errors.New("no executable"))
 
-	resPlugin1, _ := os.ReadFile(configFile.ConfigDir() + "/plugins/plugin1")
+		executor := &MockExecutor{Debug: true, Output: []byte("branch\ncommit\n")}
+		configFile := &MockConfig{Debug: true}
+		s := newSetup(true, executor, configFile, root)
+		defer os.RemoveAll(configFile.ConfigDir())
 
-	if string(resPlugin1) != "plugin1 content" {
-		t.Errorf("expected output to be '%s', but got '%s'", "plugin1 content", resPlugin1)
-	}
+		os.MkdirAll(configFile.ConfigDir()+"/plugins", 0755)
+		os.WriteFile(configFile.ConfigDir()+"/plugins/git-removed", []byte("#!/bin/sh\n\ngit-helper removed $@"), 0755)
 
-	resPlugin2, _ := os.ReadFile(configFile.ConfigDir() + "/plugins/plugin2")
+		s.createOrUpdatePlugins()
 
-	if string(resPlugin2) != "plugin2 content" {
-		t.Errorf("expected output to be '%s', but got '%s'", "plugin2 content", resPlugin2)
-	}
+		content, _ := os.ReadFile(configFile.ConfigDir() + "/plugins/git-new-branch")
+		if !strings.Contains(string(content), "exec git-helper new-branch \"$@\"") {
+			t.Errorf("expected a new-branch shim, but got '%s'", content)
+		}
+
+		for _, name := range []string{"git-branch", "git-removed"} {
+			if _, err := os.Stat(configFile.ConfigDir() + "/plugins/" + name); !os.IsNotExist(err) {
+				t.Errorf("expected %s not to exist", name)
+			}
+		}
+	})
 }
 
 func Test_createOrUpdateCompletion(t *testing.T) {
@@ -238,13 +322,12 @@ func Test_createOrUpdateCompletion(t *testing.T) {
 			"github_token":    "test_token",
 		},
 	}
-	s := newSetup("owner", "repo", true, executor, configFile)
+	s := newSetup(true, executor, configFile, nil)
 	defer os.RemoveAll(configFile.ConfigDir())
 
-	s.createOrUpdateCompletion()
+	s.createOrUpdateCompletion(shells)
 
-	shes := []string{"bash", "fish", "powershell", "zsh"}
-	for _, sh := range shes {
+	for _, sh := range shells {
 		_, err := os.Stat(configFile.ConfigDir() + "/completions/completion." + sh)
 		if err != nil {
 			if os.IsNotExist(err) {
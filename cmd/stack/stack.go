@@ -0,0 +1,268 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/stack"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var errConflicts = errors.New("restack stopped on conflicts")
+
+type Stack struct {
+	Debug    bool
+	Executor executor.ExecutorInterface
+	Push     bool
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug bool
+		push  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "stack",
+		Short:                 "Works with branches stacked on top of each other",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+	}
+
+	cmd.PersistentFlags().BoolVar(&debug, "debug", false, "enables debug mode")
+
+	restackCmd := &cobra.Command{
+		Use:                   "restack",
+		Short:                 "Rebases every branch in the current stack onto its parent",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newStack(debug, executor.NewExecutor(debug), push).execute((*Stack).restack)
+			return nil
+		},
+	}
+
+	restackCmd.Flags().BoolVar(&push, "push", false, "push every restacked branch with --force-with-lease")
+
+	showCmd := &cobra.Command{
+		Use:                   "show",
+		Short:                 "Shows the branches in the current stack",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newStack(debug, executor.NewExecutor(debug), false).execute((*Stack).show)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(restackCmd, showCmd)
+
+	return cmd
+}
+
+func newStack(debug bool, executor executor.ExecutorInterface, push bool) *Stack {
+	return &Stack{
+		Debug:    debug,
+		Executor: executor,
+		Push:     push,
+	}
+}
+
+func (s *Stack) execute(run func(*Stack) error) {
+	err := run(s)
+	if err != nil {
+		utils.HandleError(err, s.Debug, nil)
+		return
+	}
+}
+
+// restack rebases each branch in the current stack onto its parent, parents
+// first. Children of a branch whose code request merged are first moved onto
+// that branch's own parent.
+func (s *Stack) restack() error {
+	g := git.NewGit(s.Debug, s.Executor)
+	if operation := g.InProgress(); operation != "" {
+		return fmt.Errorf("finish the %s in progress before restacking", operation)
+	}
+
+	st := stack.NewStack(s.Debug, s.Executor)
+	current := g.CurrentBranch()
+	members := s.members(st, current)
+	if len(members) == 1 && st.Parent(current) == "" {
+		return fmt.Errorf("%s isn't part of a stack, start one with git-helper new-branch --stacked", current)
+	}
+
+	g.Fetch()
+	s.retargetMerged(g, st, members)
+
+	restacked := []string{}
+	for _, branch := range members {
+		parent := st.Parent(branch)
+		if parent == "" {
+			continue
+		}
+
+		onto := s.onto(st, parent)
+		if st.Contains(branch, onto) {
+			if commit, err := st.Commit(onto); err == nil {
+				st.SetBase(branch, commit)
+			}
+			fmt.Printf("%s is already up to date with %s\n", branch, onto)
+			restacked = append(restacked, branch)
+			continue
+		}
+
+		fmt.Printf("Rebasing %s onto %s\n", branch, onto)
+		err := st.Rebase(branch, onto)
+		if err != nil {
+			return s.stopped(g, err)
+		}
+		restacked = append(restacked, branch)
+	}
+
+	if s.Push {
+		for _, branch := range restacked {
+			err := g.ForcePushBranch(branch)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	g.Checkout(current)
+	return nil
+}
+
+// retargetMerged moves the children of every branch whose code request has
+// merged onto that branch's parent, both locally and on the forge.
+func (s *Stack) retargetMerged(g *git.Git, st *stack.Stack, members []string) {
+	remote, err := g.Remote("origin")
+	if err != nil {
+		fmt.Println("Skipping the check for merged branches:", err)
+		return
+	}
+
+	client, err := forge.NewClient(s.Debug, remote)
+	if err != nil {
+		fmt.Println("Skipping the check for merged branches:", err)
+		return
+	}
+
+	for _, branch := range members {
+		parent := st.Parent(branch)
+		children := st.Children(branch)
+		if parent == "" || len(children) == 0 {
+			continue
+		}
+
+		cr, err := client.FindCodeRequest(branch)
+		if err != nil {
+			fmt.Printf("Could not look up the code request for %s: %v\n", branch, err)
+			continue
+		}
+
+		if cr == nil || cr.State != forge.StateMerged {
+			continue
+		}
+
+		for _, child := range children {
+			err = st.SetParent(child, parent)
+			if err != nil {
+				fmt.Printf("Could not move %s onto %s: %v\n", child, parent, err)
+				continue
+			}
+			fmt.Printf("%s was merged, so %s is now stacked on %s\n", branch, child, parent)
+
+			childCR, err := client.FindCodeRequest(child)
+			if err != nil || childCR == nil || childCR.State != forge.StateOpen || childCR.Base == parent {
+				continue
+			}
+
+			err = client.Retarget(childCR.Number, parent)
+			if err != nil {
+				fmt.Printf("Could not retarget %s to %s: %v\n", childCR.URL, parent, err)
+				continue
+			}
+			fmt.Printf("Retargeted %s to %s\n", childCR.URL, parent)
+		}
+
+		st.Remove(branch)
+	}
+}
+
+func (s *Stack) show() error {
+	g := git.NewGit(s.Debug, s.Executor)
+	st := stack.NewStack(s.Debug, s.Executor)
+	current := g.CurrentBranch()
+	members := s.members(st, current)
+	if len(members) == 1 && st.Parent(current) == "" {
+		return fmt.Errorf("%s isn't part of a stack, start one with git-helper new-branch --stacked", current)
+	}
+
+	root := members[0]
+	if parent := st.Parent(root); parent != "" {
+		root = parent
+	}
+
+	s.printTree(st, root, current, 0)
+	return nil
+}
+
+func (s *Stack) printTree(st *stack.Stack, branch, current string, depth int) {
+	marker := ""
+	if branch == current {
+		marker = " *"
+	}
+
+	fmt.Printf("%s%s%s\n", strings.Repeat("  ", depth), branch, marker)
+	for _, child := range st.Children(branch) {
+		s.printTree(st, child, current, depth+1)
+	}
+}
+
+// members returns the bottom of branch's stack followed by everything stacked
+// on it, parents first.
+func (s *Stack) members(st *stack.Stack, branch string) []string {
+	bottom := branch
+	if lineage := st.Lineage(branch); len(lineage) > 0 {
+		bottom = lineage[0]
+	}
+
+	return append([]string{bottom}, st.Descendants(bottom)...)
+}
+
+// onto returns what a branch stacked on parent is rebased onto. Branches at
+// the bottom of a stack go onto their parent's remote branch, since the local
+// one is often behind.
+func (s *Stack) onto(st *stack.Stack, parent string) string {
+	if st.Parent(parent) != "" {
+		return parent
+	}
+
+	if _, err := st.Commit("origin/" + parent); err == nil {
+		return "origin/" + parent
+	}
+
+	return parent
+}
+
+func (s *Stack) stopped(g *git.Git, err error) error {
+	files := g.ConflictedFiles()
+	if len(files) == 0 {
+		return err
+	}
+
+	fmt.Printf("\nThere are conflicts in:\n  %s\n\n", strings.Join(files, "\n  "))
+	fmt.Println("Resolve them and stage the files with git add, then run:")
+	fmt.Println("  git rebase --continue")
+	fmt.Println("  git-helper stack restack")
+	fmt.Println("Or undo this branch's rebase with:")
+	fmt.Println("  git rebase --abort")
+
+	return errConflicts
+}
@@ -0,0 +1,195 @@
+package stack
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+)
+
+// MockExecutor keeps git config in Config, returns the output for each other
+// command line in Outputs, and an error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Config  map[string]string
+	Debug   bool
+	Outputs map[string]string
+	Run     []string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+
+	if len(args) > 1 && args[0] == "config" {
+		return me.config(args[1:])
+	}
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+func (me *MockExecutor) config(args []string) ([]byte, error) {
+	switch args[0] {
+	case "--get-regexp":
+		lines := []string{}
+		for key, value := range me.Config {
+			if strings.HasSuffix(key, ".git-helper-parent") {
+				lines = append(lines, key+" "+value)
+			}
+		}
+		sort.Strings(lines)
+		return []byte(strings.Join(lines, "\n")), nil
+	case "--get":
+		value, ok := me.Config[args[1]]
+		if !ok {
+			return nil, errors.New("exit status 1")
+		}
+		return []byte(value + "\n"), nil
+	case "--unset":
+		delete(me.Config, args[1])
+		return nil, nil
+	default:
+		me.Config[args[0]] = args[1]
+		return nil, nil
+	}
+}
+
+// fakeClient embeds forge.Client so it only has to implement what's used.
+type fakeClient struct {
+	forge.Client
+	requests   map[string]*forge.CodeRequest
+	retargeted map[int]string
+}
+
+func (c *fakeClient) FindCodeRequest(branch string) (*forge.CodeRequest, error) {
+	return c.requests[branch], nil
+}
+
+func (c *fakeClient) Retarget(number int, base string) error {
+	c.retargeted[number] = base
+	return nil
+}
+
+func newTestStack(t *testing.T, current string, outputs map[string]string, client *fakeClient) (*Stack, *MockExecutor) {
+	originalNewClient := forge.NewClient
+	t.Cleanup(func() {
+		forge.NewClient = originalNewClient
+	})
+	forge.NewClient = func(debug bool, remote *git.Remote) (forge.Client, error) {
+		return client, nil
+	}
+
+	base := map[string]string{
+		"git branch":                                       "  main\n  feature-a\n  feature-b\n",
+		"git fetch -p":                                     "",
+		"git remote get-url --push origin":                 "git@github.com:octocat/hello.git\n",
+		"git checkout " + current:                          "",
+		"git rev-parse --verify -q origin/main":            "m2\n",
+		"git rev-parse --verify -q feature-a":              "a2\n",
+		"git merge-base --is-ancestor feature-a feature-b": "",
+	}
+	base["git branch"] = strings.Replace(base["git branch"], "  "+current+"\n", "* "+current+"\n", 1)
+	for line, output := range outputs {
+		base[line] = output
+	}
+
+	executor := &MockExecutor{
+		Config: map[string]string{
+			"branch.feature-a.git-helper-parent": "main",
+			"branch.feature-a.git-helper-base":   "m1",
+			"branch.feature-b.git-helper-parent": "feature-a",
+			"branch.feature-b.git-helper-base":   "a1",
+		},
+		Debug:   true,
+		Outputs: base,
+	}
+	return newStack(true, executor, false), executor
+}
+
+func ran(executor *MockExecutor, line string) bool {
+	for _, run := range executor.Run {
+		if run == line {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_restack(t *testing.T) {
+	client := &fakeClient{requests: map[string]*forge.CodeRequest{}, retargeted: map[int]string{}}
+	s, executor := newTestStack(t, "feature-b", map[string]string{
+		"git rebase --onto origin/main m1 feature-a": "",
+	}, client)
+
+	if err := s.restack(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ran(executor, "git rebase --onto origin/main m1 feature-a") || ran(executor, "git rebase --onto feature-a a1 feature-b") {
+		t.Errorf("expected only feature-a to be rebased, got %v", executor.Run)
+	}
+
+	if executor.Config["branch.feature-a.git-helper-base"] != "m2" || executor.Config["branch.feature-b.git-helper-base"] != "a2" {
+		t.Errorf("expected the new bases to be recorded, got %v", executor.Config)
+	}
+
+	if executor.Run[len(executor.Run)-1] != "git checkout feature-b" {
+		t.Errorf("expected to end up back on feature-b, got %v", executor.Run)
+	}
+}
+
+func Test_restack_MergedParent(t *testing.T) {
+	client := &fakeClient{
+		requests: map[string]*forge.CodeRequest{
+			"feature-a": {Base: "main", Number: 1, State: forge.StateMerged},
+			"feature-b": {Base: "feature-a", Number: 2, State: forge.StateOpen},
+		},
+		retargeted: map[int]string{},
+	}
+	s, executor := newTestStack(t, "feature-b", map[string]string{
+		"git rebase --onto origin/main a1 feature-b": "",
+	}, client)
+
+	if err := s.restack(); err != nil {
+		t.Fatal(err)
+	}
+
+	if executor.Config["branch.feature-b.git-helper-parent"] != "main" || executor.Config["branch.feature-a.git-helper-parent"] != "" {
+		t.Errorf("expected feature-b to move onto main, got %v", executor.Config)
+	}
+
+	if client.retargeted[2] != "main" {
+		t.Errorf("expected #2 to be retargeted to main, got %v", client.retargeted)
+	}
+
+	if !ran(executor, "git rebase --onto origin/main a1 feature-b") {
+		t.Errorf("expected only feature-b's own commits to be rebased onto origin/main, got %v", executor.Run)
+	}
+}
+
+func Test_restack_Conflicts(t *testing.T) {
+	client := &fakeClient{requests: map[string]*forge.CodeRequest{}, retargeted: map[int]string{}}
+	s, _ := newTestStack(t, "feature-b", map[string]string{
+		"git diff --name-only --diff-filter=U": "main.go\n",
+	}, client)
+
+	if err := s.restack(); err != errConflicts {
+		t.Errorf("expected the restack to stop on conflicts, got %v", err)
+	}
+
+	s, _ = newTestStack(t, "main", map[string]string{}, client)
+	s.Executor.(*MockExecutor).Config = map[string]string{}
+	if err := s.restack(); err == nil || !strings.Contains(err.Error(), "isn't part of a stack") {
+		t.Errorf("expected an unstacked branch to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type Status struct {
+	Debug    bool
+	Executor executor.ExecutorInterface
+	JSON     bool
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		debug      bool
+		jsonOutput bool
+		mine       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "status",
+		Short:                 "Shows the current branch's code request, or all of yours with --mine",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newStatus(jsonOutput, debug, executor.NewExecutor(debug)).execute(mine)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "prints the status as JSON")
+	cmd.Flags().BoolVar(&mine, "mine", false, "lists your open code requests and review requests on every forge you're logged in to")
+
+	return cmd
+}
+
+func newStatus(jsonOutput, debug bool, executor executor.ExecutorInterface) *Status {
+	return &Status{
+		Debug:    debug,
+		Executor: executor,
+		JSON:     jsonOutput,
+	}
+}
+
+func (s *Status) execute(mine bool) {
+	var err error
+	if mine {
+		err = s.mine(os.Stdout)
+	} else {
+		err = s.status(os.Stdout)
+	}
+
+	if err != nil {
+		utils.HandleError(err, s.Debug, nil)
+		return
+	}
+}
+
+func (s *Status) status(w io.Writer) error {
+	g := git.NewGit(s.Debug, s.Executor)
+	branch := g.CurrentBranch()
+
+	remote, err := g.Remote("origin")
+	if err != nil {
+		return err
+	}
+
+	client, err := forge.NewClient(s.Debug, remote)
+	if err != nil {
+		return err
+	}
+
+	cr, err := client.FindCodeRequest(branch)
+	if err != nil {
+		return err
+	}
+
+	if cr == nil {
+		return fmt.Errorf("%s doesn't have a code request, run git-helper code-request to create one", branch)
+	}
+
+	status, err := client.Status(cr)
+	if err != nil {
+		return err
+	}
+
+	if s.JSON {
+		return printJSON(w, status)
+	}
+
+	printStatus(w, status)
+	return nil
+}
+
+func (s *Status) mine(w io.Writer) error {
+	summaries, errs := forge.Mine(s.Debug)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if len(summaries) == 0 && len(errs) > 0 {
+		return errors.New("could not list your code requests on any forge")
+	}
+
+	if s.JSON {
+		return printJSON(w, summaries)
+	}
+
+	printSummaries(w, summaries)
+	return nil
+}
+
+func printJSON(w io.Writer, value any) error {
+	output, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, string(output))
+	return nil
+}
+
+func printStatus(w io.Writer, status *forge.Status) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	state := status.State
+	if status.Draft {
+		state += ", draft"
+	}
+
+	fmt.Fprintf(tw, "Title:\t%s\n", status.Title)
+	fmt.Fprintf(tw, "URL:\t%s\n", status.URL)
+	fmt.Fprintf(tw, "State:\t%s\n", state)
+	fmt.Fprintf(tw, "Review:\t%s\n", utils.ValueOr(strings.ReplaceAll(status.Review, "_", " "), "none"))
+	fmt.Fprintf(tw, "Threads:\t%d unresolved\n", status.UnresolvedThreads)
+	fmt.Fprintf(tw, "Checks:\t%s\n", checkCounts(status.Checks))
+
+	if status.State == forge.StateOpen {
+		mergeable := "yes"
+		if !status.Mergeable {
+			mergeable = "no, " + strings.Join(status.MergeProblems, "; ")
+		}
+		fmt.Fprintf(tw, "Mergeable:\t%s\n", mergeable)
+	}
+	tw.Flush()
+
+	if len(status.Checks) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATE\tURL")
+	for _, check := range status.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", check.Name, check.State, check.URL)
+	}
+	tw.Flush()
+}
+
+func printSummaries(w io.Writer, summaries []forge.Summary) {
+	if len(summaries) == 0 {
+		fmt.Fprintln(w, "You don't have any open code requests or review requests")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROLE\tREPO\tNUMBER\tTITLE\tURL")
+	for _, summary := range summaries {
+		title := summary.Title
+		if summary.Draft {
+			title = "[draft] " + title
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", summary.Role, summary.Host+"/"+summary.Repo, summary.Number, title, summary.URL)
+	}
+	tw.Flush()
+}
+
+func checkCounts(checks []forge.Check) string {
+	if len(checks) == 0 {
+		return "none"
+	}
+
+	counts := map[string]int{}
+	for _, check := range checks {
+		counts[check.State]++
+	}
+
+	return fmt.Sprintf("%d passed, %d failed, %d pending", counts[forge.CheckSuccess], counts[forge.CheckFailure], counts[forge.CheckPending])
+}
@@ -0,0 +1,151 @@
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Outputs map[string]string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+type fakeClient struct {
+	forge.Client
+	requests map[string]*forge.CodeRequest
+}
+
+func (c *fakeClient) FindCodeRequest(branch string) (*forge.CodeRequest, error) {
+	return c.requests[branch], nil
+}
+
+func (c *fakeClient) Status(cr *forge.CodeRequest) (*forge.Status, error) {
+	return &forge.Status{
+		Checks:            []forge.Check{{Name: "test", State: forge.CheckFailure, URL: "https://ci.example.com/1"}, {Name: "lint", State: forge.CheckSuccess}},
+		MergeProblems:     []string{"checks failed: test"},
+		Number:            cr.Number,
+		Review:            forge.ReviewChangesRequested,
+		State:             cr.State,
+		Title:             cr.Title,
+		UnresolvedThreads: 2,
+		URL:               cr.URL,
+	}, nil
+}
+
+func newTestStatus(t *testing.T, jsonOutput bool, cr *forge.CodeRequest) *Status {
+	client := &fakeClient{requests: map[string]*forge.CodeRequest{"feature": cr}}
+	originalNewClient := forge.NewClient
+	t.Cleanup(func() {
+		forge.NewClient = originalNewClient
+	})
+	forge.NewClient = func(debug bool, remote *git.Remote) (forge.Client, error) {
+		return client, nil
+	}
+
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git branch":                       "  main\n* feature\n",
+		"git remote get-url --push origin": "git@github.com:octocat/hello.git\n",
+	}}
+
+	return newStatus(jsonOutput, true, executor)
+}
+
+func Test_status(t *testing.T) {
+	cr := &forge.CodeRequest{Number: 2, State: forge.StateOpen, Title: "Add things", URL: "https://github.com/octocat/hello/pull/2"}
+	s := newTestStatus(t, false, cr)
+
+	var out bytes.Buffer
+	if err := s.status(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"Review:     changes requested",
+		"Threads:    2 unresolved",
+		"Checks:     1 passed, 1 failed, 0 pending",
+		"Mergeable:  no, checks failed: test",
+		"test   failure  https://ci.example.com/1",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected the output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func Test_status_JSON(t *testing.T) {
+	cr := &forge.CodeRequest{Number: 2, State: forge.StateOpen, Title: "Add things"}
+	s := newTestStatus(t, true, cr)
+
+	var out bytes.Buffer
+	if err := s.status(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	var status forge.Status
+	if err := json.Unmarshal(out.Bytes(), &status); err != nil || status.Number != 2 || len(status.Checks) != 2 {
+		t.Errorf("expected the status as JSON, got %s (%v)", out.String(), err)
+	}
+}
+
+func Test_status_NoCodeRequest(t *testing.T) {
+	s := newTestStatus(t, false, nil)
+
+	err := s.status(&bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "doesn't have a code request") {
+		t.Errorf("expected an error about the missing code request, got %v", err)
+	}
+}
+
+func Test_mine(t *testing.T) {
+	originalMine := forge.Mine
+	t.Cleanup(func() {
+		forge.Mine = originalMine
+	})
+
+	forge.Mine = func(debug bool) ([]forge.Summary, []error) {
+		return []forge.Summary{
+			{Draft: true, Host: "github.com", Number: 2, Repo: "octocat/hello", Role: forge.RoleAuthor, Title: "Add things", URL: "https://github.com/octocat/hello/pull/2"},
+			{Host: "gitlab.com", Number: 4, Repo: "group/project", Role: forge.RoleReviewer, Title: "Fix things", URL: "https://gitlab.com/group/project/-/merge_requests/4"},
+		}, []error{errors.New("could not list code requests on code.example.com")}
+	}
+
+	var out bytes.Buffer
+	if err := newStatus(false, true, &MockExecutor{}).mine(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ROLE") || !strings.Contains(lines[1], "[draft] Add things") || !strings.Contains(lines[2], "gitlab.com/group/project") {
+		t.Errorf("unexpected table:\n%s", out.String())
+	}
+
+	forge.Mine = func(debug bool) ([]forge.Summary, []error) {
+		return nil, []error{errors.New("no token")}
+	}
+
+	if err := newStatus(false, true, &MockExecutor{}).mine(&bytes.Buffer{}); err == nil {
+		t.Error("expected an error when no forge could be asked")
+	}
+}
@@ -0,0 +1,256 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// stashMessage labels the stash sync makes. --continue and --abort find it
+// again by its commit, which is recorded while the sync is stopped.
+const stashMessage = "git-helper sync"
+
+var errConflicts = errors.New("sync stopped on conflicts")
+
+type Sync struct {
+	ConfigFile configfile.ConfigFileInterface
+	Debug      bool
+	Executor   executor.ExecutorInterface
+	Push       bool
+	Strategy   string
+}
+
+func NewCommand() *cobra.Command {
+	var (
+		abort         bool
+		continueSync  bool
+		debug         bool
+		merge, rebase bool
+		push          bool
+	)
+
+	cmd := &cobra.Command{
+		Use:                   "sync",
+		Short:                 "Rebases or merges the current branch onto the default branch",
+		Args:                  cobra.ExactArgs(0),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			strategy := ""
+			if merge {
+				strategy = git.OperationMerge
+			} else if rebase {
+				strategy = git.OperationRebase
+			}
+
+			s := newSync(debug, executor.NewExecutor(debug), configfile.NewConfigFile(debug), strategy, push)
+			s.execute(continueSync, abort)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&abort, "abort", false, "abort a sync that stopped on conflicts")
+	cmd.Flags().BoolVar(&continueSync, "continue", false, "continue a sync once its conflicts are resolved")
+	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().BoolVar(&merge, "merge", false, "merge the default branch into the current branch")
+	cmd.Flags().BoolVar(&push, "push", false, "push the synced branch with --force-with-lease")
+	cmd.Flags().BoolVar(&rebase, "rebase", false, "rebase the current branch onto the default branch")
+	cmd.MarkFlagsMutuallyExclusive("merge", "rebase", "continue", "abort")
+	cmd.MarkFlagsMutuallyExclusive("push", "abort")
+
+	return cmd
+}
+
+func newSync(debug bool, executor executor.ExecutorInterface, configFile configfile.ConfigFileInterface, strategy string, push bool) *Sync {
+	return &Sync{
+		ConfigFile: configFile,
+		Debug:      debug,
+		Executor:   executor,
+		Push:       push,
+		Strategy:   strategy,
+	}
+}
+
+func (s *Sync) execute(continueSync, abort bool) {
+	var err error
+	switch {
+	case continueSync:
+		err = s.resume()
+	case abort:
+		err = s.abort()
+	default:
+		err = s.sync()
+	}
+
+	if err != nil {
+		utils.HandleError(err, s.Debug, nil)
+		return
+	}
+}
+
+func (s *Sync) sync() error {
+	g := git.NewGit(s.Debug, s.Executor)
+	if operation := g.InProgress(); operation != "" {
+		return fmt.Errorf("a %s is already in progress, finish it with git-helper sync --continue or undo it with git-helper sync --abort", operation)
+	}
+
+	branch := g.CurrentBranch()
+	defaultBranch := g.DefaultBranch()
+	if s.Push && branch == defaultBranch {
+		return fmt.Errorf("refusing to force push the default branch %s", defaultBranch)
+	}
+
+	strategy := s.strategy()
+	g.Fetch()
+
+	g.ClearSyncStash()
+	sha, err := g.StashPush(stashMessage)
+	if err != nil {
+		return err
+	}
+
+	if sha != "" {
+		err = g.SetSyncStash(sha)
+		if err != nil {
+			return fmt.Errorf("could not record the stash, restore it with git stash pop: %w", err)
+		}
+	}
+
+	fmt.Printf("Syncing %s with origin/%s using %s\n", branch, defaultBranch, strategy)
+	if strategy == git.OperationMerge {
+		err = g.Merge("origin/" + defaultBranch)
+	} else {
+		err = g.Rebase("origin/" + defaultBranch)
+	}
+
+	if err != nil {
+		return s.stopped(g, err)
+	}
+
+	return s.finish(g)
+}
+
+// resume continues a sync that stopped on conflicts. When the merge or rebase
+// was already finished with git directly, only the stash and push are left.
+func (s *Sync) resume() error {
+	g := git.NewGit(s.Debug, s.Executor)
+	operation := g.InProgress()
+	if operation != "" {
+		if files := g.ConflictedFiles(); len(files) > 0 {
+			return s.stopped(g, errConflicts)
+		}
+
+		err := g.Continue(operation)
+		if err != nil {
+			return s.stopped(g, err)
+		}
+	}
+
+	return s.finish(g)
+}
+
+func (s *Sync) abort() error {
+	g := git.NewGit(s.Debug, s.Executor)
+	operation := g.InProgress()
+	if operation == "" {
+		return errors.New("there's no merge or rebase in progress to abort")
+	}
+
+	err := g.Abort(operation)
+	if err != nil {
+		return err
+	}
+
+	ref, err := s.restore(g)
+	if err != nil {
+		return fmt.Errorf("aborted the %s, but restoring the stashed changes failed, resolve the conflicts and run git stash drop %s: %w", operation, ref, err)
+	}
+
+	fmt.Printf("Aborted the %s\n", operation)
+	return nil
+}
+
+// finish restores the stashed changes and pushes the branch if asked to.
+func (s *Sync) finish(g *git.Git) error {
+	ref, err := s.restore(g)
+	if err != nil {
+		return fmt.Errorf("synced, but restoring the stashed changes failed, resolve the conflicts and run git stash drop %s: %w", ref, err)
+	}
+
+	if ref != "" {
+		fmt.Println("Restored the stashed changes")
+	}
+
+	if s.Push {
+		err = g.ForcePushBranch(g.CurrentBranch())
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("Synced")
+	return nil
+}
+
+// stopped explains how to carry on when the merge or rebase didn't finish. If
+// it stopped for some reason other than conflicts, the stash is restored.
+func (s *Sync) stopped(g *git.Git, err error) error {
+	files := g.ConflictedFiles()
+	if len(files) == 0 {
+		if g.InProgress() == "" {
+			s.restore(g)
+		}
+		return err
+	}
+
+	continueCommand := "git-helper sync --continue"
+	if s.Push {
+		continueCommand += " --push"
+	}
+
+	fmt.Printf("\nThere are conflicts in:\n  %s\n\n", strings.Join(files, "\n  "))
+	fmt.Println("Resolve them and stage the files with git add, then run:")
+	fmt.Printf("  %s\n", continueCommand)
+	fmt.Println("Or put everything back the way it was with:")
+	fmt.Println("  git-helper sync --abort")
+
+	if g.SyncStash() != "" {
+		fmt.Println("\nYour uncommitted changes are stashed, and come back once the sync finishes or is aborted.")
+	}
+
+	return errConflicts
+}
+
+// restore pops the stash this sync made, if it made one and it's still there,
+// and forgets it. It returns the stash's ref, so a stash that couldn't be
+// applied cleanly can be dropped by hand.
+func (s *Sync) restore(g *git.Git) (string, error) {
+	sha := g.SyncStash()
+	if sha == "" {
+		return "", nil
+	}
+
+	ref, err := g.StashPop(sha)
+	g.ClearSyncStash()
+	return ref, err
+}
+
+// strategy returns the strategy from the flags, then the sync_strategy config
+// key, and rebases by default.
+func (s *Sync) strategy() string {
+	if s.Strategy != "" {
+		return s.Strategy
+	}
+
+	config, err := s.ConfigFile.Load()
+	if err == nil && config.SyncStrategy != "" {
+		return config.SyncStrategy
+	}
+
+	return git.OperationRebase
+}
@@ -0,0 +1,202 @@
+package sync
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Outputs map[string]string
+	Run     []string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+func newTestSync(t *testing.T, config, strategy string, push bool, outputs map[string]string) (*Sync, *MockExecutor) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if config != "" {
+		os.MkdirAll(filepath.Join(home, ".git-helper"), 0700)
+		os.WriteFile(filepath.Join(home, ".git-helper", "config.yml"), []byte(config), 0600)
+	}
+
+	base := map[string]string{
+		"git branch": "* feature\n  main\n",
+		"git symbolic-ref refs/remotes/origin/HEAD": "refs/remotes/origin/main\n",
+		"git fetch -p": "",
+		"git status --porcelain --untracked-files=no": "",
+		"git stash list --format=%gd %H":              "",
+	}
+	for line, output := range outputs {
+		base[line] = output
+	}
+
+	executor := &MockExecutor{Debug: true, Outputs: base}
+	cf := configfile.NewConfigFile(true)
+	cf.Executor = executor
+	return newSync(true, executor, cf, strategy, push), executor
+}
+
+func ran(executor *MockExecutor, line string) bool {
+	for _, run := range executor.Run {
+		if run == line {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_sync(t *testing.T) {
+	tests := []struct {
+		config   string
+		strategy string
+		expected string
+	}{
+		{expected: "git rebase origin/main"},
+		{config: "sync_strategy: merge\n", expected: "git merge --no-edit origin/main"},
+		{config: "sync_strategy: merge\n", strategy: "rebase", expected: "git rebase origin/main"},
+	}
+
+	for _, test := range tests {
+		s, executor := newTestSync(t, test.config, test.strategy, true, map[string]string{
+			"git rebase origin/main":                                    "",
+			"git merge --no-edit origin/main":                           "",
+			"git push --force-with-lease --set-upstream origin feature": "",
+		})
+
+		if err := s.sync(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !ran(executor, test.expected) || !ran(executor, "git push --force-with-lease --set-upstream origin feature") {
+			t.Errorf("expected %q and a push, got %v", test.expected, executor.Run)
+		}
+	}
+}
+
+func Test_sync_Autostash(t *testing.T) {
+	s, executor := newTestSync(t, "", "", false, map[string]string{
+		"git status --porcelain --untracked-files=no": " M main.go\n",
+		"git stash push -m git-helper sync":           "",
+		"git rev-parse -q --verify refs/stash":        "def456\n",
+		"git config git-helper.sync-stash def456":     "",
+		"git rebase origin/main":                      "",
+		"git config --get git-helper.sync-stash":      "def456\n",
+		"git stash list --format=%gd %H":              "stash@{0} def456\n",
+		"git stash pop stash@{0}":                     "",
+	})
+
+	if err := s.sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"git stash push -m git-helper sync", "git config git-helper.sync-stash def456", "git stash pop stash@{0}", "git config --unset git-helper.sync-stash"} {
+		if !ran(executor, line) {
+			t.Errorf("expected %q to be run while stashing around the rebase, got %v", line, executor.Run)
+		}
+	}
+}
+
+func Test_sync_Conflicts(t *testing.T) {
+	s, executor := newTestSync(t, "", "", true, map[string]string{
+		"git diff --name-only --diff-filter=U": "main.go\n",
+	})
+
+	if err := s.sync(); err != errConflicts {
+		t.Errorf("expected the sync to stop on conflicts, got %v", err)
+	}
+
+	if ran(executor, "git push --force-with-lease --set-upstream origin feature") {
+		t.Errorf("expected nothing to be pushed, got %v", executor.Run)
+	}
+
+	s, _ = newTestSync(t, "", "", true, map[string]string{
+		"git branch": "* main\n",
+	})
+	if err := s.sync(); err == nil || !strings.Contains(err.Error(), "refusing to force push") {
+		t.Errorf("expected pushing the default branch to be refused, got %v", err)
+	}
+
+	s, _ = newTestSync(t, "", "", false, map[string]string{
+		"git rev-parse -q --verify REBASE_HEAD": "abc123\n",
+	})
+	if err := s.sync(); err == nil || !strings.Contains(err.Error(), "already in progress") {
+		t.Errorf("expected a rebase in progress to be refused, got %v", err)
+	}
+}
+
+func Test_resume(t *testing.T) {
+	s, executor := newTestSync(t, "", "", false, map[string]string{
+		"git rev-parse -q --verify REBASE_HEAD": "abc123\n",
+		"git diff --name-only --diff-filter=U":  "main.go\n",
+	})
+	if err := s.resume(); err != errConflicts || ran(executor, "git -c core.editor=true rebase --continue") {
+		t.Errorf("expected unresolved conflicts to stop the sync, got %v after %v", err, executor.Run)
+	}
+
+	s, executor = newTestSync(t, "", "", true, map[string]string{
+		"git rev-parse -q --verify MERGE_HEAD":                      "abc123\n",
+		"git diff --name-only --diff-filter=U":                      "",
+		"git -c core.editor=true merge --continue":                  "",
+		"git push --force-with-lease --set-upstream origin feature": "",
+	})
+	if err := s.resume(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ran(executor, "git -c core.editor=true merge --continue") || !ran(executor, "git push --force-with-lease --set-upstream origin feature") {
+		t.Errorf("expected the merge to continue and be pushed, got %v", executor.Run)
+	}
+}
+
+func Test_abort(t *testing.T) {
+	s, _ := newTestSync(t, "", "", false, map[string]string{})
+	if err := s.abort(); err == nil {
+		t.Errorf("expected an error with nothing to abort")
+	}
+
+	s, executor := newTestSync(t, "", "", false, map[string]string{
+		"git rev-parse -q --verify REBASE_HEAD":  "abc123\n",
+		"git rebase --abort":                     "",
+		"git config --get git-helper.sync-stash": "def456\n",
+		"git stash list --format=%gd %H":         "stash@{0} abc123\nstash@{1} def456\n",
+		"git stash pop stash@{1}":                "",
+	})
+	if err := s.abort(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ran(executor, "git rebase --abort") || !ran(executor, "git stash pop stash@{1}") || ran(executor, "git stash pop stash@{0}") {
+		t.Errorf("expected the rebase to be aborted and only the sync's stash restored, got %v", executor.Run)
+	}
+
+	s, executor = newTestSync(t, "", "", false, map[string]string{
+		"git rev-parse -q --verify REBASE_HEAD": "abc123\n",
+		"git rebase --abort":                    "",
+		"git stash list --format=%gd %H":        "stash@{0} abc123\n",
+	})
+	if err := s.abort(); err != nil || ran(executor, "git stash pop stash@{0}") {
+		t.Errorf("expected an older stash to be left alone when the sync didn't stash, got %v after %v", err, executor.Run)
+	}
+}
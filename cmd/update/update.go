@@ -1,170 +1,297 @@
 package update
 
 import (
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"io/fs"
 	"os"
-	"os/user"
+	"path/filepath"
 	"runtime"
 	"strings"
 
+	"github.com/emmahsax/go-git-helper/internal/configfile"
 	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/plugins"
+	"github.com/emmahsax/go-git-helper/internal/release"
 	"github.com/emmahsax/go-git-helper/internal/utils"
 	"github.com/spf13/cobra"
-	"github.com/tidwall/gjson"
 )
 
 type Update struct {
+	Check      bool
+	Client     *release.Client
+	ConfigFile configfile.ConfigFileInterface
 	Debug      bool
 	Executor   executor.ExecutorInterface
-	Owner      string
-	Repository string
+	Prerelease bool
+	Tag        string
+	Version    string
 }
 
-var (
-	asset   = "git-helper_" + runtime.GOOS + "_" + runtime.GOARCH
-	newPath = "/usr/local/bin/git-helper" // This is for linux and mac based systems only
-)
-
-func NewCommand(packageOwner, packageRepository string) *cobra.Command {
+func NewCommand(packageOwner, packageRepository, packageVersion string) *cobra.Command {
 	var (
-		debug bool
+		check      bool
+		debug      bool
+		prerelease bool
+		tag        string
 	)
 
 	cmd := &cobra.Command{
-		Use:                   "update",
+		Use:                   "update [--check] [--version tag] [--prerelease]",
 		Short:                 "Updates Git Helper with the newest version on GitHub",
 		Args:                  cobra.ExactArgs(0),
 		DisableFlagsInUseLine: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			newUpdate(packageOwner, packageRepository, debug, executor.NewExecutor(debug)).execute()
+			u := newUpdate(debug, executor.NewExecutor(debug), configfile.NewConfigFile(debug), release.NewClient(packageOwner, packageRepository), packageVersion)
+			u.Check = check
+			u.Prerelease = prerelease
+			u.Tag = tag
+			u.execute()
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&check, "check", false, "only check whether a newer version is available")
 	cmd.Flags().BoolVar(&debug, "debug", false, "enables debug mode")
+	cmd.Flags().BoolVar(&prerelease, "prerelease", false, "consider prereleases when looking for the newest version")
+	cmd.Flags().StringVar(&tag, "version", "", "install this release tag instead of the newest one")
+	cmd.MarkFlagsMutuallyExclusive("version", "prerelease")
 
 	return cmd
 }
 
-func newUpdate(owner, repository string, debug bool, executor executor.ExecutorInterface) *Update {
+func newUpdate(debug bool, executor executor.ExecutorInterface, configFile configfile.ConfigFileInterface, client *release.Client, version string) *Update {
 	return &Update{
+		Client:     client,
+		ConfigFile: configFile,
 		Debug:      debug,
 		Executor:   executor,
-		Owner:      owner,
-		Repository: repository,
+		Version:    version,
 	}
 }
 
 func (u *Update) execute() {
-	u.downloadGitHelper()
-	u.moveGitHelper()
-	u.setPermissions()
-	u.outputNewVersion()
-}
+	rel, err := u.findRelease()
+	if err != nil {
+		utils.HandleError(err, u.Debug, nil)
+		return
+	}
 
-func (u *Update) downloadGitHelper() {
-	fmt.Println("Installing latest git-helper version")
+	current := "v" + strings.TrimPrefix(u.Version, "v")
+	if u.Check {
+		if release.Compare(rel.TagName, current) > 0 {
+			fmt.Printf("git-helper %s is available (you have %s), run git-helper update to install it\n", rel.TagName, current)
+		} else {
+			fmt.Printf("git-helper %s is up to date\n", current)
+		}
+		return
+	}
 
-	releaseURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", u.Owner, u.Repository)
-	downloadURL := u.getDownloadURL(u.fetchReleaseBody(releaseURL))
-	binaryName := strings.Split(downloadURL, "/")[len(strings.Split(downloadURL, "/"))-1]
-	u.downloadAndSaveBinary(downloadURL, binaryName)
-}
+	if u.Tag == "" && release.Compare(rel.TagName, current) <= 0 {
+		fmt.Printf("git-helper %s is already up to date\n", current)
+		return
+	}
+
+	fmt.Printf("Installing git-helper %s\n", rel.TagName)
 
-func (u *Update) fetchReleaseBody(releaseURL string) []byte {
-	resp, err := http.Get(releaseURL)
+	binary, err := u.download(rel)
 	if err != nil {
 		utils.HandleError(err, u.Debug, nil)
-		return []byte{}
+		return
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	target, err := plugins.Executable()
 	if err != nil {
 		utils.HandleError(err, u.Debug, nil)
-		return []byte{}
+		return
 	}
 
-	return body
+	err = u.replace(target, binary)
+	if err != nil {
+		utils.HandleError(err, u.Debug, nil)
+		return
+	}
+
+	u.regeneratePlugins(filepath.Join(u.ConfigFile.ConfigDir(), "plugins"), target)
+	u.outputNewVersion(target)
 }
 
-func (u *Update) getDownloadURL(body []byte) string {
-	var downloadURL string
-	switch runtime.GOOS {
-	case "darwin":
-		downloadURL = gjson.Get(string(body), "assets.#(name==\""+asset+"\").browser_download_url").String()
-	case "linux":
-		downloadURL = gjson.Get(string(body), "assets.#(name==\""+asset+"\").browser_download_url").String()
-	default:
-		fmt.Println("Unsupported operating system:", runtime.GOOS)
+func (u *Update) findRelease() (*release.Release, error) {
+	if u.Tag != "" {
+		return u.Client.Tag(u.Tag)
 	}
 
-	return downloadURL
+	return u.Client.Latest(u.Prerelease)
 }
 
-func (u *Update) downloadAndSaveBinary(downloadURL, binaryName string) {
-	resp, err := http.Get(downloadURL)
+// download returns this platform's binary from the release, once it matches
+// the published checksum. Older releases without a checksums file are never
+// installed, and when update_public_key is set, the checksums file must also
+// carry a valid signature.
+func (u *Update) download(rel *release.Release) ([]byte, error) {
+	asset := rel.Asset(release.AssetName())
+	if asset == nil {
+		return nil, fmt.Errorf("release %s has no build for %s/%s", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+
+	checksumsAsset := rel.Asset(release.ChecksumsAsset)
+	if checksumsAsset == nil {
+		return nil, fmt.Errorf("release %s has no %s, like every release from before update verified downloads, so its build can't be verified and won't be installed, download %s by hand if you trust it", rel.TagName, release.ChecksumsAsset, asset.DownloadURL)
+	}
+
+	checksums, err := u.Client.Download(checksumsAsset)
 	if err != nil {
-		utils.HandleError(err, u.Debug, nil)
-		return
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	out, err := os.Create(binaryName)
+	err = u.verifySignature(rel, checksums)
 	if err != nil {
-		utils.HandleError(err, u.Debug, nil)
-		return
+		return nil, err
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
+	binary, err := u.Client.Download(asset)
 	if err != nil {
-		utils.HandleError(err, u.Debug, nil)
-		return
+		return nil, err
 	}
+
+	return binary, release.VerifyChecksum(binary, checksums, asset.Name)
 }
 
-func (u *Update) moveGitHelper() {
-	output, err := u.Executor.Exec("actionAndOutput", "sudo", "mv", "./"+asset, newPath)
+func (u *Update) verifySignature(rel *release.Release, checksums []byte) error {
+	config, err := u.ConfigFile.Load()
 	if err != nil {
-		utils.HandleError(err, u.Debug, nil)
-		return
+		return err
 	}
 
-	fmt.Printf("%s", string(output))
+	if config.UpdatePublicKey == "" {
+		return nil
+	}
+
+	signatureAsset := rel.Asset(release.SignatureAsset)
+	if signatureAsset == nil {
+		return fmt.Errorf("release %s has no %s, but update_public_key is set", rel.TagName, release.SignatureAsset)
+	}
+
+	signature, err := u.Client.Download(signatureAsset)
+	if err != nil {
+		return err
+	}
+
+	return release.VerifySignature(checksums, signature, config.UpdatePublicKey)
 }
 
-func (u *Update) setPermissions() {
-	currentUser, err := user.Current()
+// replace swaps target for binary with a rename in the same directory, so
+// target is never left half-written. If the directory isn't writable, it
+// falls back to sudo.
+func (u *Update) replace(target string, binary []byte) error {
+	tmp, err := writeTemp(filepath.Dir(target), binary)
+	if errors.Is(err, fs.ErrPermission) && runtime.GOOS != "windows" {
+		return u.replaceWithSudo(target, binary)
+	}
 	if err != nil {
-		utils.HandleError(err, u.Debug, nil)
-		return
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		// Windows can't replace a running executable, but it can rename one
+		os.Remove(target + ".old")
+		err = os.Rename(target, target+".old")
+		if err != nil {
+			os.Remove(tmp)
+			return err
+		}
 	}
 
-	output, err := u.Executor.Exec("actionAndOutput", "sudo", "chown", currentUser.Username+":staff", newPath)
+	err = os.Rename(tmp, target)
 	if err != nil {
-		utils.HandleError(err, u.Debug, nil)
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// RemoveOldBinary removes the binary an update on Windows renamed out of the
+// way, which couldn't be removed while it was still running. It's called when
+// git-helper starts.
+func RemoveOldBinary() {
+	if runtime.GOOS != "windows" {
 		return
 	}
 
-	fmt.Printf("%s", string(output))
+	target, err := plugins.Executable()
+	if err == nil {
+		removeOldBinary(target)
+	}
+}
 
-	output, err = u.Executor.Exec("actionAndOutput", "sudo", "chmod", "+x", newPath)
+func removeOldBinary(target string) {
+	err := os.Remove(target + ".old")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "Could not remove %s.old from the last update: %s\n", target, err)
+	}
+}
+
+func (u *Update) replaceWithSudo(target string, binary []byte) error {
+	fmt.Printf("%s isn't writable, so using sudo to replace it\n", filepath.Dir(target))
+
+	tmp, err := writeTemp("", binary)
 	if err != nil {
-		utils.HandleError(err, u.Debug, nil)
-		return
+		return err
 	}
 
-	fmt.Printf("%s", string(output))
+	output, err := u.Executor.Exec("actionAndOutput", "sudo", "mv", tmp, target)
+	if err != nil {
+		os.Remove(tmp)
+		return errors.New(strings.TrimSpace(string(output) + " " + err.Error()))
+	}
+
+	return nil
 }
 
-func (u *Update) outputNewVersion() {
-	output, err := u.Executor.Exec("actionAndOutput", "git-helper", "version")
+func writeTemp(dir string, binary []byte) (string, error) {
+	file, err := os.CreateTemp(dir, ".git-helper-update-*")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.Write(binary)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0755)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+func (u *Update) outputNewVersion(binary string) {
+	output, err := u.Executor.Exec("actionAndOutput", binary, "version")
 	if err != nil {
 		utils.HandleError(err, u.Debug, nil)
 		return
 	}
 	fmt.Printf("Installed %s", string(output))
 }
+
+// regeneratePlugins has the new binary rewrite the plugin links or shims, so
+// commands added in this version get one and removed commands lose theirs.
+func (u *Update) regeneratePlugins(pluginsDir, binary string) {
+	links := plugins.NewPlugins(u.Debug, filepath.Dir(binary), u.Executor).Links(binary)
+	if _, err := os.Stat(pluginsDir); err != nil && len(links) == 0 {
+		return
+	}
+
+	output, err := u.Executor.Exec("actionAndOutput", binary, "setup", "--plugins")
+	if err != nil {
+		utils.HandleError(err, u.Debug, nil)
+		return
+	}
+
+	fmt.Printf("%s", string(output))
+}
@@ -1,10 +1,19 @@
 package update
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/release"
 )
 
 type MockExecutor struct {
@@ -20,153 +29,244 @@ func (me *MockExecutor) Exec(execType string, command string, args ...string) ([
 	return me.Output, nil
 }
 
-func Test_fetchReleaseBody(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("test response"))
+// newTestUpdate serves a release with this platform's binary, its checksums
+// and files, and uses a config file in a temporary home directory.
+func newTestUpdate(t *testing.T, config string, files map[string]string) (*Update, *MockExecutor) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	os.MkdirAll(filepath.Join(tempDir, ".git-helper"), 0700)
+	os.WriteFile(filepath.Join(tempDir, ".git-helper", "config.yml"), []byte(config), 0600)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/releases/latest", "/repos/owner/repo/releases/tags/v0.3.0":
+			assets := []string{}
+			for name := range files {
+				assets = append(assets, `{"name": "`+name+`", "browser_download_url": "`+server.URL+`/download/`+name+`"}`)
+			}
+			w.Write([]byte(`{"tag_name": "v0.3.0", "assets": [` + strings.Join(assets, ",") + `]}`))
+		default:
+			content, ok := files[strings.TrimPrefix(r.URL.Path, "/download/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(content))
+		}
 	}))
-	defer server.Close()
+	t.Cleanup(server.Close)
+
+	client := release.NewClient("owner", "repo")
+	client.APIURL = server.URL
 
 	executor := &MockExecutor{Debug: true}
-	u := newUpdate("owner", "repo", true, executor)
-	body := u.fetchReleaseBody(server.URL)
+	cf := configfile.NewConfigFile(true)
+	cf.Executor = executor
+	return newUpdate(true, executor, cf, client, "0.2.1"), executor
+}
 
-	if string(body) != "test response" {
-		t.Errorf("expected 'test response', got '%s'", body)
-	}
+func checksums(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:]) + "  " + release.AssetName() + "\n"
 }
 
-func Test_getDownloadURL(t *testing.T) {
-	body := []byte(`{
-		"assets": [
-			{
-				"name": "` + asset + `",
-				"browser_download_url": "https://example.com/download"
-			}
-		]
-	}`)
+func Test_findRelease(t *testing.T) {
+	u, _ := newTestUpdate(t, "", map[string]string{})
 
-	executor := &MockExecutor{Debug: true}
-	u := newUpdate("owner", "repo", true, executor)
-	downloadURL := u.getDownloadURL(body)
+	rel, err := u.findRelease()
+	if err != nil || rel.TagName != "v0.3.0" {
+		t.Errorf("expected the latest release, got %+v (%v)", rel, err)
+	}
 
-	if downloadURL != "https://example.com/download" {
-		t.Errorf("expected 'https://example.com/download', got '%s'", downloadURL)
+	u.Tag = "0.3.0"
+	rel, err = u.findRelease()
+	if err != nil || rel.TagName != "v0.3.0" {
+		t.Errorf("expected the tagged release, got %+v (%v)", rel, err)
+	}
+
+	u.Tag = "v9.9.9"
+	if _, err = u.findRelease(); err == nil {
+		t.Error("expected an error for a missing tag")
 	}
 }
 
-func Test_downloadAndSaveBinary(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("test response"))
-	}))
-	defer server.Close()
+func Test_download(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(nil)
+	keyConfig := "update_public_key: " + base64.StdEncoding.EncodeToString(publicKey) + "\n"
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(checksums("binary"))))
 
-	executor := &MockExecutor{Debug: true}
-	u := newUpdate("owner", "repo", true, executor)
+	tests := []struct {
+		name   string
+		config string
+		files  map[string]string
+		err    bool
+	}{
+		{
+			name:  "matching checksum",
+			files: map[string]string{release.AssetName(): "binary", release.ChecksumsAsset: checksums("binary")},
+		},
+		{
+			name:  "checksum mismatch",
+			files: map[string]string{release.AssetName(): "tampered", release.ChecksumsAsset: checksums("binary")},
+			err:   true,
+		},
+		{
+			name:  "no checksums",
+			files: map[string]string{release.AssetName(): "binary"},
+			err:   true,
+		},
+		{
+			name:  "no build for this platform",
+			files: map[string]string{release.ChecksumsAsset: checksums("binary")},
+			err:   true,
+		},
+		{
+			name:   "valid signature",
+			config: keyConfig,
+			files:  map[string]string{release.AssetName(): "binary", release.ChecksumsAsset: checksums("binary"), release.SignatureAsset: signature},
+		},
+		{
+			name:   "missing signature",
+			config: keyConfig,
+			files:  map[string]string{release.AssetName(): "binary", release.ChecksumsAsset: checksums("binary")},
+			err:    true,
+		},
+		{
+			name:   "bad signature",
+			config: keyConfig,
+			files:  map[string]string{release.AssetName(): "binary", release.ChecksumsAsset: checksums("other"), release.SignatureAsset: signature},
+			err:    true,
+		},
+	}
 
-	binaryName := "test_binary"
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u, _ := newTestUpdate(t, test.config, test.files)
+			rel, err := u.findRelease()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			binary, err := u.download(rel)
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
 
-	u.downloadAndSaveBinary(server.URL, binaryName)
+			if !test.err && string(binary) != "binary" {
+				t.Errorf("expected binary, got %q", binary)
+			}
+		})
+	}
+}
 
-	content, err := os.ReadFile(binaryName)
+func Test_download_NoChecksums(t *testing.T) {
+	u, _ := newTestUpdate(t, "", map[string]string{release.AssetName(): "binary"})
+	rel, err := u.findRelease()
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if string(content) != "test response" {
-		t.Errorf("expected 'test response', got '%s'", content)
+	_, err = u.download(rel)
+	if err == nil || !strings.Contains(err.Error(), "has no checksums.txt") || !strings.Contains(err.Error(), rel.Asset(release.AssetName()).DownloadURL) {
+		t.Errorf("expected an error explaining how to install the release by hand, got %v", err)
 	}
-
-	os.Remove(binaryName)
 }
 
-func Test_moveGitHelper(t *testing.T) {
-	tests := []struct {
-		expectedArgs []string
-	}{
-		{expectedArgs: []string{"mv", "./" + asset, "/usr/local/bin/git-helper"}},
-	}
+func Test_replace(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "git-helper")
+	os.WriteFile(target, []byte("old"), 0755)
 
-	for _, test := range tests {
-		executor := &MockExecutor{Debug: true}
-		u := newUpdate("owner", "repo", true, executor)
-		u.moveGitHelper()
+	u := newUpdate(true, &MockExecutor{Debug: true}, nil, nil, "0.2.1")
+	err := u.replace(target, []byte("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
 
-		if executor.Command != "sudo" {
-			t.Errorf("unexpected command received: expected %s, but got %s", "git", executor.Command)
-		}
+	content, _ := os.ReadFile(target)
+	if string(content) != "new" {
+		t.Errorf("expected the new binary, got %q", content)
+	}
 
-		if len(executor.Args) != len(test.expectedArgs) {
-			t.Errorf("unexpected args received: expected %v, but got %v", test.expectedArgs, executor.Args)
-		}
+	info, _ := os.Stat(target)
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("expected the binary to be executable, got %#o", info.Mode().Perm())
+	}
 
-		for i, v := range executor.Args {
-			if v != test.expectedArgs[i] {
-				t.Errorf("unexpected args received: expected %v, but got %v", test.expectedArgs, executor.Args)
-			}
-		}
+	entries, _ := os.ReadDir(filepath.Dir(target))
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left behind, got %d entries", len(entries))
 	}
 }
 
-func Test_setPermissions(t *testing.T) {
-	tests := []struct {
-		expectedArgs []string
-	}{
-		{expectedArgs: []string{"chmod", "+x", "/usr/local/bin/git-helper"}},
+func Test_removeOldBinary(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "git-helper.exe")
+	os.WriteFile(target, []byte("new"), 0755)
+	os.WriteFile(target+".old", []byte("old"), 0755)
+
+	removeOldBinary(target)
+	if _, err := os.Stat(target + ".old"); !os.IsNotExist(err) {
+		t.Errorf("expected the old binary to be removed, got %v", err)
 	}
 
-	for _, test := range tests {
-		executor := &MockExecutor{Debug: true}
-		u := newUpdate("owner", "repo", true, executor)
-		u.setPermissions()
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("expected the binary to be kept, got %v", err)
+	}
 
-		if executor.Command != "sudo" {
-			t.Errorf("unexpected command received: expected %s, but got %s", "git", executor.Command)
-		}
+	removeOldBinary(target)
+}
 
-		if len(executor.Args) != len(test.expectedArgs) {
-			t.Errorf("unexpected args received: expected %v, but got %v", test.expectedArgs, executor.Args)
-		}
+func Test_replaceWithSudo(t *testing.T) {
+	executor := &MockExecutor{Debug: true}
+	u := newUpdate(true, executor, nil, nil, "0.2.1")
 
-		for i, v := range executor.Args {
-			if v != test.expectedArgs[i] {
-				t.Errorf("unexpected args received: expected %v, but got %v", test.expectedArgs, executor.Args)
-			}
-		}
+	err := u.replaceWithSudo("/usr/local/bin/git-helper", []byte("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(executor.Args[1])
+
+	if executor.Command != "sudo" || executor.Args[0] != "mv" || executor.Args[2] != "/usr/local/bin/git-helper" {
+		t.Errorf("unexpected command received: %s %v", executor.Command, executor.Args)
+	}
+
+	content, _ := os.ReadFile(executor.Args[1])
+	if string(content) != "new" {
+		t.Errorf("expected the new binary to be moved, got %q", content)
 	}
 }
 
 func Test_outputNewVersion(t *testing.T) {
-	tests := []struct {
-		executorOutput string
-		expectedArgs   []string
-	}{
-		{
-			executorOutput: "Installed git-helper version",
-			expectedArgs:   []string{"version"},
-		},
+	executor := &MockExecutor{Debug: true, Output: []byte("git-helper version 0.3.0\n")}
+	u := newUpdate(true, executor, nil, nil, "0.2.1")
+	u.outputNewVersion("/usr/local/bin/git-helper")
+
+	if executor.Command != "/usr/local/bin/git-helper" || strings.Join(executor.Args, " ") != "version" {
+		t.Errorf("unexpected command received: %s %v", executor.Command, executor.Args)
 	}
+}
 
-	for _, test := range tests {
-		executor := &MockExecutor{
-			Debug:  true,
-			Output: []byte(test.executorOutput),
-		}
+func Test_regeneratePlugins(t *testing.T) {
+	executor := &MockExecutor{Debug: true}
+	u := newUpdate(true, executor, nil, nil, "0.2.1")
 
-		u := newUpdate("owner", "repo", true, executor)
-		u.outputNewVersion()
+	binDir := t.TempDir()
+	binary := filepath.Join(binDir, "git-helper")
 
-		if executor.Command != "git-helper" {
-			t.Errorf("unexpected command received: expected %s, but got %s", "git", executor.Command)
-		}
+	u.regeneratePlugins(t.TempDir()+"/missing", binary)
+	if executor.Command != "" {
+		t.Errorf("expected no command without plugins, but got %s", executor.Command)
+	}
 
-		if len(executor.Args) != len(test.expectedArgs) {
-			t.Errorf("unexpected args received: expected %v, but got %v", test.expectedArgs, executor.Args)
-		}
+	u.regeneratePlugins(t.TempDir(), binary)
+	if executor.Command != binary || strings.Join(executor.Args, " ") != "setup --plugins" {
+		t.Errorf("unexpected command received: %s %v", executor.Command, executor.Args)
+	}
 
-		for i, v := range executor.Args {
-			if v != test.expectedArgs[i] {
-				t.Errorf("unexpected args received: expected %v, but got %v", test.expectedArgs, executor.Args)
-			}
-		}
+	executor.Command = ""
+	os.Symlink(binary, filepath.Join(binDir, "git-new-branch"))
+	u.regeneratePlugins(t.TempDir()+"/missing", binary)
+	if executor.Command != binary || strings.Join(executor.Args, " ") != "setup --plugins" {
+		t.Errorf("unexpected command received: %s %v", executor.Command, executor.Args)
 	}
 }
@@ -1,22 +1,213 @@
 package version
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
 
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/release"
+	"github.com/emmahsax/go-git-helper/internal/utils"
 	"github.com/spf13/cobra"
 )
 
-func NewCommand(packageVersion string) *cobra.Command {
+// Build is the version metadata injected with -ldflags "-X main.packageCommit=..."
+// at build time. Anything left empty is filled in from the binary's build info,
+// and so is the version when nothing was injected.
+type Build struct {
+	Commit  string
+	Date    string
+	Version string
+}
+
+// pseudoVersion matches the end of a Go pseudo-version, which is given to
+// builds of commits that aren't tagged.
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}$`)
+
+// NewBuild returns the build metadata injected at build time, filled in from
+// the binary's build info.
+func NewBuild(commit, date, version string) Build {
+	return fillBuild(Build{Commit: commit, Date: date, Version: version}, debug.ReadBuildInfo)
+}
+
+type Version struct {
+	Build      Build
+	Client     *release.Client
+	ConfigFile configfile.ConfigFileInterface
+	Debug      bool
+	Executor   executor.ExecutorInterface
+}
+
+type Info struct {
+	Arch            string `json:"arch"`
+	BuildDate       string `json:"build_date,omitempty"`
+	Commit          string `json:"commit,omitempty"`
+	ConfigFile      string `json:"config_file"`
+	GitVersion      string `json:"git_version,omitempty"`
+	GoVersion       string `json:"go_version"`
+	Latest          string `json:"latest,omitempty"`
+	OS              string `json:"os"`
+	UpdateAvailable *bool  `json:"update_available,omitempty"`
+	Version         string `json:"version"`
+}
+
+func NewCommand(packageOwner, packageRepository string, build Build) *cobra.Command {
+	var (
+		check      bool
+		debugB     bool
+		jsonOutput bool
+	)
+
 	cmd := &cobra.Command{
 		Use:                   "version",
 		Short:                 "Print the version number",
 		Args:                  cobra.ExactArgs(0),
 		DisableFlagsInUseLine: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Printf("git-helper version %s\n", packageVersion)
+			v := newVersion(debugB, executor.NewExecutor(debugB), configfile.NewConfigFile(debugB), release.NewClient(packageOwner, packageRepository), build)
+			v.execute(jsonOutput, check)
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&check, "check", false, "compare against the latest release on GitHub")
+	cmd.Flags().BoolVar(&debugB, "debug", false, "enables debug mode")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the version, build and environment details as JSON")
+
 	return cmd
 }
+
+func newVersion(debugB bool, executor executor.ExecutorInterface, configFile configfile.ConfigFileInterface, client *release.Client, build Build) *Version {
+	return &Version{
+		Build:      fillBuild(build, debug.ReadBuildInfo),
+		Client:     client,
+		ConfigFile: configFile,
+		Debug:      debugB,
+		Executor:   executor,
+	}
+}
+
+func (v *Version) execute(jsonOutput, check bool) {
+	info := v.info(jsonOutput)
+
+	if check {
+		err := v.check(info)
+		if err != nil {
+			utils.HandleError(err, v.Debug, nil)
+			return
+		}
+	}
+
+	if jsonOutput {
+		output, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			utils.HandleError(err, v.Debug, nil)
+			return
+		}
+
+		fmt.Println(string(output))
+		return
+	}
+
+	fmt.Printf("git-helper version %s\n", info.Version)
+	if info.Commit != "" {
+		fmt.Printf("  commit %s, built %s with %s for %s/%s\n", info.Commit, utils.ValueOr(info.BuildDate, "at an unknown date"), info.GoVersion, info.OS, info.Arch)
+	}
+
+	if info.UpdateAvailable != nil {
+		if *info.UpdateAvailable {
+			fmt.Printf("git-helper %s is available, run git-helper update to install it\n", info.Latest)
+		} else {
+			fmt.Println("git-helper is up to date")
+		}
+	}
+}
+
+// check compares the version against the latest release on GitHub.
+func (v *Version) check(info *Info) error {
+	rel, err := v.Client.Latest(false)
+	if err != nil {
+		return err
+	}
+
+	available := release.Compare(rel.TagName, info.Version) > 0
+	info.Latest = rel.TagName
+	info.UpdateAvailable = &available
+	return nil
+}
+
+// info collects the version details. The git version and config file are
+// only looked up for the JSON output.
+func (v *Version) info(details bool) *Info {
+	info := &Info{
+		Arch:      runtime.GOARCH,
+		BuildDate: v.Build.Date,
+		Commit:    v.Build.Commit,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Version:   v.Build.Version,
+	}
+
+	if details {
+		info.ConfigFile = v.ConfigFile.ConfigFile()
+
+		output, err := v.Executor.Exec("actionAndOutput", "git", "--version")
+		if err == nil {
+			info.GitVersion = strings.TrimPrefix(strings.TrimSpace(string(output)), "git version ")
+		}
+	}
+
+	return info
+}
+
+// fillBuild fills in whatever wasn't injected at build time from the module
+// and VCS details Go records in the binary.
+func fillBuild(build Build, readBuildInfo func() (*debug.BuildInfo, bool)) Build {
+	buildInfo, ok := readBuildInfo()
+	if !ok {
+		return build
+	}
+
+	// The commit is always injected along with the version, so without it the
+	// version is only the one in the source, and a release's module version,
+	// like from go install, is more accurate
+	if (build.Version == "" || build.Commit == "") && isRelease(buildInfo.Main.Version) {
+		build.Version = strings.TrimPrefix(buildInfo.Main.Version, "v")
+	}
+
+	var revision, date string
+	modified := false
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.time":
+			date = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+
+	if build.Commit == "" && revision != "" {
+		build.Commit = revision
+		if modified {
+			build.Commit += "-dirty"
+		}
+	}
+
+	if build.Date == "" {
+		build.Date = date
+	}
+
+	return build
+}
+
+// isRelease reports whether a module version is a tagged release, rather than
+// a development build, a pseudo-version or one with local changes.
+func isRelease(version string) bool {
+	return strings.HasPrefix(version, "v") && !strings.Contains(version, "+") && !pseudoVersion.MatchString(version)
+}
@@ -1,11 +1,30 @@
 package version
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
 	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/release"
 )
 
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Output  []byte
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	return me.Output, nil
+}
+
 func Test_NewCommand(t *testing.T) {
-	cmd := NewCommand("1.2.3")
+	cmd := NewCommand("owner", "repo", Build{Version: "1.2.3"})
 
 	if cmd.Use != "version" {
 		t.Errorf("Expected command Use to be 'version', got '%s'", cmd.Use)
@@ -17,8 +36,8 @@ func Test_NewCommand(t *testing.T) {
 }
 
 func Test_NewCommand_ExecuteWithVersion(t *testing.T) {
-	version := "1.2.3"
-	cmd := NewCommand(version)
+	version := Build{Version: "1.2.3"}
+	cmd := NewCommand("owner", "repo", version)
 
 	// Set args to empty to avoid default os.Args
 	cmd.SetArgs([]string{})
@@ -34,8 +53,8 @@ func Test_NewCommand_ExecuteWithVersion(t *testing.T) {
 }
 
 func Test_NewCommand_WithDifferentVersion(t *testing.T) {
-	version := "2.0.0-beta"
-	cmd := NewCommand(version)
+	version := Build{Version: "2.0.0-beta", Commit: "abc1234", Date: "2026-01-01T00:00:00Z"}
+	cmd := NewCommand("owner", "repo", version)
 
 	// Set args to empty to avoid default os.Args
 	cmd.SetArgs([]string{})
@@ -50,7 +69,7 @@ func Test_NewCommand_WithDifferentVersion(t *testing.T) {
 }
 
 func Test_NewCommand_NoArgs(t *testing.T) {
-	cmd := NewCommand("1.0.0")
+	cmd := NewCommand("owner", "repo", Build{Version: "1.0.0"})
 
 	// Set args to empty slice
 	cmd.SetArgs([]string{})
@@ -62,7 +81,7 @@ func Test_NewCommand_NoArgs(t *testing.T) {
 }
 
 func Test_NewCommand_WithArgs_ShouldFail(t *testing.T) {
-	cmd := NewCommand("1.0.0")
+	cmd := NewCommand("owner", "repo", Build{Version: "1.0.0"})
 
 	// Set args that should be rejected
 	cmd.SetArgs([]string{"extra-arg"})
@@ -72,3 +91,107 @@ func Test_NewCommand_WithArgs_ShouldFail(t *testing.T) {
 		t.Errorf("Expected error with extra args, got nil")
 	}
 }
+
+func Test_fillBuild(t *testing.T) {
+	buildInfo := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123abc"},
+			{Key: "vcs.time", Value: "2026-01-01T00:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	readBuildInfo := func() (*debug.BuildInfo, bool) { return buildInfo, true }
+
+	tests := []struct {
+		name     string
+		build    Build
+		expected Build
+	}{
+		{
+			name:     "from build info",
+			build:    Build{},
+			expected: Build{Commit: "0123abc-dirty", Date: "2026-01-01T00:00:00Z", Version: "1.4.0"},
+		},
+		{
+			name:     "source version",
+			build:    Build{Version: "1.3.0"},
+			expected: Build{Commit: "0123abc-dirty", Date: "2026-01-01T00:00:00Z", Version: "1.4.0"},
+		},
+		{
+			name:     "ldflags win",
+			build:    Build{Commit: "fff0000", Date: "2025-12-31", Version: "1.3.0"},
+			expected: Build{Commit: "fff0000", Date: "2025-12-31", Version: "1.3.0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if build := fillBuild(test.build, readBuildInfo); build != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, build)
+			}
+		})
+	}
+
+	if build := fillBuild(Build{Version: "1.0.0"}, func() (*debug.BuildInfo, bool) { return nil, false }); build.Version != "1.0.0" {
+		t.Errorf("expected the version to be kept without build info, got %+v", build)
+	}
+
+	for _, moduleVersion := range []string{"(devel)", "v1.4.1-0.20260101000000-0123456789ab", "v1.4.0+dirty"} {
+		buildInfo.Main.Version = moduleVersion
+		if build := fillBuild(Build{Version: "1.3.0"}, readBuildInfo); build.Version != "1.3.0" {
+			t.Errorf("expected the source version to be kept for %s, got %+v", moduleVersion, build)
+		}
+	}
+}
+
+func Test_info(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	executor := &MockExecutor{Debug: true, Output: []byte("git version 2.43.0\n")}
+	v := newVersion(true, executor, configfile.NewConfigFile(true), nil, Build{Version: "0.2.1"})
+
+	info := v.info(true)
+	if info.GitVersion != "2.43.0" {
+		t.Errorf("expected git version 2.43.0, got %s", info.GitVersion)
+	}
+
+	if info.ConfigFile != configfile.NewConfigFile(true).ConfigFile() {
+		t.Errorf("expected the config file path, got %s", info.ConfigFile)
+	}
+
+	if info.Version != "0.2.1" || info.GoVersion == "" || info.OS == "" {
+		t.Errorf("expected the build details, got %+v", info)
+	}
+}
+
+func Test_check(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "v0.3.0"}`))
+	}))
+	defer server.Close()
+
+	client := release.NewClient("owner", "repo")
+	client.APIURL = server.URL
+
+	tests := []struct {
+		version   string
+		available bool
+	}{
+		{version: "0.2.1", available: true},
+		{version: "0.3.0", available: false},
+	}
+
+	for _, test := range tests {
+		v := newVersion(true, &MockExecutor{Debug: true}, nil, client, Build{Version: test.version})
+		info := v.info(false)
+
+		err := v.check(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Latest != "v0.3.0" || info.UpdateAvailable == nil || *info.UpdateAvailable != test.available {
+			t.Errorf("expected update available to be %t for %s, got %+v", test.available, test.version, info)
+		}
+	}
+}
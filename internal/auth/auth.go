@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/github"
+	"github.com/emmahsax/go-git-helper/internal/gitlab"
+)
+
+// ExpiryWarning is how close to expiring a token has to be before it's
+// reported.
+const ExpiryWarning = 7 * 24 * time.Hour
+
+// RequiredScopes lists the scopes each forge's token needs for every Git
+// Helper command to work.
+var RequiredScopes = map[string][]string{
+	credentials.GitHub: {"repo"},
+	credentials.GitLab: {"api"},
+}
+
+type Status struct {
+	ExpiresAt *time.Time
+	Forge     string
+	Host      string
+	Scopes    []string
+	Username  string
+	Warnings  []string
+}
+
+// CheckToken calls the forge's /user endpoint with the token to confirm it
+// works. It's a variable so tests can avoid the network.
+var CheckToken = func(debug bool, forge, host, token string) (*Status, error) {
+	if forge == credentials.GitLab {
+		return CheckGitLab(gitlab.NewGitLabFromToken(debug, host, token), host)
+	}
+
+	return CheckGitHub(github.NewGitHubFromToken(debug, host, token), host)
+}
+
+func CheckGitHub(gh *github.GitHub, host string) (*Status, error) {
+	info, err := gh.TokenInfo()
+	if err != nil {
+		return nil, fmt.Errorf("GitHub rejected the token for %s: %s", host, err)
+	}
+
+	return newStatus(credentials.GitHub, host, info.Username, info.Scopes, info.ExpiresAt, time.Now()), nil
+}
+
+func CheckGitLab(gl *gitlab.GitLab, host string) (*Status, error) {
+	info, err := gl.TokenInfo()
+	if err != nil {
+		return nil, fmt.Errorf("GitLab rejected the token for %s: %s", host, err)
+	}
+
+	return newStatus(credentials.GitLab, host, info.Username, info.Scopes, info.ExpiresAt, time.Now()), nil
+}
+
+// String renders the status as an indented summary for the terminal.
+func (s *Status) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "  Logged in to %s as %s\n", s.Host, s.Username)
+
+	if s.Scopes != nil {
+		scopes := strings.Join(s.Scopes, ", ")
+		if scopes == "" {
+			scopes = "none"
+		}
+		fmt.Fprintf(&b, "  Token scopes: %s\n", scopes)
+	}
+
+	if s.ExpiresAt != nil {
+		fmt.Fprintf(&b, "  Token expires: %s\n", s.ExpiresAt.Format("2006-01-02"))
+	}
+
+	for _, warning := range s.Warnings {
+		fmt.Fprintf(&b, "  Warning: %s\n", warning)
+	}
+
+	return b.String()
+}
+
+func newStatus(forge, host, username string, scopes []string, expiresAt *time.Time, now time.Time) *Status {
+	status := &Status{
+		ExpiresAt: expiresAt,
+		Forge:     forge,
+		Host:      host,
+		Scopes:    scopes,
+		Username:  username,
+		Warnings:  []string{},
+	}
+
+	// A nil list means the forge didn't report scopes, e.g. for fine-grained
+	// GitHub tokens, so there's nothing to compare against
+	if scopes != nil {
+		for _, required := range RequiredScopes[forge] {
+			if !slices.Contains(scopes, required) {
+				status.Warnings = append(status.Warnings, "token is missing the required '"+required+"' scope")
+			}
+		}
+	}
+
+	if expiresAt != nil {
+		if expiresAt.Before(now) {
+			status.Warnings = append(status.Warnings, "token expired on "+expiresAt.Format("2006-01-02"))
+		} else if expiresAt.Sub(now) < ExpiryWarning {
+			status.Warnings = append(status.Warnings, "token expires soon, on "+expiresAt.Format("2006-01-02"))
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/github"
+	"github.com/emmahsax/go-git-helper/internal/gitlab"
+	go_github "github.com/google/go-github/v84/github"
+	go_gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+func Test_CheckGitHub(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-OAuth-Scopes", "read:org, workflow")
+		w.Header().Set("GitHub-Authentication-Token-Expiration", time.Now().Add(48*time.Hour).UTC().Format("2006-01-02 15:04:05 MST"))
+		fmt.Fprint(w, `{"login": "octocat"}`)
+	}))
+	defer server.Close()
+
+	client := go_github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+
+	status, err := CheckGitHub(&github.GitHub{Client: client}, "github.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Username != "octocat" {
+		t.Errorf("expected username 'octocat', got '%s'", status.Username)
+	}
+
+	if len(status.Warnings) != 2 || !strings.Contains(status.Warnings[0], "'repo' scope") || !strings.Contains(status.Warnings[1], "expires soon") {
+		t.Errorf("expected missing scope and expiry warnings, got %v", status.Warnings)
+	}
+}
+
+func Test_CheckGitHub_BadToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message": "Bad credentials"}`)
+	}))
+	defer server.Close()
+
+	client := go_github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+
+	_, err := CheckGitHub(&github.GitHub{Client: client}, "github.com")
+	if err == nil || !strings.HasPrefix(err.Error(), "GitHub rejected the token for github.com") {
+		t.Errorf("expected a rejected token error, got %v", err)
+	}
+}
+
+func Test_CheckGitLab(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/user":
+			fmt.Fprint(w, `{"id": 1, "username": "tanuki"}`)
+		case "/api/v4/personal_access_tokens/self":
+			fmt.Fprint(w, `{"id": 2, "scopes": ["api"], "expires_at": "2999-01-01"}`)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := go_gitlab.NewClient("", go_gitlab.WithBaseURL(server.URL))
+
+	status, err := CheckGitLab(&gitlab.GitLab{Client: client}, "gitlab.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Username != "tanuki" || len(status.Warnings) != 0 {
+		t.Errorf("expected tanuki with no warnings, got %s with %v", status.Username, status.Warnings)
+	}
+}
+
+func Test_newStatus(t *testing.T) {
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+	later := now.Add(30 * 24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		forge     string
+		scopes    []string
+		expiresAt *time.Time
+		expected  []string
+	}{
+		{
+			name:     "valid classic token",
+			forge:    credentials.GitHub,
+			scopes:   []string{"repo"},
+			expected: []string{},
+		},
+		{
+			name:     "fine-grained token without scopes",
+			forge:    credentials.GitHub,
+			scopes:   nil,
+			expected: []string{},
+		},
+		{
+			name:      "missing scope and expired",
+			forge:     credentials.GitLab,
+			scopes:    []string{"read_api"},
+			expiresAt: &expired,
+			expected:  []string{"token is missing the required 'api' scope", "token expired on 2026-10-18"},
+		},
+		{
+			name:      "expires later",
+			forge:     credentials.GitLab,
+			scopes:    []string{"api"},
+			expiresAt: &later,
+			expected:  []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := newStatus(test.forge, "example.com", "user", test.scopes, test.expiresAt, now)
+
+			if len(status.Warnings) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, status.Warnings)
+			}
+
+			for i, warning := range status.Warnings {
+				if warning != test.expected[i] {
+					t.Errorf("expected '%s', got '%s'", test.expected[i], warning)
+				}
+			}
+		})
+	}
+}
+
+func Test_String(t *testing.T) {
+	expiresAt := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	status := &Status{
+		ExpiresAt: &expiresAt,
+		Host:      "github.com",
+		Scopes:    []string{"repo", "workflow"},
+		Username:  "octocat",
+		Warnings:  []string{"token expires soon, on 2026-11-01"},
+	}
+
+	expected := "  Logged in to github.com as octocat\n  Token scopes: repo, workflow\n  Token expires: 2026-11-01\n  Warning: token expires soon, on 2026-11-01\n"
+	if status.String() != expected {
+		t.Errorf("expected %q, got %q", expected, status.String())
+	}
+}
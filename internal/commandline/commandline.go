@@ -71,3 +71,21 @@ var AskYesNoQuestion = func(question string) bool {
 
 	return result
 }
+
+// StdinIsTerminal reports whether questions can be asked, which they can't when
+// stdin is a pipe or a file, like in scripts and CI.
+var StdinIsTerminal = func() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// NewLiveArea returns a function that redraws a block of text in place, and
+// one that stops redrawing it and leaves the last text on screen.
+var NewLiveArea = func() (func(text string), func()) {
+	area, err := pterm.DefaultArea.Start()
+	if err != nil {
+		return func(text string) { fmt.Print(text) }, func() {}
+	}
+
+	return func(text string) { area.Update(text) }, func() { _ = area.Stop() }
+}
@@ -0,0 +1,154 @@
+package configfile
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	forgeGitHub = "github"
+	forgeGitLab = "gitlab"
+)
+
+// ForgeName returns the account's forge. It only has to be set for hosts other
+// than github.com and gitlab.com.
+func (a *Account) ForgeName() string {
+	if a.Forge != "" {
+		return a.Forge
+	}
+
+	return forgeFromHost(a.Host)
+}
+
+// Owns reports whether the account is meant for repositories under owner.
+// Owners may be globs like acme-*, and a GitLab subgroup matches its top-level
+// group.
+func (a *Account) Owns(owner string) bool {
+	owner = strings.ToLower(owner)
+	topLevel := strings.SplitN(owner, "/", 2)[0]
+
+	for _, pattern := range a.Owners {
+		pattern = strings.ToLower(pattern)
+		for _, candidate := range []string{owner, topLevel} {
+			if matched, _ := path.Match(pattern, candidate); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Forge returns which forge runs on host, from the accounts configured for it
+// or else because it's github.com or gitlab.com. It's empty for any other
+// host, since a name like github.example.com doesn't say who runs it.
+func (c *Config) Forge(host string) string {
+	for i := range c.Accounts {
+		if strings.EqualFold(c.Accounts[i].Host, host) && c.Accounts[i].ForgeName() != "" {
+			return c.Accounts[i].ForgeName()
+		}
+	}
+
+	return forgeFromHost(host)
+}
+
+// FindAccount returns the account to use for a repository on host owned by
+// owner: the pinned account if there is one, then an account listing the
+// owner, then the host's account without owners. It's nil when no account is
+// configured for the host.
+func (c *Config) FindAccount(forge, host, owner string) (*Account, error) {
+	if c.Account != "" {
+		for i := range c.Accounts {
+			account := &c.Accounts[i]
+			if account.Name != c.Account {
+				continue
+			}
+
+			if !strings.EqualFold(account.Host, host) || account.ForgeName() != forge {
+				return nil, fmt.Errorf("pinned account %q is for %s, not %s", account.Name, account.Host, host)
+			}
+
+			return account, nil
+		}
+
+		return nil, fmt.Errorf("pinned account %q is not configured", c.Account)
+	}
+
+	var fallback *Account
+	for i := range c.Accounts {
+		account := &c.Accounts[i]
+		if !strings.EqualFold(account.Host, host) || account.ForgeName() != forge {
+			continue
+		}
+
+		if len(account.Owners) == 0 {
+			if fallback == nil {
+				fallback = account
+			}
+			continue
+		}
+
+		if owner != "" && account.Owns(owner) {
+			return account, nil
+		}
+	}
+
+	return fallback, nil
+}
+
+// ValidateAccounts returns problems with the accounts that only show once
+// every layer is merged, like an account without a host.
+func (c *Config) ValidateAccounts() []error {
+	var problems []error
+	defaults := map[string][]string{}
+	pinned := c.Account == ""
+
+	for _, account := range c.Accounts {
+		if account.Name == c.Account {
+			pinned = true
+		}
+
+		if account.Host == "" {
+			problems = append(problems, fmt.Errorf("accounts.%s.host must be set", account.Name))
+			continue
+		}
+
+		if forge := account.ForgeName(); forge != forgeGitHub && forge != forgeGitLab {
+			problems = append(problems, fmt.Errorf("accounts.%s.forge must be set to github or gitlab for %s", account.Name, account.Host))
+		}
+
+		if len(account.Owners) == 0 {
+			host := strings.ToLower(account.Host)
+			defaults[host] = append(defaults[host], account.Name)
+		}
+	}
+
+	for host, names := range defaults {
+		if len(names) > 1 {
+			problems = append(problems, fmt.Errorf("accounts %s all have no owners, so only %s is ever used for %s", strings.Join(names, ", "), names[0], host))
+		}
+	}
+
+	if !pinned {
+		problems = append(problems, fmt.Errorf("account %q is pinned but not configured", c.Account))
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Error() < problems[j].Error()
+	})
+
+	return problems
+}
+
+func forgeFromHost(host string) string {
+	switch strings.ToLower(host) {
+	case "github.com":
+		return forgeGitHub
+	case "gitlab.com":
+		return forgeGitLab
+	default:
+		return ""
+	}
+}
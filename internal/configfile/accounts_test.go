@@ -0,0 +1,122 @@
+package configfile
+
+import (
+	"testing"
+)
+
+func testAccounts() *Config {
+	return &Config{
+		Accounts: []Account{
+			{Name: "personal", Host: "github.com"},
+			{Name: "work", Host: "github.com", Owners: []string{"acme", "acme-*"}},
+			{Name: "enterprise", Forge: "github", Host: "git.example.com"},
+			{Name: "gitlab", Host: "gitlab.com", Owners: []string{"team"}},
+		},
+	}
+}
+
+func Test_FindAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		forge    string
+		host     string
+		owner    string
+		pinned   string
+		expected string
+	}{
+		{name: "owner match", forge: "github", host: "github.com", owner: "acme", expected: "work"},
+		{name: "owner glob", forge: "github", host: "GitHub.com", owner: "Acme-Labs", expected: "work"},
+		{name: "default for host", forge: "github", host: "github.com", owner: "emmahsax", expected: "personal"},
+		{name: "enterprise host", forge: "github", host: "git.example.com", owner: "anyone", expected: "enterprise"},
+		{name: "subgroup", forge: "gitlab", host: "gitlab.com", owner: "team/subgroup", expected: "gitlab"},
+		{name: "no default", forge: "gitlab", host: "gitlab.com", owner: "other", expected: ""},
+		{name: "unknown host", forge: "github", host: "github.other.com", owner: "acme", expected: ""},
+		{name: "pinned", forge: "github", host: "github.com", owner: "acme", pinned: "personal", expected: "personal"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := testAccounts()
+			config.Account = test.pinned
+
+			account, err := config.FindAccount(test.forge, test.host, test.owner)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			name := ""
+			if account != nil {
+				name = account.Name
+			}
+
+			if name != test.expected {
+				t.Errorf("expected account '%s', got '%s'", test.expected, name)
+			}
+		})
+	}
+}
+
+func Test_FindAccount_PinnedErrors(t *testing.T) {
+	config := testAccounts()
+
+	config.Account = "enterprise"
+	if _, err := config.FindAccount("github", "github.com", "acme"); err == nil {
+		t.Error("expected an error when the pinned account is for another host")
+	}
+
+	config.Account = "missing"
+	if _, err := config.FindAccount("github", "github.com", "acme"); err == nil {
+		t.Error("expected an error when the pinned account doesn't exist")
+	}
+}
+
+func Test_Forge(t *testing.T) {
+	config := testAccounts()
+
+	for host, expected := range map[string]string{
+		"github.com":          "github",
+		"gitlab.com":          "gitlab",
+		"git.example.com":     "github",
+		"bitbucket.org":       "",
+		"gitlab.example.com":  "",
+		"github.internal.com": "",
+	} {
+		if forge := config.Forge(host); forge != expected {
+			t.Errorf("expected %s to be '%s', got '%s'", host, expected, forge)
+		}
+	}
+}
+
+func Test_ValidateAccounts(t *testing.T) {
+	config := &Config{
+		Account: "missing",
+		Accounts: []Account{
+			{Name: "nohost"},
+			{Name: "unknown", Host: "git.example.com"},
+			{Name: "one", Host: "github.com"},
+			{Name: "two", Host: "github.com"},
+		},
+	}
+
+	expected := []string{
+		`account "missing" is pinned but not configured`,
+		"accounts one, two all have no owners, so only one is ever used for github.com",
+		"accounts.nohost.host must be set",
+		"accounts.unknown.forge must be set to github or gitlab for git.example.com",
+	}
+
+	problems := config.ValidateAccounts()
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %v", len(expected), problems)
+	}
+
+	for i, problem := range problems {
+		if problem.Error() != expected[i] {
+			t.Errorf("expected '%s', got '%s'", expected[i], problem)
+		}
+	}
+
+	if problems := testAccounts().ValidateAccounts(); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
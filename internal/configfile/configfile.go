@@ -1,9 +1,17 @@
 package configfile
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 
+	"github.com/emmahsax/go-git-helper/internal/executor"
 	"github.com/emmahsax/go-git-helper/internal/utils"
 	yaml "gopkg.in/yaml.v3"
 )
@@ -17,16 +25,83 @@ type ConfigFileInterface interface {
 	GitLabUsername() string
 	GitHubToken() string
 	GitLabToken() string
+	Layers() ([]Layer, error)
+	Load() (*Config, error)
+	LoadFile(path string) (*Config, error)
+	Origins() (map[string]string, error)
+	RepoConfigFile() string
+	Save(config *Config) error
+	SaveFile(path string, config *Config) error
 	SpecialCapitalization() map[string]string
 }
 
 type ConfigFile struct {
-	Debug bool
+	Debug    bool
+	Executor executor.ExecutorInterface
+	config   *Config
+	origins  map[string]string
+}
+
+// Layer is one source of config values. Layers are merged in order, with
+// later layers taking precedence. Repository layers come from the repository's
+// .git-helper.yml or local git config, and can't set global-only keys.
+type Layer struct {
+	Config     *Config
+	Origin     string
+	Repository bool
+}
+
+type Config struct {
+	GitHubUsername        string            `yaml:"github_username,omitempty"`
+	GitHubToken           string            `yaml:"github_token,omitempty"`
+	GitHubTokenCommand    string            `yaml:"github_token_command,omitempty"`
+	GitHubRefreshToken    string            `yaml:"github_refresh_token,omitempty"`
+	GitHubOAuthClientID   string            `yaml:"github_oauth_client_id,omitempty"`
+	GitLabUsername        string            `yaml:"gitlab_username,omitempty"`
+	GitLabToken           string            `yaml:"gitlab_token,omitempty"`
+	GitLabTokenCommand    string            `yaml:"gitlab_token_command,omitempty"`
+	GitLabRefreshToken    string            `yaml:"gitlab_refresh_token,omitempty"`
+	GitLabOAuthClientID   string            `yaml:"gitlab_oauth_client_id,omitempty"`
+	SpecialCapitalization map[string]string `yaml:"special_capitalization,omitempty"`
+	Accounts              []Account         `yaml:"accounts,omitempty"`
+	Account               string            `yaml:"account,omitempty"`
+	UpdatePublicKey       string            `yaml:"update_public_key,omitempty"`
+	DisableUpdateNotifier bool              `yaml:"disable_update_notifier,omitempty"`
+	SyncStrategy          string            `yaml:"sync_strategy,omitempty"`
+	Reviewers             []string          `yaml:"reviewers,omitempty"`
+}
+
+// Account is one forge login. When several accounts share a host, the one
+// listing the remote's owner is used, then the one without owners.
+type Account struct {
+	Name         string   `yaml:"name"`
+	Forge        string   `yaml:"forge,omitempty"`
+	Host         string   `yaml:"host,omitempty"`
+	Username     string   `yaml:"username,omitempty"`
+	Owners       []string `yaml:"owners,omitempty"`
+	Token        string   `yaml:"token,omitempty"`
+	TokenCommand string   `yaml:"token_command,omitempty"`
+	RefreshToken string   `yaml:"refresh_token,omitempty"`
+}
+
+const (
+	EnvPrefix          = "GIT_HELPER_"
+	RepoConfigFileName = ".git-helper.yml"
+)
+
+// legacyKeys maps the keys written by the Ruby version of Git Helper to their
+// current names.
+var legacyKeys = map[string]string{
+	":github_user":  "github_username",
+	":github_token": "github_token",
+	":gitlab_user":  "gitlab_username",
+	":gitlab_token": "gitlab_token",
 }
 
 func NewConfigFile(debug bool) *ConfigFile {
 	return &ConfigFile{
-		Debug: debug,
+		Debug:    debug,
+		Executor: executor.NewExecutor(debug),
 	}
 }
 
@@ -60,92 +135,533 @@ func (cf *ConfigFile) ConfigFileExists() bool {
 	return err == nil
 }
 
-// TODO: pull from the values w/o the : at the beginning, as that's leftover from ruby to go migration
+// RepoConfigFile returns the path of the repository's .git-helper.yml, or an
+// empty string when not inside a git repository.
+func (cf *ConfigFile) RepoConfigFile() string {
+	output, err := cf.Executor.Exec("actionAndOutput", "git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
 
-func (cf *ConfigFile) GitHubUsername() string {
-	configFile := cf.configFileContents()
-	if configFile["github_username"] != "" {
-		return configFile["github_username"]
-	} else {
-		return configFile[":github_user"]
+	rootDir := strings.TrimSpace(string(output))
+	if rootDir == "" {
+		return ""
 	}
+
+	return filepath.Join(rootDir, RepoConfigFileName)
+}
+
+func (cf *ConfigFile) GitHubUsername() string {
+	return cf.loadOrExit().GitHubUsername
 }
 
 func (cf *ConfigFile) GitLabUsername() string {
-	configFile := cf.configFileContents()
-	if configFile["gitlab_username"] != "" {
-		return configFile["gitlab_username"]
-	} else {
-		return configFile[":gitlab_user"]
-	}
+	return cf.loadOrExit().GitLabUsername
 }
 
 func (cf *ConfigFile) GitHubToken() string {
-	configFile := cf.configFileContents()
-	if configFile["github_token"] != "" {
-		return configFile["github_token"]
-	} else {
-		return configFile[":github_token"]
-	}
+	return cf.loadOrExit().GitHubToken
 }
 
 func (cf *ConfigFile) GitLabToken() string {
-	configFile := cf.configFileContents()
-	if configFile["gitlab_token"] != "" {
-		return configFile["gitlab_token"]
-	} else {
-		return configFile[":gitlab_token"]
-	}
+	return cf.loadOrExit().GitLabToken
 }
 
 func (cf *ConfigFile) SpecialCapitalization() map[string]string {
-	var result map[string]interface{}
-	data, err := os.ReadFile(cf.ConfigFile())
-	if err != nil {
+	config, err := cf.Load()
+	if err != nil || config.SpecialCapitalization == nil {
 		return map[string]string{}
 	}
 
-	err = yaml.Unmarshal(data, &result)
+	return config.SpecialCapitalization
+}
+
+// Load merges every config layer once, caching the result for the rest of the
+// command. See Layers for the order they're applied in.
+func (cf *ConfigFile) Load() (*Config, error) {
+	if cf.config != nil {
+		return cf.config, nil
+	}
+
+	layers, err := cf.Layers()
 	if err != nil {
-		return map[string]string{}
+		return nil, err
 	}
 
-	if specialCap, ok := result["special_capitalization"].(map[string]interface{}); ok {
-		capitalizations := make(map[string]string)
-		for key, value := range specialCap {
-			if strValue, ok := value.(string); ok {
-				capitalizations[key] = strValue
+	config := &Config{}
+	origins := map[string]string{}
+	for _, layer := range layers {
+		values := layer.Config.Values()
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if layer.Repository && IsGlobalOnly(key) {
+				return nil, fmt.Errorf("%s: %s can only be set in the global config file, global git config or the environment", layer.Origin, key)
 			}
+
+			value := values[key]
+			err = config.Set(key, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", layer.Origin, err)
+			}
+
+			origins[key] = layer.Origin
 		}
-		return capitalizations
 	}
 
-	return map[string]string{}
+	cf.config = config
+	cf.origins = origins
+	return config, nil
 }
 
-func (cf *ConfigFile) configFileContents() map[string]string {
-	var rawResult map[string]interface{}
-	data, err := os.ReadFile(cf.ConfigFile())
+// Origins returns where each of the merged values in Load came from.
+func (cf *ConfigFile) Origins() (map[string]string, error) {
+	_, err := cf.Load()
 	if err != nil {
-		customErr := errors.New("error reading file: " + err.Error())
-		utils.HandleError(customErr, cf.Debug, nil)
-		return map[string]string{}
+		return nil, err
 	}
 
-	err = yaml.Unmarshal(data, &rawResult)
+	return cf.origins, nil
+}
+
+// Layers returns the config sources from lowest to highest precedence: the
+// global config file, the repository's .git-helper.yml, git config keys under
+// helper.*, and GIT_HELPER_* environment variables.
+func (cf *ConfigFile) Layers() ([]Layer, error) {
+	layers := []Layer{}
+
+	global, err := cf.LoadFile(cf.ConfigFile())
 	if err != nil {
-		customErr := errors.New("error unmarshaling YAML: " + err.Error())
-		utils.HandleError(customErr, cf.Debug, nil)
-		return map[string]string{}
+		return nil, err
+	}
+	layers = append(layers, Layer{Config: global, Origin: "file:" + cf.ConfigFile()})
+
+	if repoFile := cf.RepoConfigFile(); repoFile != "" {
+		repo, err := cf.LoadFile(repoFile)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, Layer{Config: repo, Origin: "file:" + repoFile, Repository: true})
+	}
+
+	layers = append(layers, cf.gitConfigLayers()...)
+
+	envLayers, err := envLayers(os.Environ())
+	if err != nil {
+		return nil, err
+	}
+
+	return append(layers, envLayers...), nil
+}
+
+// LoadFile reads a single config file. A missing file is an empty config.
+// Legacy keys from the Ruby version are renamed in the file before it's
+// decoded.
+func (cf *ConfigFile) LoadFile(path string) (*Config, error) {
+	return readConfig(path)
+}
+
+// ReadFile reads a single config file like LoadFile, but only renames legacy
+// keys in memory, so it never writes the file. It's safe to call while a
+// command might be changing the file.
+func (cf *ConfigFile) ReadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+
+		return nil, errors.New("error reading file " + path + ": " + err.Error())
+	}
+
+	migrated, _, err := migrateLegacyKeys(data)
+	if err != nil {
+		return nil, errors.New("error unmarshaling YAML in " + path + ": " + err.Error())
+	}
+
+	return decodeConfig(path, migrated)
+}
+
+// ReadGlobal merges the layers that don't belong to a repository: the global
+// config file, read with ReadFile so it's never written, system and global git
+// config, and the environment.
+func (cf *ConfigFile) ReadGlobal() (*Config, error) {
+	global, err := cf.ReadFile(cf.ConfigFile())
+	if err != nil {
+		return nil, err
+	}
+
+	layers := []Layer{{Config: global, Origin: "file:" + cf.ConfigFile()}}
+	for _, layer := range cf.gitConfigLayers() {
+		if !layer.Repository {
+			layers = append(layers, layer)
+		}
+	}
+
+	envLayers, err := envLayers(os.Environ())
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	for _, layer := range append(layers, envLayers...) {
+		values := layer.Config.Values()
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			err = config.Set(key, values[key])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", layer.Origin, err)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// Save writes the global config file.
+func (cf *ConfigFile) Save(config *Config) error {
+	if !cf.ConfigDirExists() {
+		err := os.MkdirAll(cf.ConfigDir(), 0700)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cf.SaveFile(cf.ConfigFile(), config)
+}
+
+// SaveFile writes a config file. The global config file may hold tokens, so
+// it's only ever readable by the current user.
+func (cf *ConfigFile) SaveFile(path string, config *Config) error {
+	data, err := encodeYAML(config)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if path == cf.ConfigFile() {
+		mode = 0600
+	}
+
+	err = os.WriteFile(path, data, mode)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(path, mode)
+	if err != nil {
+		return err
+	}
+
+	cf.config = nil
+	cf.origins = nil
+	return nil
+}
+
+// Validate returns every problem found in the config. It's empty when the
+// config is valid.
+func (c *Config) Validate() []error {
+	var problems []error
+
+	for key, value := range map[string]string{
+		"github_username": c.GitHubUsername,
+		"github_token":    c.GitHubToken,
+		"gitlab_username": c.GitLabUsername,
+		"gitlab_token":    c.GitLabToken,
+	} {
+		if strings.ContainsAny(value, " \t\n") {
+			problems = append(problems, fmt.Errorf("%s must not contain whitespace", key))
+		}
+	}
+
+	names := map[string]bool{}
+	for _, account := range c.Accounts {
+		if account.Name == "" {
+			problems = append(problems, errors.New("accounts must each have a name"))
+			continue
+		}
+
+		if names[account.Name] {
+			problems = append(problems, fmt.Errorf("accounts.%s is defined more than once", account.Name))
+		}
+		names[account.Name] = true
+
+		if account.Forge != "" && account.Forge != forgeGitHub && account.Forge != forgeGitLab {
+			problems = append(problems, fmt.Errorf("accounts.%s.forge must be github or gitlab", account.Name))
+		}
+
+		for field, value := range map[string]string{"username": account.Username, "token": account.Token} {
+			if strings.ContainsAny(value, " \t\n") {
+				problems = append(problems, fmt.Errorf("accounts.%s.%s must not contain whitespace", account.Name, field))
+			}
+		}
+	}
+
+	if c.SyncStrategy != "" && c.SyncStrategy != "merge" && c.SyncStrategy != "rebase" {
+		problems = append(problems, errors.New("sync_strategy must be merge or rebase"))
+	}
+
+	for word, replacement := range c.SpecialCapitalization {
+		if word != strings.ToLower(word) {
+			problems = append(problems, fmt.Errorf("special_capitalization.%s must be lowercase to ever match", word))
+		}
+
+		if replacement == "" {
+			problems = append(problems, fmt.Errorf("special_capitalization.%s must not be empty", word))
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Error() < problems[j].Error()
+	})
+
+	return problems
+}
+
+func (cf *ConfigFile) loadOrExit() *Config {
+	config, err := cf.Load()
+	if err != nil {
+		utils.HandleError(err, cf.Debug, nil)
+		return &Config{}
+	}
+
+	return config
+}
+
+// gitConfigLayers reads git config keys like helper.github-username,
+// helper.special-capitalization.api or helper.accounts.work.token-command, with
+// one layer per key so each keeps its own origin. Keys from the repository's
+// own git config are repository layers.
+func (cf *ConfigFile) gitConfigLayers() []Layer {
+	output, err := cf.Executor.Exec("actionAndOutput", "git", "config", "--show-scope", "--null", "--get-regexp", `^helper\.`)
+	if err != nil {
+		return []Layer{}
+	}
+
+	layers := []Layer{}
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		scope := fields[i]
+		name, value, found := strings.Cut(fields[i+1], "\n")
+		if !found {
+			continue
+		}
+
+		key, entryName, _ := strings.Cut(strings.TrimPrefix(name, "helper."), ".")
+		key = strings.ReplaceAll(key, "-", "_")
+		if element, field, ok := strings.Cut(entryName, "."); ok {
+			entryName = element + "." + strings.ReplaceAll(field, "-", "_")
+		}
+		if entryName != "" {
+			key = key + "." + entryName
+		}
+
+		config := &Config{}
+		if config.Set(key, value) != nil {
+			continue
+		}
+
+		repository := scope == "local" || scope == "worktree"
+		layers = append(layers, Layer{Config: config, Origin: "gitconfig:" + name, Repository: repository})
 	}
 
-	// Convert to map[string]string, skipping non-string values
-	result := make(map[string]string)
-	for key, value := range rawResult {
-		if strValue, ok := value.(string); ok {
-			result[key] = strValue
+	return layers
+}
+
+// envLayers reads environment variables like GIT_HELPER_GITHUB_USERNAME or
+// GIT_HELPER_SPECIAL_CAPITALIZATION_API, with one layer per variable.
+func envLayers(environ []string) ([]Layer, error) {
+	layers := []Layer{}
+	sort.Strings(environ)
+
+	for _, env := range environ {
+		name, value, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+
+		key := envKey(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)))
+		if key == "" {
+			continue
+		}
+
+		config := &Config{}
+		err := config.Set(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("env:%s: %s", name, err)
 		}
+
+		layers = append(layers, Layer{Config: config, Origin: "env:" + name})
+	}
+
+	return layers, nil
+}
+
+func envKey(name string) string {
+	t := reflect.TypeOf(Config{})
+
+	for i := 0; i < t.NumField(); i++ {
+		key := yamlName(t.Field(i))
+
+		if t.Field(i).Type.Kind() == reflect.Map {
+			if entry, ok := strings.CutPrefix(name, key+"_"); ok && entry != "" {
+				return key + "." + entry
+			}
+		} else if isStructList(reflect.New(t.Field(i).Type).Elem()) {
+			if entry, ok := strings.CutPrefix(name, key+"_"); ok {
+				if listKey := envListKey(key, entry, t.Field(i).Type.Elem()); listKey != "" {
+					return listKey
+				}
+			}
+		} else if name == key {
+			return key
+		}
+	}
+
+	return ""
+}
+
+// envListKey splits a variable like GIT_HELPER_ACCOUNTS_WORK_TOKEN_COMMAND
+// into the element's name and the longest field it ends with.
+func envListKey(key, entry string, t reflect.Type) string {
+	field := ""
+	for i := 1; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if strings.HasSuffix(entry, "_"+name) && len(name) > len(field) {
+			field = name
+		}
+	}
+
+	element := strings.TrimSuffix(entry, "_"+field)
+	if field == "" || element == "" {
+		return ""
+	}
+
+	return key + "." + element + "." + field
+}
+
+func readConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+
+		return nil, errors.New("error reading file " + path + ": " + err.Error())
+	}
+
+	migrated, changed, err := migrateLegacyKeys(data)
+	if err != nil {
+		return nil, errors.New("error unmarshaling YAML in " + path + ": " + err.Error())
+	}
+
+	if changed {
+		// Keep the file's mode, since the global config file may hold tokens
+		// and is only readable by its owner.
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.New("error migrating legacy keys: " + err.Error())
+		}
+
+		err = os.WriteFile(path, migrated, info.Mode().Perm())
+		if err != nil {
+			return nil, errors.New("error migrating legacy keys: " + err.Error())
+		}
+
+		fmt.Fprintf(os.Stderr, "Migrated legacy keys in %s to their new names\n", path)
+		data = migrated
+	}
+
+	return decodeConfig(path, data)
+}
+
+func decodeConfig(path string, data []byte) (*Config, error) {
+	config := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err := decoder.Decode(config)
+	if err != nil && err != io.EOF {
+		return nil, errors.New("error unmarshaling YAML in " + path + ": " + err.Error())
+	}
+
+	return config, nil
+}
+
+// migrateLegacyKeys renames any legacy keys in the top-level mapping, keeping
+// comments and key order. When both the legacy and the new key are present,
+// the new key wins.
+func migrateLegacyKeys(data []byte) ([]byte, bool, error) {
+	var document yaml.Node
+	err := yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return data, false, nil
+	}
+
+	mapping := document.Content[0]
+	present := map[string]bool{}
+	for i := 0; i < len(mapping.Content); i += 2 {
+		present[mapping.Content[i].Value] = true
+	}
+
+	changed := false
+	content := []*yaml.Node{}
+	for i := 0; i < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+
+		if newKey, ok := legacyKeys[key.Value]; ok {
+			changed = true
+			if present[newKey] {
+				continue
+			}
+
+			key.Value = newKey
+			present[newKey] = true
+		}
+
+		content = append(content, key, value)
+	}
+
+	if !changed {
+		return data, false, nil
+	}
+
+	mapping.Content = content
+	migrated, err := encodeYAML(&document)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return migrated, true, nil
+}
+
+func encodeYAML(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	err := encoder.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
 	}
 
-	return result
+	return buf.Bytes(), nil
 }
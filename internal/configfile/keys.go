@@ -0,0 +1,343 @@
+package configfile
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Keys returns every top-level config key in the order they're written to the
+// config file.
+func Keys() []string {
+	keys := []string{}
+	t := reflect.TypeOf(Config{})
+
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, yamlName(t.Field(i)))
+	}
+
+	return keys
+}
+
+// IsSecret reports whether the value at key should be masked when displayed.
+func IsSecret(key string) bool {
+	return strings.HasSuffix(key, "_token") || strings.HasSuffix(key, ".token")
+}
+
+// IsGlobalOnly reports whether the value at key can run commands or decide
+// where tokens are sent. Those keys are only read from the global config file,
+// global git config and the environment, since a repository's .git-helper.yml
+// and local git config come from whoever wrote the repository.
+func IsGlobalOnly(key string) bool {
+	name, _ := splitKey(key)
+	switch name {
+	case "accounts", "update_public_key":
+		return true
+	}
+
+	return strings.HasSuffix(name, "_token") || strings.HasSuffix(name, "_token_command") || strings.HasSuffix(name, "_oauth_client_id")
+}
+
+// Get returns the value at key, where map entries are addressed with a dot,
+// e.g. "special_capitalization.api", and accounts by name and field, e.g.
+// "accounts.work.host". Lists are joined with commas.
+func (c *Config) Get(key string) (string, bool) {
+	name, entry := splitKey(key)
+	f, ok := c.field(name)
+	if !ok {
+		return "", false
+	}
+
+	if isStructList(f) {
+		element, field := splitElementKey(f, entry)
+		item, ok := findElement(f, element)
+		if !ok {
+			return "", false
+		}
+
+		v, ok := structField(item, field)
+		if !ok || v.IsZero() {
+			return "", false
+		}
+
+		return formatValue(v), true
+	}
+
+	if f.Kind() == reflect.Map {
+		if entry == "" || f.IsNil() {
+			return "", false
+		}
+
+		value := f.MapIndex(reflect.ValueOf(entry))
+		if !value.IsValid() {
+			return "", false
+		}
+
+		return value.String(), true
+	}
+
+	if entry != "" || f.IsZero() {
+		return "", false
+	}
+
+	return formatValue(f), true
+}
+
+func (c *Config) Set(key, value string) error {
+	name, entry := splitKey(key)
+	f, ok := c.field(name)
+	if !ok {
+		return unknownKeyError(key)
+	}
+
+	if f.Kind() == reflect.Map {
+		if entry == "" {
+			return fmt.Errorf("%s is a map, set one of its entries like %s.<name>", name, name)
+		}
+
+		if f.IsNil() {
+			f.Set(reflect.MakeMap(f.Type()))
+		}
+
+		f.SetMapIndex(reflect.ValueOf(entry), reflect.ValueOf(value))
+		return nil
+	}
+
+	if isStructList(f) {
+		element, field := splitElementKey(f, entry)
+		if element == "" || field == "" {
+			return fmt.Errorf("%s is a list, set one of its fields like %s.<name>.<field>", name, name)
+		}
+
+		item, ok := findElement(f, element)
+		if !ok {
+			f.Set(reflect.Append(f, reflect.New(f.Type().Elem()).Elem()))
+			item = f.Index(f.Len() - 1)
+			item.Field(0).SetString(element)
+		}
+
+		v, ok := structField(item, field)
+		if !ok || field == "name" {
+			return unknownKeyError(key)
+		}
+
+		return setValue(v, key, value)
+	}
+
+	if entry != "" {
+		return unknownKeyError(key)
+	}
+
+	return setValue(f, key, value)
+}
+
+func setValue(f reflect.Value, key, value string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", key)
+		}
+		f.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number", key)
+		}
+		f.SetInt(int64(i))
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.Set(reflect.ValueOf(items))
+	default:
+		return errors.New("cannot set " + key + " from the command-line")
+	}
+
+	return nil
+}
+
+func (c *Config) Unset(key string) error {
+	name, entry := splitKey(key)
+	f, ok := c.field(name)
+	if !ok {
+		return unknownKeyError(key)
+	}
+
+	if f.Kind() == reflect.Map && entry != "" {
+		if !f.IsNil() {
+			f.SetMapIndex(reflect.ValueOf(entry), reflect.Value{})
+		}
+		return nil
+	}
+
+	if isStructList(f) && entry != "" {
+		element, field := splitElementKey(f, entry)
+		item, ok := findElement(f, element)
+		if !ok {
+			return nil
+		}
+
+		if field == "" {
+			remaining := reflect.MakeSlice(f.Type(), 0, f.Len()-1)
+			for i := 0; i < f.Len(); i++ {
+				if f.Index(i).Field(0).String() != element {
+					remaining = reflect.Append(remaining, f.Index(i))
+				}
+			}
+			f.Set(remaining)
+			return nil
+		}
+
+		v, ok := structField(item, field)
+		if !ok || field == "name" {
+			return unknownKeyError(key)
+		}
+
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	if entry != "" {
+		return unknownKeyError(key)
+	}
+
+	f.Set(reflect.Zero(f.Type()))
+	return nil
+}
+
+// Values flattens every value that's set into a map of key to value, using the
+// same keys that Get and Set accept.
+func (c *Config) Values() map[string]string {
+	values := map[string]string{}
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		f := v.Field(i)
+
+		if f.IsZero() {
+			continue
+		}
+
+		if f.Kind() == reflect.Map {
+			iter := f.MapRange()
+			for iter.Next() {
+				values[name+"."+iter.Key().String()] = iter.Value().String()
+			}
+			continue
+		}
+
+		if isStructList(f) {
+			for j := 0; j < f.Len(); j++ {
+				item := f.Index(j)
+				for k := 1; k < item.NumField(); k++ {
+					if !item.Field(k).IsZero() {
+						values[name+"."+item.Field(0).String()+"."+yamlName(item.Type().Field(k))] = formatValue(item.Field(k))
+					}
+				}
+			}
+			continue
+		}
+
+		values[name] = formatValue(f)
+	}
+
+	return values
+}
+
+func (c *Config) field(name string) (reflect.Value, bool) {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if yamlName(t.Field(i)) == name {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// isStructList reports whether f is a list like accounts, whose elements are
+// structs addressed by their first field, the name.
+func isStructList(f reflect.Value) bool {
+	return f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Struct
+}
+
+// splitElementKey splits an entry like "work.token" into the element's name
+// and field. Names may contain dots, like hosts do, but field names never do.
+func splitElementKey(f reflect.Value, entry string) (string, string) {
+	if _, ok := findElement(f, entry); ok {
+		return entry, ""
+	}
+
+	i := strings.LastIndex(entry, ".")
+	if i < 0 {
+		return entry, ""
+	}
+
+	return entry[:i], entry[i+1:]
+}
+
+func findElement(f reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < f.Len(); i++ {
+		if f.Index(i).Field(0).String() == name {
+			return f.Index(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func structField(item reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < item.NumField(); i++ {
+		if yamlName(item.Type().Field(i)) == name {
+			return item.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func formatValue(f reflect.Value) string {
+	switch f.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(f.Bool())
+	case reflect.Int:
+		return strconv.Itoa(int(f.Int()))
+	case reflect.Slice:
+		items := []string{}
+		for i := 0; i < f.Len(); i++ {
+			items = append(items, f.Index(i).String())
+		}
+		return strings.Join(items, ",")
+	default:
+		return f.String()
+	}
+}
+
+func splitKey(key string) (string, string) {
+	parts := strings.SplitN(key, ".", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+func yamlName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("yaml"), ",")[0]
+}
+
+func unknownKeyError(key string) error {
+	return fmt.Errorf("unknown config key %q, expected one of: %s", key, strings.Join(Keys(), ", "))
+}
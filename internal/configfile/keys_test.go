@@ -0,0 +1,141 @@
+package configfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Keys(t *testing.T) {
+	expected := []string{
+		"github_username",
+		"github_token",
+		"github_token_command",
+		"github_refresh_token",
+		"github_oauth_client_id",
+		"gitlab_username",
+		"gitlab_token",
+		"gitlab_token_command",
+		"gitlab_refresh_token",
+		"gitlab_oauth_client_id",
+		"special_capitalization",
+		"accounts",
+		"account",
+		"update_public_key",
+		"disable_update_notifier",
+		"sync_strategy",
+		"reviewers",
+	}
+
+	if !reflect.DeepEqual(Keys(), expected) {
+		t.Errorf("expected %v, got %v", expected, Keys())
+	}
+}
+
+func Test_GetSetUnset(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{key: "github_username", value: "testuser"},
+		{key: "gitlab_token", value: "glpat-token123"},
+		{key: "special_capitalization.api", value: "API"},
+		{key: "accounts.work.host", value: "github.example.com"},
+		{key: "accounts.work.owners", value: "acme,acme-*"},
+		{key: "account", value: "work"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			config := &Config{}
+
+			if _, ok := config.Get(test.key); ok {
+				t.Errorf("expected %s to be unset", test.key)
+			}
+
+			if err := config.Set(test.key, test.value); err != nil {
+				t.Fatal(err)
+			}
+
+			if value, ok := config.Get(test.key); !ok || value != test.value {
+				t.Errorf("expected '%s', got '%s'", test.value, value)
+			}
+
+			if err := config.Unset(test.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := config.Get(test.key); ok {
+				t.Errorf("expected %s to be unset", test.key)
+			}
+		})
+	}
+}
+
+func Test_Set_Errors(t *testing.T) {
+	config := &Config{}
+
+	for _, key := range []string{"github_user", "special_capitalization", "github_username.extra", "accounts", "accounts.work", "accounts.work.name", "accounts.work.bogus"} {
+		if err := config.Set(key, "value"); err == nil {
+			t.Errorf("expected an error setting %s", key)
+		}
+	}
+}
+
+func Test_Values(t *testing.T) {
+	config := &Config{
+		GitHubUsername:        "testuser",
+		SpecialCapitalization: map[string]string{"api": "API"},
+		Accounts:              []Account{{Name: "work", Host: "github.example.com", Owners: []string{"acme", "acme-*"}}},
+	}
+
+	expected := map[string]string{
+		"github_username":            "testuser",
+		"special_capitalization.api": "API",
+		"accounts.work.host":         "github.example.com",
+		"accounts.work.owners":       "acme,acme-*",
+	}
+
+	if !reflect.DeepEqual(config.Values(), expected) {
+		t.Errorf("expected %v, got %v", expected, config.Values())
+	}
+}
+
+func Test_IsSecret(t *testing.T) {
+	for _, key := range []string{"github_token", "gitlab_refresh_token", "accounts.work.token", "accounts.work.refresh_token"} {
+		if !IsSecret(key) {
+			t.Errorf("expected %s to be secret", key)
+		}
+	}
+
+	for _, key := range []string{"github_username", "github_token_command", "accounts.work.token_command"} {
+		if IsSecret(key) {
+			t.Errorf("expected %s not to be secret", key)
+		}
+	}
+}
+
+func Test_IsGlobalOnly(t *testing.T) {
+	for _, key := range []string{"github_token", "gitlab_token_command", "github_refresh_token", "github_oauth_client_id", "accounts", "accounts.work.host", "accounts.work.token_command", "update_public_key"} {
+		if !IsGlobalOnly(key) {
+			t.Errorf("expected %s to be global-only", key)
+		}
+	}
+
+	for _, key := range []string{"account", "github_username", "special_capitalization.api", "sync_strategy", "reviewers"} {
+		if IsGlobalOnly(key) {
+			t.Errorf("expected %s not to be global-only", key)
+		}
+	}
+}
+
+func Test_Unset_Account(t *testing.T) {
+	config := &Config{Accounts: []Account{{Name: "personal", Host: "github.com"}, {Name: "work", Host: "github.example.com"}}}
+
+	if err := config.Unset("accounts.personal"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.Accounts) != 1 || config.Accounts[0].Name != "work" {
+		t.Errorf("expected only the work account to remain, got %+v", config.Accounts)
+	}
+}
@@ -0,0 +1,520 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+)
+
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+
+	// helperUsername is the username credentials are stored under in git's
+	// credential helper, so they're never mixed up with ones git or other
+	// tools stored for the same host. Accounts add their name to it, so each
+	// account on a host has its own entry.
+	helperUsername = "git-helper"
+)
+
+// Source is one place a forge token can come from. Token returns an empty
+// string when the source has nothing for the host.
+type Source interface {
+	Name() string
+	Token(forge, host string) (string, error)
+}
+
+type Credentials struct {
+	Config   configfile.ConfigFileInterface
+	Debug    bool
+	Executor executor.ExecutorInterface
+	Sources  []Source
+}
+
+// Credential is a token to be stored for a host, along with what's needed to
+// refresh it.
+type Credential struct {
+	ExpiresAt    *time.Time
+	Host         string
+	RefreshToken string
+	Token        string
+	Username     string
+}
+
+// GitCredential runs `git credential <action>` with the given input on stdin,
+// and never prompts on the terminal. It's a variable so tests can replace it.
+var GitCredential = func(action, input string) (string, error) {
+	cmd := exec.Command("git", "credential", action)
+	cmd.Dir = executor.WorkingDir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdin = strings.NewReader(input)
+
+	output, err := cmd.Output()
+	return string(output), err
+}
+
+// NewCredentials looks for tokens in a matching account first. Without one, it
+// looks in environment variables, then a configured token command, then git's
+// credential helpers, and finally the config file.
+func NewCredentials(debug bool, config configfile.ConfigFileInterface, executor executor.ExecutorInterface) *Credentials {
+	return &Credentials{
+		Config:   config,
+		Debug:    debug,
+		Executor: executor,
+		Sources: []Source{
+			&EnvSource{},
+			&CommandSource{Config: config, Executor: executor},
+			&GitCredentialSource{},
+			&ConfigFileSource{Config: config},
+		},
+	}
+}
+
+// DefaultHost returns the forge's public host, which the github_* and gitlab_*
+// config keys are for.
+func DefaultHost(forge string) string {
+	if forge == GitLab {
+		return "gitlab.com"
+	}
+
+	return "github.com"
+}
+
+// Token returns the token for a repository on host owned by owner, along with
+// the name of the source it came from. When an account is configured for the
+// host and owner, only that account is used.
+func (c *Credentials) Token(forge, host, owner string) (string, string, error) {
+	account, err := c.Account(forge, host, owner)
+	if err != nil {
+		return "", "", err
+	}
+
+	if account != nil {
+		return c.AccountToken(account)
+	}
+
+	return c.HostToken(forge, host)
+}
+
+// Account returns the configured account to use for the host and owner, or
+// nil when there isn't one.
+func (c *Credentials) Account(forge, host, owner string) (*configfile.Account, error) {
+	config, err := c.Config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.FindAccount(forge, host, owner)
+}
+
+// AccountToken returns the account's token, from its token key, its token
+// command, or git's credential helper: the entry Store saved for the account
+// first, then any entry for its username.
+func (c *Credentials) AccountToken(account *configfile.Account) (string, string, error) {
+	source := "account " + account.Name
+	if account.Token != "" {
+		return account.Token, source, nil
+	}
+
+	if account.TokenCommand != "" {
+		token, err := runTokenCommand(c.Executor, account.TokenCommand)
+		if err != nil {
+			return "", "", fmt.Errorf("accounts.%s.token_command failed: %s", account.Name, err)
+		}
+
+		if token != "" {
+			return token, source + " (token command)", nil
+		}
+	}
+
+	input := credentialInput(account.Host)
+	if account.Username != "" {
+		input += "username=" + account.Username + "\n"
+	}
+
+	for _, input := range []string{helperInput(account.Host, helperKey(account)), input} {
+		output, err := GitCredential("fill", input+"\n")
+		if err != nil && c.Debug {
+			fmt.Fprintf(os.Stderr, "could not read account %s token from git credential helper: %s\n", account.Name, err)
+		}
+
+		if token := credentialValue(output, "password"); err == nil && token != "" {
+			return token, source + " (git credential helper)", nil
+		}
+	}
+
+	return "", "", fmt.Errorf("no token found for account %s, please set accounts.%s.token_command or run git-helper auth login --%s --host %s", account.Name, account.Name, account.ForgeName(), account.Host)
+}
+
+// HostToken returns the first token found in the sources for the forge and
+// host, ignoring accounts, along with the name of the source it came from.
+func (c *Credentials) HostToken(forge, host string) (string, string, error) {
+	for _, source := range c.Sources {
+		token, err := source.Token(forge, host)
+		if err != nil {
+			if c.Debug {
+				fmt.Fprintf(os.Stderr, "could not read %s token from %s: %s\n", forge, source.Name(), err)
+			}
+			continue
+		}
+
+		if token != "" {
+			return token, source.Name(), nil
+		}
+	}
+
+	if host != DefaultHost(forge) {
+		return "", "", fmt.Errorf("no %s token found for %s, please run git-helper auth login --%s --host %s", forgeName(forge), host, forge, host)
+	}
+
+	return "", "", fmt.Errorf("no %s token found for %s, please run git-helper setup or set %s", forgeName(forge), host, envName(forge))
+}
+
+// Store saves the credential with git's credential helper when one is
+// configured, and otherwise in the global config file. Either way, the
+// account it belongs to is created or updated in the global config file. It
+// returns where the credential was stored.
+func (c *Credentials) Store(forge string, credential Credential) (string, error) {
+	config, err := c.Config.LoadFile(c.Config.ConfigFile())
+	if err != nil {
+		return "", err
+	}
+
+	helper := c.hasCredentialHelper()
+	values := map[string]string{
+		forge + "_username":      credential.Username,
+		forge + "_token":         credential.Token,
+		forge + "_refresh_token": credential.RefreshToken,
+	}
+	if helper {
+		values = map[string]string{forge + "_username": credential.Username}
+	}
+
+	account := storedAccount(config, forge, credential)
+	if account != nil {
+		account.Username = credential.Username
+		if !helper {
+			account.Token = credential.Token
+			account.RefreshToken = credential.RefreshToken
+		}
+	} else {
+		for key, value := range values {
+			if value == "" {
+				err = config.Unset(key)
+			} else {
+				err = config.Set(key, value)
+			}
+
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	err = c.Config.Save(config)
+	if err != nil {
+		return "", err
+	}
+
+	if !helper {
+		return c.Config.ConfigFile(), nil
+	}
+
+	input := helperInput(credential.Host, helperKey(account)) + "password=" + credential.Token + "\n"
+	if credential.ExpiresAt != nil {
+		input += "password_expiry_utc=" + strconv.FormatInt(credential.ExpiresAt.Unix(), 10) + "\n"
+	}
+	if credential.RefreshToken != "" {
+		input += "oauth_refresh_token=" + credential.RefreshToken + "\n"
+	}
+
+	_, err = GitCredential("approve", input+"\n")
+	if err != nil {
+		return "", errors.New("could not store credential with git credential helper: " + err.Error())
+	}
+
+	return (&GitCredentialSource{}).Name(), nil
+}
+
+// Erase removes the host's credential from git's credential helper and the
+// global config file. Only the credential Store saved is removed from the
+// credential helper, never ones stored by git or other tools.
+func (c *Credentials) Erase(forge, host string) error {
+	config, err := c.Config.LoadFile(c.Config.ConfigFile())
+	if err != nil {
+		return err
+	}
+
+	if c.hasCredentialHelper() {
+		for _, key := range helperKeys(config, forge, host) {
+			_, err := GitCredential("reject", helperInput(host, key)+"\n")
+			if err != nil {
+				return errors.New("could not erase credential from git credential helper: " + err.Error())
+			}
+		}
+	}
+
+	changed := false
+	for i := range config.Accounts {
+		account := &config.Accounts[i]
+		if matchesHost(account, forge, host) && (account.Token != "" || account.RefreshToken != "") {
+			account.Token = ""
+			account.RefreshToken = ""
+			changed = true
+		}
+	}
+
+	if _, ok := config.Get(forge + "_token"); ok && host == DefaultHost(forge) {
+		for _, key := range []string{forge + "_token", forge + "_refresh_token"} {
+			err = config.Unset(key)
+			if err != nil {
+				return err
+			}
+		}
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return c.Config.Save(config)
+}
+
+// RefreshToken returns the OAuth refresh token stored for the host, if any.
+func (c *Credentials) RefreshToken(forge, host string) string {
+	config, err := c.Config.Load()
+	if err != nil {
+		return ""
+	}
+
+	if c.hasCredentialHelper() {
+		for _, key := range helperKeys(config, forge, host) {
+			output, err := GitCredential("fill", helperInput(host, key)+"\n")
+			if err != nil {
+				continue
+			}
+
+			if refreshToken := credentialValue(output, "oauth_refresh_token"); refreshToken != "" {
+				return refreshToken
+			}
+		}
+	}
+
+	for _, account := range config.Accounts {
+		if matchesHost(&account, forge, host) && account.RefreshToken != "" {
+			return account.RefreshToken
+		}
+	}
+
+	if host != DefaultHost(forge) {
+		return ""
+	}
+
+	refreshToken, _ := config.Get(forge + "_refresh_token")
+	return refreshToken
+}
+
+func (c *Credentials) hasCredentialHelper() bool {
+	output, err := c.Executor.Exec("actionAndOutput", "git", "config", "--get-all", "credential.helper")
+	return err == nil && strings.TrimSpace(string(output)) != ""
+}
+
+type EnvSource struct{}
+
+func (s *EnvSource) Name() string {
+	return "environment"
+}
+
+func (s *EnvSource) Token(forge, host string) (string, error) {
+	if host != DefaultHost(forge) {
+		return "", nil
+	}
+
+	return strings.TrimSpace(os.Getenv(envName(forge))), nil
+}
+
+type CommandSource struct {
+	Config   configfile.ConfigFileInterface
+	Executor executor.ExecutorInterface
+}
+
+func (s *CommandSource) Name() string {
+	return "token command"
+}
+
+func (s *CommandSource) Token(forge, host string) (string, error) {
+	if host != DefaultHost(forge) {
+		return "", nil
+	}
+
+	config, err := s.Config.Load()
+	if err != nil {
+		return "", err
+	}
+
+	command, _ := config.Get(forge + "_token_command")
+	if command == "" {
+		return "", nil
+	}
+
+	token, err := runTokenCommand(s.Executor, command)
+	if err != nil {
+		return "", errors.New(forge + "_token_command failed: " + err.Error())
+	}
+
+	return token, nil
+}
+
+type GitCredentialSource struct{}
+
+func (s *GitCredentialSource) Name() string {
+	return "git credential helper"
+}
+
+// Token prefers the credential Store saved, then falls back to whatever the
+// credential helper has for the host.
+func (s *GitCredentialSource) Token(forge, host string) (string, error) {
+	output, err := GitCredential("fill", helperInput(host, helperUsername)+"\n")
+	if token := credentialValue(output, "password"); err == nil && token != "" {
+		return token, nil
+	}
+
+	output, err = GitCredential("fill", credentialInput(host)+"\n")
+	if err != nil {
+		return "", err
+	}
+
+	return credentialValue(output, "password"), nil
+}
+
+type ConfigFileSource struct {
+	Config configfile.ConfigFileInterface
+}
+
+func (s *ConfigFileSource) Name() string {
+	return "config file"
+}
+
+func (s *ConfigFileSource) Token(forge, host string) (string, error) {
+	if host != DefaultHost(forge) {
+		return "", nil
+	}
+
+	config, err := s.Config.Load()
+	if err != nil {
+		return "", err
+	}
+
+	token, _ := config.Get(forge + "_token")
+	return token, nil
+}
+
+func runTokenCommand(e executor.ExecutorInterface, command string) (string, error) {
+	output, err := e.Exec("captureStdout", "sh", "-c", command)
+	if err != nil {
+		return "", err
+	}
+
+	// Only the first line is used, so tools like pass can keep metadata below
+	// the secret
+	return strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0]), nil
+}
+
+// storedAccount returns the account a new credential belongs to: the host's
+// account for the same user or without a user yet. Hosts other than the
+// forge's public one get a new account, since the github_* and gitlab_* keys
+// only apply to github.com and gitlab.com.
+func storedAccount(config *configfile.Config, forge string, credential Credential) *configfile.Account {
+	for i := range config.Accounts {
+		account := &config.Accounts[i]
+		if matchesHost(account, forge, credential.Host) && (account.Username == "" || account.Username == credential.Username) {
+			return account
+		}
+	}
+
+	if credential.Host == DefaultHost(forge) {
+		return nil
+	}
+
+	name := strings.ReplaceAll(credential.Host, ".", "-")
+	for _, account := range config.Accounts {
+		if account.Name == name {
+			name += "-" + credential.Username
+			break
+		}
+	}
+
+	config.Accounts = append(config.Accounts, configfile.Account{Name: name, Forge: forge, Host: credential.Host})
+	return &config.Accounts[len(config.Accounts)-1]
+}
+
+func matchesHost(account *configfile.Account, forge, host string) bool {
+	return strings.EqualFold(account.Host, host) && account.ForgeName() == forge
+}
+
+func credentialInput(host string) string {
+	return "protocol=https\nhost=" + host + "\n"
+}
+
+// helperInput is credentialInput for a credential git-helper stores under key.
+func helperInput(host, key string) string {
+	return credentialInput(host) + "username=" + key + "\n"
+}
+
+// helperKey returns the username an account's credential is stored under in
+// git's credential helper, or the one for the github_* and gitlab_* keys when
+// there's no account.
+func helperKey(account *configfile.Account) string {
+	if account == nil {
+		return helperUsername
+	}
+
+	return helperUsername + "/" + account.Name
+}
+
+// helperKeys returns the keys of every credential git-helper may have stored
+// for the host.
+func helperKeys(config *configfile.Config, forge, host string) []string {
+	var keys []string
+	for i := range config.Accounts {
+		if matchesHost(&config.Accounts[i], forge, host) {
+			keys = append(keys, helperKey(&config.Accounts[i]))
+		}
+	}
+
+	if host == DefaultHost(forge) {
+		keys = append(keys, helperUsername)
+	}
+
+	return keys
+}
+
+func credentialValue(output, key string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if value, ok := strings.CutPrefix(line, key+"="); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return ""
+}
+
+func envName(forge string) string {
+	return strings.ToUpper(forge) + "_TOKEN"
+}
+
+func forgeName(forge string) string {
+	if forge == GitLab {
+		return "GitLab"
+	}
+
+	return "GitHub"
+}
@@ -0,0 +1,384 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+)
+
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Output  []byte
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	return me.Output, nil
+}
+
+func setupTestConfig(t *testing.T, content string) *configfile.ConfigFile {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	os.MkdirAll(filepath.Join(tempDir, ".git-helper"), 0700)
+
+	err := os.WriteFile(filepath.Join(tempDir, ".git-helper", "config.yml"), []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cf := configfile.NewConfigFile(true)
+	cf.Executor = &MockExecutor{Debug: true}
+	return cf
+}
+
+func stubGitCredential(t *testing.T, output string, err error) *string {
+	var input string
+	original := GitCredential
+	t.Cleanup(func() {
+		GitCredential = original
+	})
+	GitCredential = func(action, in string) (string, error) {
+		input = action + "\n" + in
+		return output, err
+	}
+
+	return &input
+}
+
+func Test_Token(t *testing.T) {
+	tests := []struct {
+		name           string
+		env            string
+		commandOutput  string
+		credential     string
+		credentialErr  error
+		config         string
+		expectedToken  string
+		expectedSource string
+	}{
+		{
+			name:           "environment wins",
+			env:            "env_token",
+			commandOutput:  "command_token\n",
+			credential:     "password=credential_token\n",
+			config:         "github_token: config_token\ngithub_token_command: pass show github\n",
+			expectedToken:  "env_token",
+			expectedSource: "environment",
+		},
+		{
+			name:           "token command",
+			commandOutput:  "command_token\nlogin: octocat\n",
+			credential:     "password=credential_token\n",
+			config:         "github_token: config_token\ngithub_token_command: pass show github\n",
+			expectedToken:  "command_token",
+			expectedSource: "token command",
+		},
+		{
+			name:           "git credential helper",
+			credential:     "protocol=https\nhost=github.com\nusername=octocat\npassword=credential_token\n",
+			config:         "github_token: config_token\n",
+			expectedToken:  "credential_token",
+			expectedSource: "git credential helper",
+		},
+		{
+			name:           "config file fallback",
+			credentialErr:  errors.New("terminal prompts disabled"),
+			config:         "github_token: config_token\n",
+			expectedToken:  "config_token",
+			expectedSource: "config file",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", test.env)
+			cf := setupTestConfig(t, test.config)
+			input := stubGitCredential(t, test.credential, test.credentialErr)
+			executor := &MockExecutor{Debug: true, Output: []byte(test.commandOutput)}
+
+			token, source, err := NewCredentials(true, cf, executor).Token(GitHub, "github.com", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if token != test.expectedToken || source != test.expectedSource {
+				t.Errorf("expected %s from %s, got %s from %s", test.expectedToken, test.expectedSource, token, source)
+			}
+
+			if test.expectedSource == "token command" && (executor.Command != "sh" || executor.Args[1] != "pass show github") {
+				t.Errorf("unexpected command received: %s %v", executor.Command, executor.Args)
+			}
+
+			if test.expectedSource == "git credential helper" && *input != "fill\nprotocol=https\nhost=github.com\nusername=git-helper\n\n" {
+				t.Errorf("unexpected git credential input: %q", *input)
+			}
+		})
+	}
+}
+
+func Test_GitCredentialSource_FallsBackToOtherCredentials(t *testing.T) {
+	original := GitCredential
+	t.Cleanup(func() {
+		GitCredential = original
+	})
+
+	inputs := []string{}
+	GitCredential = func(action, input string) (string, error) {
+		inputs = append(inputs, input)
+		if strings.Contains(input, "username=git-helper") {
+			return "", errors.New("terminal prompts disabled")
+		}
+		return "username=octocat\npassword=gh_token\n", nil
+	}
+
+	token, err := (&GitCredentialSource{}).Token(GitHub, "github.com")
+	if err != nil || token != "gh_token" {
+		t.Errorf("expected the host's other credential, got '%s' (%v)", token, err)
+	}
+
+	expected := []string{"protocol=https\nhost=github.com\nusername=git-helper\n\n", "protocol=https\nhost=github.com\n\n"}
+	if !reflect.DeepEqual(inputs, expected) {
+		t.Errorf("expected %q, got %q", expected, inputs)
+	}
+}
+
+func Test_Token_NotFound(t *testing.T) {
+	t.Setenv("GITLAB_TOKEN", "")
+	cf := setupTestConfig(t, "")
+	stubGitCredential(t, "", errors.New("terminal prompts disabled"))
+
+	_, _, err := NewCredentials(true, cf, &MockExecutor{Debug: true}).Token(GitLab, "gitlab.com", "")
+	expected := "no GitLab token found for gitlab.com, please run git-helper setup or set GITLAB_TOKEN"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected '%s' error, got '%v'", expected, err)
+	}
+}
+
+func Test_Store_ConfigFile(t *testing.T) {
+	cf := setupTestConfig(t, "github_username: old\ngitlab_token: keep\n")
+	stubGitCredential(t, "", errors.New("should not be called"))
+
+	c := NewCredentials(true, cf, &MockExecutor{Debug: true})
+	location, err := c.Store(GitHub, Credential{Host: "github.com", RefreshToken: "refresh123", Token: "token123", Username: "octocat"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if location != cf.ConfigFile() {
+		t.Errorf("expected the credential to be stored in %s, got %s", cf.ConfigFile(), location)
+	}
+
+	config, _ := cf.LoadFile(cf.ConfigFile())
+	if config.GitHubUsername != "octocat" || config.GitHubToken != "token123" || config.GitHubRefreshToken != "refresh123" || config.GitLabToken != "keep" {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	if c.RefreshToken(GitHub, "github.com") != "refresh123" {
+		t.Errorf("expected the refresh token to be read back")
+	}
+
+	err = c.Erase(GitHub, "github.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config, _ = cf.LoadFile(cf.ConfigFile())
+	if config.GitHubToken != "" || config.GitHubRefreshToken != "" || config.GitHubUsername != "octocat" {
+		t.Errorf("expected the tokens to be erased and the username kept, got %+v", config)
+	}
+}
+
+func Test_Store_GitCredentialHelper(t *testing.T) {
+	cf := setupTestConfig(t, "")
+	input := stubGitCredential(t, "", nil)
+	expiresAt := time.Unix(1800000000, 0)
+
+	c := NewCredentials(true, cf, &MockExecutor{Debug: true, Output: []byte("osxkeychain\n")})
+	location, err := c.Store(GitLab, Credential{ExpiresAt: &expiresAt, Host: "gitlab.com", RefreshToken: "refresh123", Token: "token123", Username: "tanuki"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if location != "git credential helper" {
+		t.Errorf("expected the credential to be stored in the git credential helper, got %s", location)
+	}
+
+	expected := "approve\nprotocol=https\nhost=gitlab.com\nusername=git-helper\npassword=token123\npassword_expiry_utc=1800000000\noauth_refresh_token=refresh123\n\n"
+	if *input != expected {
+		t.Errorf("expected input %q, got %q", expected, *input)
+	}
+
+	err = c.Erase(GitLab, "gitlab.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *input != "reject\nprotocol=https\nhost=gitlab.com\nusername=git-helper\n\n" {
+		t.Errorf("unexpected erase input %q", *input)
+	}
+}
+
+func Test_Token_Accounts(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "envtoken")
+	cf := setupTestConfig(t, `github_token: legacytoken
+accounts:
+  - name: personal
+    host: github.com
+    token: personaltoken
+  - name: work
+    host: github.com
+    owners: [acme]
+    token_command: pass show work
+  - name: enterprise
+    forge: github
+    host: git.example.com
+    username: jdoe
+`)
+	input := stubGitCredential(t, "username=jdoe\npassword=enterprisetoken\n", nil)
+
+	tests := []struct {
+		host           string
+		owner          string
+		expectedToken  string
+		expectedSource string
+	}{
+		{host: "github.com", owner: "emmahsax", expectedToken: "personaltoken", expectedSource: "account personal"},
+		{host: "github.com", owner: "acme", expectedToken: "worktoken", expectedSource: "account work (token command)"},
+		{host: "git.example.com", owner: "acme", expectedToken: "enterprisetoken", expectedSource: "account enterprise (git credential helper)"},
+	}
+
+	for _, test := range tests {
+		executor := &MockExecutor{Debug: true, Output: []byte("worktoken\n")}
+		token, source, err := NewCredentials(true, cf, executor).Token(GitHub, test.host, test.owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if token != test.expectedToken || source != test.expectedSource {
+			t.Errorf("expected %s from %s, got %s from %s", test.expectedToken, test.expectedSource, token, source)
+		}
+	}
+
+	if *input != "fill\nprotocol=https\nhost=git.example.com\nusername=git-helper/enterprise\n\n" {
+		t.Errorf("unexpected git credential input: %q", *input)
+	}
+}
+
+func Test_HostToken_LegacyKeysOnlyForDefaultHost(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	cf := setupTestConfig(t, "github_token: legacytoken\n")
+	stubGitCredential(t, "", errors.New("terminal prompts disabled"))
+
+	c := NewCredentials(true, cf, &MockExecutor{Debug: true})
+	if token, _, _ := c.HostToken(GitHub, "github.com"); token != "legacytoken" {
+		t.Errorf("expected the legacy token for github.com, got '%s'", token)
+	}
+
+	if token, _, err := c.HostToken(GitHub, "git.example.com"); err == nil {
+		t.Errorf("expected no token for an enterprise host, got '%s'", token)
+	}
+}
+
+func Test_HostToken_EnvOnlyForDefaultHost(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "envtoken")
+	cf := setupTestConfig(t, "")
+	stubGitCredential(t, "", errors.New("terminal prompts disabled"))
+
+	c := NewCredentials(true, cf, &MockExecutor{Debug: true})
+	if token, source, _ := c.HostToken(GitHub, "github.com"); token != "envtoken" || source != "environment" {
+		t.Errorf("expected GITHUB_TOKEN for github.com, got '%s' from %s", token, source)
+	}
+
+	if token, _, err := c.HostToken(GitHub, "github.example.com"); err == nil {
+		t.Errorf("expected GITHUB_TOKEN not to be sent to another host, got '%s'", token)
+	}
+}
+
+func Test_Store_EnterpriseAccount(t *testing.T) {
+	cf := setupTestConfig(t, "github_token: legacytoken\n")
+	stubGitCredential(t, "", errors.New("should not be called"))
+
+	c := NewCredentials(true, cf, &MockExecutor{Debug: true})
+	_, err := c.Store(GitHub, Credential{Host: "git.example.com", Token: "token123", Username: "jdoe"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config, _ := cf.LoadFile(cf.ConfigFile())
+	expected := configfile.Account{Name: "git-example-com", Forge: GitHub, Host: "git.example.com", Username: "jdoe", Token: "token123"}
+	if len(config.Accounts) != 1 || !reflect.DeepEqual(config.Accounts[0], expected) || config.GitHubToken != "legacytoken" {
+		t.Errorf("expected a new enterprise account, got %+v", config)
+	}
+
+	err = c.Erase(GitHub, "git.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config, _ = cf.LoadFile(cf.ConfigFile())
+	if config.Accounts[0].Token != "" || config.GitHubToken != "legacytoken" {
+		t.Errorf("expected only the enterprise token to be erased, got %+v", config)
+	}
+}
+
+func Test_Store_GitCredentialHelperAccounts(t *testing.T) {
+	cf := setupTestConfig(t, "")
+	stored := map[string]string{}
+	original := GitCredential
+	t.Cleanup(func() {
+		GitCredential = original
+	})
+	GitCredential = func(action, input string) (string, error) {
+		key := strings.SplitN(input, "password=", 2)[0]
+		switch action {
+		case "approve":
+			stored[key] = input
+		case "fill":
+			if output, ok := stored[strings.TrimSuffix(input, "\n")]; ok {
+				return output, nil
+			}
+			return "", errors.New("terminal prompts disabled")
+		}
+		return "", nil
+	}
+
+	c := NewCredentials(true, cf, &MockExecutor{Debug: true, Output: []byte("osxkeychain\n")})
+	for _, credential := range []Credential{
+		{Host: "git.example.com", RefreshToken: "jdoerefresh", Token: "jdoetoken", Username: "jdoe"},
+		{Host: "git.example.com", Token: "asmithtoken", Username: "asmith"},
+	} {
+		_, err := c.Store(GitHub, credential)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	config, _ := cf.LoadFile(cf.ConfigFile())
+	expected := []configfile.Account{
+		{Name: "git-example-com", Forge: GitHub, Host: "git.example.com", Username: "jdoe"},
+		{Name: "git-example-com-asmith", Forge: GitHub, Host: "git.example.com", Username: "asmith"},
+	}
+	if !reflect.DeepEqual(config.Accounts, expected) {
+		t.Fatalf("expected an account for each user without their tokens, got %+v", config.Accounts)
+	}
+
+	for i, token := range []string{"jdoetoken", "asmithtoken"} {
+		got, source, err := c.AccountToken(&config.Accounts[i])
+		if err != nil || got != token {
+			t.Errorf("expected %s for %s, got '%s' from %s (%v)", token, config.Accounts[i].Name, got, source, err)
+		}
+	}
+
+	if refreshToken := c.RefreshToken(GitHub, "git.example.com"); refreshToken != "jdoerefresh" {
+		t.Errorf("expected jdoe's refresh token, got '%s'", refreshToken)
+	}
+}
@@ -6,6 +6,11 @@ import (
 	"os/exec"
 )
 
+// WorkingDir is the directory every new Executor runs its commands in. It's
+// set from the root -C flag, and an empty value means the process's working
+// directory.
+var WorkingDir string
+
 type ExecutorInterface interface {
 	Exec(execType string, command string, args ...string) ([]byte, error)
 }
@@ -14,11 +19,13 @@ type Executor struct {
 	Args    []string
 	Command string
 	Debug   bool
+	Dir     string
 }
 
 func NewExecutor(debug bool) *Executor {
 	return &Executor{
 		Debug: debug,
+		Dir:   WorkingDir,
 	}
 }
 
@@ -28,17 +35,31 @@ func (e *Executor) Exec(execType string, command string, args ...string) ([]byte
 
 	switch execType {
 	case "actionAndOutput":
-		o, e := actionAndOutput(command, args)
-		return o, e
+		o, err := actionAndOutput(e.Dir, command, args)
+		return o, err
+	case "captureStdout":
+		o, err := captureStdout(e.Dir, command, args)
+		return o, err
 	case "waitAndStdout":
-		return []byte{}, waitAndStdout(command, args)
+		return []byte{}, waitAndStdout(e.Dir, command, args)
 	default:
 		return []byte{}, errors.New("invalid exec type")
 	}
 }
 
-func actionAndOutput(command string, args []string) ([]byte, error) {
+// BaseDir returns the directory commands are run from, resolving an empty
+// WorkingDir to the process's working directory.
+func BaseDir() (string, error) {
+	if WorkingDir != "" {
+		return WorkingDir, nil
+	}
+
+	return os.Getwd()
+}
+
+func actionAndOutput(dir, command string, args []string) ([]byte, error) {
 	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, err
@@ -47,11 +68,24 @@ func actionAndOutput(command string, args []string) ([]byte, error) {
 	return output, nil
 }
 
-func waitAndStdout(command string, args []string) error {
+// captureStdout returns only what the command writes to stdout, while its
+// stderr and stdin stay attached to the terminal so it can still prompt.
+func captureStdout(dir, command string, args []string) ([]byte, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	return cmd.Output()
+}
+
+func waitAndStdout(dir, command string, args []string) error {
 	origStdout := os.Stdout
 	origStderr := os.Stderr
 
 	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
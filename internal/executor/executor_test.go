@@ -18,6 +18,15 @@ func Test_Exec(t *testing.T) {
 		t.Errorf("expected '%s', got '%s'", expectedOutput, output)
 	}
 
+	output, err = executor.Exec("captureStdout", "sh", "-c", "echo hello; echo oops >&2")
+	if err != nil {
+		t.Errorf("expected nil error, got '%s'", err)
+	}
+
+	if !reflect.DeepEqual(output, expectedOutput) {
+		t.Errorf("expected '%s', got '%s'", expectedOutput, output)
+	}
+
 	_, err = executor.Exec("waitAndStdout", "echo", "hello")
 	if err != nil {
 		t.Errorf("expected nil error, got '%s'", err)
@@ -29,3 +38,32 @@ func Test_Exec(t *testing.T) {
 		t.Errorf("expected '%s' error, got '%s'", expectedError, err)
 	}
 }
+
+func Test_Exec_WorkingDir(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWorkingDir := WorkingDir
+	t.Cleanup(func() {
+		WorkingDir = originalWorkingDir
+	})
+	WorkingDir = tempDir
+
+	executor := NewExecutor(false)
+	if executor.Dir != tempDir {
+		t.Errorf("expected Dir '%s', got '%s'", tempDir, executor.Dir)
+	}
+
+	output, err := executor.Exec("actionAndOutput", "pwd")
+	if err != nil {
+		t.Errorf("expected nil error, got '%s'", err)
+	}
+
+	expectedOutput := tempDir + "\n"
+	if string(output) != expectedOutput {
+		t.Errorf("expected '%s', got '%s'", expectedOutput, output)
+	}
+
+	baseDir, err := BaseDir()
+	if err != nil || baseDir != tempDir {
+		t.Errorf("expected base dir '%s', got '%s' (%v)", tempDir, baseDir, err)
+	}
+}
@@ -0,0 +1,84 @@
+package forge
+
+import (
+	"errors"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/github"
+)
+
+// CheckResults are the checks on a commit, along with the forge's API rate
+// limit after fetching them, which is nil when the forge didn't say.
+type CheckResults struct {
+	Checks    []Check
+	RateLimit *RateLimit
+}
+
+// RateLimit is how many API requests are left until the limit resets.
+type RateLimit struct {
+	Remaining int
+	Reset     time.Time
+}
+
+// RetryAfter returns when to try again after err, when it's a rate limit
+// error. The GitLab client waits out rate limits itself.
+func RetryAfter(err error) (time.Time, bool) {
+	return github.RetryAfter(err)
+}
+
+func (c *gitHubClient) Checks(sha string) (*CheckResults, error) {
+	checks, err := c.client.Checks(c.owner, c.repo, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &CheckResults{Checks: []Check{}}
+	for _, check := range checks {
+		results.Checks = append(results.Checks, Check{ID: check.ID, Name: check.Name, State: check.State, URL: check.URL})
+	}
+
+	if rate, ok := c.client.RateLimit(); ok {
+		results.RateLimit = &RateLimit{Remaining: rate.Remaining, Reset: rate.Reset}
+	}
+
+	return results, nil
+}
+
+// CheckLog returns the log of a check run from GitHub Actions. Commit
+// statuses don't have logs.
+func (c *gitHubClient) CheckLog(check Check) (string, error) {
+	if check.ID == 0 {
+		return "", errors.New("only GitHub Actions check runs have logs")
+	}
+
+	return c.client.JobLog(c.owner, c.repo, check.ID)
+}
+
+func (c *gitLabClient) Checks(sha string) (*CheckResults, error) {
+	results := &CheckResults{Checks: []Check{}}
+	pipeline, err := c.client.LatestPipeline(c.project, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	if pipeline != nil {
+		jobs, err := c.client.Jobs(c.project, pipeline.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, job := range jobs {
+			results.Checks = append(results.Checks, Check{ID: job.ID, Name: job.Name, State: gitLabJobState(job), URL: job.WebURL})
+		}
+	}
+
+	if rate, ok := c.client.RateLimit(); ok {
+		results.RateLimit = &RateLimit{Remaining: rate.Remaining, Reset: rate.Reset}
+	}
+
+	return results, nil
+}
+
+func (c *gitLabClient) CheckLog(check Check) (string, error) {
+	return c.client.JobLog(c.project, check.ID)
+}
@@ -0,0 +1,84 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/github"
+	"github.com/emmahsax/go-git-helper/internal/gitlab"
+	go_github "github.com/google/go-github/v84/github"
+	go_gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+func Test_Checks_GitHub(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "10")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		switch r.URL.Path {
+		case "/repos/octocat/hello/commits/abc123/status":
+			fmt.Fprint(w, `{"statuses": [{"context": "ci/docs", "state": "success"}]}`)
+		case "/repos/octocat/hello/commits/abc123/check-runs":
+			fmt.Fprint(w, `{"check_runs": [{"id": 9, "name": "test", "status": "completed", "conclusion": "failure"}]}`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := go_github.NewClient(nil)
+	ghClient.BaseURL, _ = ghClient.BaseURL.Parse(server.URL + "/")
+	gh := &gitHubClient{client: &github.GitHub{Client: ghClient}, owner: "octocat", repo: "hello"}
+
+	results, err := gh.Checks("abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results.Checks) != 2 || results.Checks[1].ID != 9 || results.Checks[1].State != CheckFailure {
+		t.Errorf("unexpected checks %+v", results.Checks)
+	}
+
+	if results.RateLimit == nil || results.RateLimit.Remaining != 10 {
+		t.Errorf("expected the rate limit, got %+v", results.RateLimit)
+	}
+
+	if _, err := gh.CheckLog(results.Checks[0]); err == nil {
+		t.Error("expected an error getting a commit status's log")
+	}
+}
+
+func Test_Checks_GitLab(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/projects/group/project/pipelines":
+			fmt.Fprint(w, `[{"id": 7}]`)
+		case "/api/v4/projects/group/project/pipelines/7/jobs":
+			fmt.Fprint(w, `[{"id": 3, "name": "test", "status": "failed", "web_url": "https://gitlab.com/group/project/-/jobs/3"}, {"id": 4, "name": "lint", "status": "pending"}]`)
+		case "/api/v4/projects/group/project/jobs/3/trace":
+			fmt.Fprint(w, "FAIL\n")
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	glClient, _ := go_gitlab.NewClient("", go_gitlab.WithBaseURL(server.URL))
+	gl := &gitLabClient{client: &gitlab.GitLab{Client: glClient}, project: "group/project"}
+
+	results, err := gl.Checks("abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results.Checks) != 2 || results.Checks[0].State != CheckFailure || results.Checks[1].State != CheckPending || results.RateLimit != nil {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	log, err := gl.CheckLog(results.Checks[0])
+	if err != nil || log != "FAIL\n" {
+		t.Errorf("expected the failed job's log, got %q (%v)", log, err)
+	}
+}
@@ -0,0 +1,187 @@
+package forge
+
+import (
+	"fmt"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/github"
+	"github.com/emmahsax/go-git-helper/internal/gitlab"
+	go_github "github.com/google/go-github/v84/github"
+	go_gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+// States of a code request.
+const (
+	StateClosed = "closed"
+	StateMerged = "merged"
+	StateOpen   = "open"
+)
+
+// CodeRequest is a GitHub pull request or a GitLab merge request. HeadRef,
+// HeadRepo and MaintainerCanModify are only filled in by Client.CodeRequest.
+type CodeRequest struct {
+	Base                string
+	Draft               bool
+	Head                string
+	HeadRef             string
+	HeadRepo            string
+	HeadSHA             string
+	MaintainerCanModify bool
+	Number              int
+	State               string
+	Title               string
+	URL                 string
+}
+
+// Client works with the code requests of one repository, on either forge.
+type Client interface {
+	CheckLog(check Check) (string, error)
+	Checks(sha string) (*CheckResults, error)
+	CodeRequest(number int) (*CodeRequest, error)
+	FindCodeRequest(branch string) (*CodeRequest, error)
+	Merge(number int, options MergeOptions) error
+	MergeStatus(number int) (*MergeStatus, error)
+	RequestReviewers(number int, reviewers []string) error
+	Retarget(number int, base string) error
+	SetDraft(number int, draft bool) error
+	Status(cr *CodeRequest) (*Status, error)
+}
+
+// NewClient returns a client for the remote's repository. It's a variable so
+// tests can swap in a fake forge.
+var NewClient = func(debug bool, remote *git.Remote) (Client, error) {
+	forge, err := Name(debug, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	cf := configfile.NewConfigFile(debug)
+	token, _, err := credentials.NewCredentials(debug, cf, executor.NewExecutor(debug)).Token(forge, remote.Host, remote.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if forge == credentials.GitLab {
+		return &gitLabClient{client: gitlab.NewGitLabFromToken(debug, remote.Host, token), project: remote.FullName()}, nil
+	}
+
+	return &gitHubClient{client: github.NewGitHubFromToken(debug, remote.Host, token), owner: remote.Owner, repo: remote.Repo}, nil
+}
+
+type gitHubClient struct {
+	client *github.GitHub
+	owner  string
+	repo   string
+}
+
+// FindCodeRequest returns the branch's newest pull request, or nil when it
+// doesn't have one.
+func (c *gitHubClient) FindCodeRequest(branch string) (*CodeRequest, error) {
+	pr, err := c.client.PullRequestForBranch(c.owner, c.repo, branch)
+	if err != nil || pr == nil {
+		return nil, err
+	}
+
+	return newGitHubCodeRequest(pr), nil
+}
+
+func newGitHubCodeRequest(pr *go_github.PullRequest) *CodeRequest {
+	state := pr.GetState()
+	if pr.MergedAt != nil {
+		state = StateMerged
+	}
+
+	return &CodeRequest{
+		Base:    pr.GetBase().GetRef(),
+		Draft:   pr.GetDraft(),
+		Head:    pr.GetHead().GetRef(),
+		HeadSHA: pr.GetHead().GetSHA(),
+		Number:  pr.GetNumber(),
+		State:   state,
+		Title:   pr.GetTitle(),
+		URL:     pr.GetHTMLURL(),
+	}
+}
+
+// CodeRequest returns the pull request with number, including where to fetch
+// its head from.
+func (c *gitHubClient) CodeRequest(number int) (*CodeRequest, error) {
+	pr, err := c.client.PullRequest(c.owner, c.repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := newGitHubCodeRequest(pr)
+	cr.HeadRef = fmt.Sprintf("refs/pull/%d/head", number)
+	cr.HeadRepo = pr.GetHead().GetRepo().GetFullName()
+	cr.MaintainerCanModify = pr.GetMaintainerCanModify()
+	return cr, nil
+}
+
+func (c *gitHubClient) Retarget(number int, base string) error {
+	return c.client.UpdatePullRequestBase(c.owner, c.repo, number, base)
+}
+
+type gitLabClient struct {
+	client  *gitlab.GitLab
+	project string
+}
+
+// FindCodeRequest returns the branch's newest merge request, or nil when it
+// doesn't have one.
+func (c *gitLabClient) FindCodeRequest(branch string) (*CodeRequest, error) {
+	mr, err := c.client.MergeRequestForBranch(c.project, branch)
+	if err != nil || mr == nil {
+		return nil, err
+	}
+
+	return newGitLabCodeRequest(mr), nil
+}
+
+func newGitLabCodeRequest(mr *go_gitlab.BasicMergeRequest) *CodeRequest {
+	state := mr.State
+	switch state {
+	case "opened", "locked":
+		state = StateOpen
+	}
+
+	return &CodeRequest{
+		Base:    mr.TargetBranch,
+		Draft:   mr.Draft,
+		Head:    mr.SourceBranch,
+		HeadSHA: mr.SHA,
+		Number:  int(mr.IID),
+		State:   state,
+		Title:   mr.Title,
+		URL:     mr.WebURL,
+	}
+}
+
+// CodeRequest returns the merge request with number, including where to fetch
+// its head from.
+func (c *gitLabClient) CodeRequest(number int) (*CodeRequest, error) {
+	mr, err := c.client.MergeRequest(c.project, int64(number))
+	if err != nil {
+		return nil, err
+	}
+
+	cr := newGitLabCodeRequest(&mr.BasicMergeRequest)
+	cr.HeadRef = fmt.Sprintf("refs/merge-requests/%d/head", number)
+	cr.HeadRepo = c.project
+	cr.MaintainerCanModify = mr.AllowCollaboration
+	if mr.SourceProjectID != mr.TargetProjectID {
+		cr.HeadRepo, err = c.client.ProjectPath(mr.SourceProjectID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cr, nil
+}
+
+func (c *gitLabClient) Retarget(number int, base string) error {
+	return c.client.UpdateMergeRequestTarget(c.project, int64(number), base)
+}
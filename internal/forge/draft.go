@@ -0,0 +1,55 @@
+package forge
+
+import (
+	"regexp"
+	"strings"
+)
+
+// draftPrefix matches the title prefixes GitLab treats as marking a merge
+// request as a draft.
+var draftPrefix = regexp.MustCompile(`(?i)^\s*(?:\[draft\]|\(draft\)|draft:|draft\s+-)\s*`)
+
+// SetDraft marks the pull request as a draft, or as ready for review.
+func (c *gitHubClient) SetDraft(number int, draft bool) error {
+	pr, err := c.client.PullRequest(c.owner, c.repo, number)
+	if err != nil {
+		return err
+	}
+
+	if draft {
+		return c.client.ConvertToDraft(pr.GetNodeID())
+	}
+
+	return c.client.MarkReadyForReview(pr.GetNodeID())
+}
+
+func (c *gitHubClient) RequestReviewers(number int, reviewers []string) error {
+	return c.client.RequestReviewers(c.owner, c.repo, number, reviewers)
+}
+
+// SetDraft marks the merge request as a draft, or as ready for review, by
+// adding or removing the Draft: prefix on its title.
+func (c *gitLabClient) SetDraft(number int, draft bool) error {
+	mr, err := c.client.MergeRequest(c.project, int64(number))
+	if err != nil {
+		return err
+	}
+
+	return c.client.UpdateMergeRequestTitle(c.project, int64(number), draftTitle(mr.Title, draft))
+}
+
+func (c *gitLabClient) RequestReviewers(number int, reviewers []string) error {
+	return c.client.AddReviewers(c.project, int64(number), reviewers)
+}
+
+func draftTitle(title string, draft bool) string {
+	for draftPrefix.MatchString(title) {
+		title = draftPrefix.ReplaceAllString(title, "")
+	}
+
+	if draft {
+		return "Draft: " + title
+	}
+
+	return strings.TrimSpace(title)
+}
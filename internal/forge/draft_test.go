@@ -0,0 +1,89 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/github"
+	"github.com/emmahsax/go-git-helper/internal/gitlab"
+	go_github "github.com/google/go-github/v84/github"
+	go_gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+func Test_SetDraft_GitHub(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/pulls/2":
+			fmt.Fprint(w, `{"number": 2, "node_id": "PR_2"}`)
+		case "/graphql":
+			var body struct {
+				Query string `json:"query"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			query = body.Query
+			fmt.Fprint(w, `{"data": {}}`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := go_github.NewClient(nil)
+	ghClient.BaseURL, _ = ghClient.BaseURL.Parse(server.URL + "/")
+	gh := &gitHubClient{client: &github.GitHub{Client: ghClient}, owner: "octocat", repo: "hello"}
+
+	if err := gh.SetDraft(2, true); err != nil || !strings.Contains(query, "convertPullRequestToDraft") {
+		t.Errorf("expected the pull request to be converted to a draft, got %q (%v)", query, err)
+	}
+}
+
+func Test_SetDraft_GitLab(t *testing.T) {
+	var title string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			var body struct {
+				Title string `json:"title"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			title = body.Title
+		}
+		fmt.Fprint(w, `{"iid": 4, "title": "Draft: Fix things"}`)
+	}))
+	defer server.Close()
+
+	glClient, _ := go_gitlab.NewClient("", go_gitlab.WithBaseURL(server.URL))
+	gl := &gitLabClient{client: &gitlab.GitLab{Client: glClient}, project: "group/project"}
+
+	if err := gl.SetDraft(4, false); err != nil || title != "Fix things" {
+		t.Errorf("expected the draft prefix to be removed, got %q (%v)", title, err)
+	}
+}
+
+func Test_draftTitle(t *testing.T) {
+	tests := []struct {
+		title    string
+		draft    bool
+		expected string
+	}{
+		{title: "Fix things", draft: true, expected: "Draft: Fix things"},
+		{title: "Draft: Fix things", draft: true, expected: "Draft: Fix things"},
+		{title: "Draft: Fix things", draft: false, expected: "Fix things"},
+		{title: "[Draft] Fix things", draft: false, expected: "Fix things"},
+		{title: "(draft) Fix things", draft: false, expected: "Fix things"},
+		{title: "Draft - Fix things", draft: false, expected: "Fix things"},
+		{title: "Draft: WIP: Fix things", draft: false, expected: "WIP: Fix things"},
+		{title: "Drafting the spec", draft: false, expected: "Drafting the spec"},
+	}
+
+	for _, test := range tests {
+		actual := draftTitle(test.title, test.draft)
+		if actual != test.expected {
+			t.Errorf("expected %q for %q, got %q", test.expected, test.title, actual)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package forge
+
+import (
+	"errors"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/github"
+	"github.com/emmahsax/go-git-helper/internal/gitlab"
+)
+
+// Name returns github or gitlab for the remote's host, or an error when it
+// can't be told from the host name or an account.
+func Name(debug bool, remote *git.Remote) (string, error) {
+	config, err := configfile.NewConfigFile(debug).Load()
+	if err != nil {
+		return "", err
+	}
+
+	forge := config.Forge(remote.Host)
+	if forge == "" {
+		return "", errors.New("can't tell whether " + remote.Host + " is GitHub or GitLab, please add an account for it with a forge")
+	}
+
+	return forge, nil
+}
+
+// DefaultBranch asks the remote's forge for the repository's default branch.
+// Unlike the forge clients' constructors, it returns an error instead of
+// exiting when there's no token.
+func DefaultBranch(debug bool, remote *git.Remote) (string, error) {
+	forge, err := Name(debug, remote)
+	if err != nil {
+		return "", err
+	}
+
+	cf := configfile.NewConfigFile(debug)
+	token, _, err := credentials.NewCredentials(debug, cf, executor.NewExecutor(debug)).Token(forge, remote.Host, remote.Owner)
+	if err != nil {
+		return "", err
+	}
+
+	if forge == credentials.GitLab {
+		return gitlab.NewGitLabFromToken(debug, remote.Host, token).DefaultBranch(remote.FullName())
+	}
+
+	return github.NewGitHubFromToken(debug, remote.Host, token).DefaultBranch(remote.Owner, remote.Repo)
+}
@@ -0,0 +1,105 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/github"
+	"github.com/emmahsax/go-git-helper/internal/gitlab"
+	go_github "github.com/google/go-github/v84/github"
+	go_gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+func Test_Name(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".git-helper"), 0700)
+	os.WriteFile(filepath.Join(home, ".git-helper", "config.yml"), []byte("accounts:\n  - name: work\n    host: code.example.com\n    forge: gitlab\n"), 0600)
+
+	tests := []struct {
+		host     string
+		expected string
+		err      bool
+	}{
+		{host: "github.com", expected: "github"},
+		{host: "gitlab.com", expected: "gitlab"},
+		{host: "gitlab.example.com", err: true},
+		{host: "code.example.com", expected: "gitlab"},
+		{host: "git.example.com", err: true},
+	}
+
+	for _, test := range tests {
+		forge, err := Name(true, &git.Remote{Host: test.host})
+		if (err != nil) != test.err || forge != test.expected {
+			t.Errorf("expected %q for %s, got %q (%v)", test.expected, test.host, forge, err)
+		}
+	}
+}
+
+func Test_FindCodeRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/v4/") {
+			fmt.Fprint(w, `[{"iid": 4, "state": "opened", "target_branch": "feature-a", "source_branch": "feature-b", "web_url": "https://gitlab.com/group/project/-/merge_requests/4"}]`)
+			return
+		}
+
+		fmt.Fprint(w, `[{"number": 3, "state": "closed", "merged_at": "2026-01-01T00:00:00Z", "base": {"ref": "main"}, "head": {"ref": "feature-a"}}]`)
+	}))
+	defer server.Close()
+
+	ghClient := go_github.NewClient(nil)
+	ghClient.BaseURL, _ = ghClient.BaseURL.Parse(server.URL + "/")
+	gh := &gitHubClient{client: &github.GitHub{Client: ghClient}, owner: "octocat", repo: "hello"}
+
+	cr, err := gh.FindCodeRequest("feature-a")
+	if err != nil || cr.Number != 3 || cr.State != StateMerged || cr.Base != "main" {
+		t.Errorf("expected merged pull request #3, got %+v (%v)", cr, err)
+	}
+
+	glClient, _ := go_gitlab.NewClient("", go_gitlab.WithBaseURL(server.URL))
+	gl := &gitLabClient{client: &gitlab.GitLab{Client: glClient}, project: "group/project"}
+
+	cr, err = gl.FindCodeRequest("feature-b")
+	if err != nil || cr.Number != 4 || cr.State != StateOpen || cr.Base != "feature-a" {
+		t.Errorf("expected open merge request !4, got %+v (%v)", cr, err)
+	}
+}
+
+func Test_CodeRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/pulls/3":
+			fmt.Fprint(w, `{"number": 3, "state": "open", "maintainer_can_modify": true, "head": {"ref": "main", "repo": {"full_name": "hubot/hello"}}}`)
+		case "/api/v4/projects/group/project/merge_requests/4":
+			fmt.Fprint(w, `{"iid": 4, "state": "opened", "source_branch": "fix", "source_project_id": 9, "target_project_id": 1}`)
+		case "/api/v4/projects/9":
+			fmt.Fprint(w, `{"id": 9, "path_with_namespace": "someone/project"}`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := go_github.NewClient(nil)
+	ghClient.BaseURL, _ = ghClient.BaseURL.Parse(server.URL + "/")
+	gh := &gitHubClient{client: &github.GitHub{Client: ghClient}, owner: "octocat", repo: "hello"}
+
+	cr, err := gh.CodeRequest(3)
+	if err != nil || cr.HeadRef != "refs/pull/3/head" || cr.HeadRepo != "hubot/hello" || !cr.MaintainerCanModify {
+		t.Errorf("expected pull request #3 from hubot/hello, got %+v (%v)", cr, err)
+	}
+
+	glClient, _ := go_gitlab.NewClient("", go_gitlab.WithBaseURL(server.URL))
+	gl := &gitLabClient{client: &gitlab.GitLab{Client: glClient}, project: "group/project"}
+
+	cr, err = gl.CodeRequest(4)
+	if err != nil || cr.HeadRef != "refs/merge-requests/4/head" || cr.HeadRepo != "someone/project" || cr.State != StateOpen {
+		t.Errorf("expected merge request !4 from someone/project, got %+v (%v)", cr, err)
+	}
+}
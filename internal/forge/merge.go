@@ -0,0 +1,269 @@
+package forge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/github"
+	go_github "github.com/google/go-github/v84/github"
+	go_gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+// Merge methods.
+const (
+	MethodMerge  = "merge"
+	MethodRebase = "rebase"
+	MethodSquash = "squash"
+)
+
+// MergeStatus says whether a code request can be merged, and how.
+type MergeStatus struct {
+	Approvals     int
+	Blockers      []string
+	HeadSHA       string
+	Methods       []string
+	SquashMessage string
+	Waiting       []string
+	Warnings      []string
+}
+
+// Ready reports whether the code request can be merged right away.
+func (s *MergeStatus) Ready() bool {
+	return len(s.Blockers) == 0 && len(s.Waiting) == 0
+}
+
+// MergeOptions say how to merge a code request. With Auto, it's merged by the
+// forge once whatever it's waiting on is done.
+type MergeOptions struct {
+	Auto         bool
+	DeleteBranch bool
+	Message      string
+	Method       string
+	SHA          string
+}
+
+func (c *gitHubClient) MergeStatus(number int) (*MergeStatus, error) {
+	pr, err := c.client.PullRequest(c.owner, c.repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MergeStatus{HeadSHA: pr.GetHead().GetSHA()}
+	status.Approvals, err = c.client.Approvals(c.owner, c.repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	status.Methods, err = c.client.MergeMethods(c.owner, c.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	checks, err := c.client.Checks(c.owner, c.repo, status.HeadSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	status.Blockers, status.Waiting, status.Warnings = gitHubMergeability(pr, checks)
+
+	messages, err := c.client.CommitMessages(c.owner, c.repo, number)
+	if err != nil {
+		return nil, err
+	}
+	status.SquashMessage = squashMessage(fmt.Sprintf("%s (#%d)", pr.GetTitle(), number), messages)
+
+	return status, nil
+}
+
+func (c *gitHubClient) Merge(number int, options MergeOptions) error {
+	title, message := "", ""
+	if options.Method == MethodSquash && options.Message != "" {
+		title, message, _ = strings.Cut(options.Message, "\n")
+		message = strings.TrimSpace(message)
+	}
+
+	if options.Auto {
+		pr, err := c.client.PullRequest(c.owner, c.repo, number)
+		if err != nil {
+			return err
+		}
+
+		return c.client.EnableAutoMerge(pr.GetNodeID(), options.Method, title, message)
+	}
+
+	return c.client.MergePullRequest(c.owner, c.repo, number, options.Method, options.SHA, title, message)
+}
+
+func (c *gitLabClient) MergeStatus(number int) (*MergeStatus, error) {
+	iid := int64(number)
+	mr, err := c.client.MergeRequest(c.project, iid)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MergeStatus{HeadSHA: mr.SHA}
+
+	approvals, err := c.client.Approvals(c.project, iid)
+	if err != nil {
+		return nil, err
+	}
+	status.Approvals = len(approvals.ApprovedBy)
+
+	method, squash, err := c.client.MergeSettings(c.project)
+	if err != nil {
+		return nil, err
+	}
+	status.Methods = gitLabMethods(method, squash)
+
+	status.Blockers, status.Waiting = gitLabMergeability(mr, approvals.ApprovalsLeft)
+
+	messages, err := c.client.CommitMessages(c.project, iid)
+	if err != nil {
+		return nil, err
+	}
+	status.SquashMessage = squashMessage(mr.Title, messages)
+
+	return status, nil
+}
+
+func (c *gitLabClient) Merge(number int, options MergeOptions) error {
+	accept := &go_gitlab.AcceptMergeRequestOptions{
+		Squash:                   go_gitlab.Ptr(options.Method == MethodSquash),
+		ShouldRemoveSourceBranch: go_gitlab.Ptr(options.DeleteBranch),
+	}
+
+	if options.SHA != "" {
+		accept.SHA = go_gitlab.Ptr(options.SHA)
+	}
+
+	if options.Auto {
+		accept.AutoMerge = go_gitlab.Ptr(true)
+	}
+
+	if options.Method == MethodSquash && options.Message != "" {
+		accept.SquashCommitMessage = go_gitlab.Ptr(options.Message)
+	}
+
+	return c.client.AcceptMergeRequest(c.project, int64(number), accept)
+}
+
+// gitHubMergeability returns what stops the pull request being merged, what
+// it's waiting on, and any checks that failed or are running without being
+// required.
+func gitHubMergeability(pr *go_github.PullRequest, checks []github.Check) ([]string, []string, []string) {
+	blockers, waiting, warnings := []string{}, []string{}, []string{}
+	failing, pending := []string{}, []string{}
+	for _, check := range checks {
+		switch check.State {
+		case github.CheckFailure:
+			failing = append(failing, check.Name)
+		case github.CheckPending:
+			pending = append(pending, check.Name)
+		}
+	}
+
+	state := pr.GetMergeableState()
+	switch {
+	case pr.GetDraft():
+		blockers = append(blockers, "it's still a draft")
+	case pr.Mergeable == nil:
+		blockers = append(blockers, "GitHub is still working out whether it can be merged, try again shortly")
+	case state == "dirty":
+		blockers = append(blockers, "it has conflicts with "+pr.GetBase().GetRef())
+	case state == "behind":
+		blockers = append(blockers, "it's behind "+pr.GetBase().GetRef()+", run git-helper sync --push first")
+	case state == "blocked":
+		if len(failing) > 0 {
+			blockers = append(blockers, "checks failed: "+strings.Join(failing, ", "))
+		} else if len(pending) > 0 {
+			waiting = append(waiting, "checks are still running: "+strings.Join(pending, ", "))
+		} else {
+			waiting = append(waiting, "branch protection needs more approvals or required checks")
+		}
+	default:
+		if len(failing) > 0 {
+			warnings = append(warnings, "checks that aren't required failed: "+strings.Join(failing, ", "))
+		}
+		if len(pending) > 0 {
+			warnings = append(warnings, "checks are still running: "+strings.Join(pending, ", "))
+		}
+	}
+
+	return blockers, waiting, warnings
+}
+
+// gitLabMergeability returns what stops the merge request being merged, and
+// what it's waiting on.
+func gitLabMergeability(mr *go_gitlab.MergeRequest, approvalsLeft int64) ([]string, []string) {
+	blockers, waiting := []string{}, []string{}
+	pipeline := ""
+	if mr.HeadPipeline != nil {
+		pipeline = mr.HeadPipeline.Status
+	}
+
+	switch mr.DetailedMergeStatus {
+	case "mergeable":
+	case "ci_still_running":
+		waiting = append(waiting, "the pipeline is still running")
+	case "ci_must_pass":
+		if pipeline == "failed" || pipeline == "canceled" {
+			blockers = append(blockers, "the pipeline "+pipeline)
+		} else {
+			waiting = append(waiting, "the pipeline has to pass first")
+		}
+	case "not_approved":
+		waiting = append(waiting, "it needs "+strconv.FormatInt(approvalsLeft, 10)+" more approval(s)")
+	case "conflict", "broken_status":
+		blockers = append(blockers, "it has conflicts with "+mr.TargetBranch)
+	case "draft_status":
+		blockers = append(blockers, "it's still a draft")
+	case "need_rebase":
+		blockers = append(blockers, "it needs rebasing onto "+mr.TargetBranch+", run git-helper sync --push first")
+	case "discussions_not_resolved":
+		blockers = append(blockers, "it has unresolved threads")
+	case "requested_changes":
+		blockers = append(blockers, "a reviewer requested changes")
+	case "checking", "unchecked", "preparing", "approvals_syncing":
+		blockers = append(blockers, "GitLab is still working out whether it can be merged, try again shortly")
+	default:
+		blockers = append(blockers, "GitLab says it can't be merged ("+strings.ReplaceAll(mr.DetailedMergeStatus, "_", " ")+")")
+	}
+
+	return blockers, waiting
+}
+
+// gitLabMethods returns the merge methods a project allows. The project picks
+// between merge commits and fast-forwarding, which is offered as rebase, and
+// whether squashing is allowed, required or neither.
+func gitLabMethods(method go_gitlab.MergeMethodValue, squash go_gitlab.SquashOptionValue) []string {
+	base := MethodMerge
+	if method == go_gitlab.FastForwardMerge || method == go_gitlab.RebaseMerge {
+		base = MethodRebase
+	}
+
+	switch squash {
+	case go_gitlab.SquashOptionAlways:
+		return []string{MethodSquash}
+	case go_gitlab.SquashOptionNever:
+		return []string{base}
+	default:
+		return []string{base, MethodSquash}
+	}
+}
+
+// squashMessage lists each commit's subject under the title, like GitHub's
+// default squash commit message.
+func squashMessage(title string, messages []string) string {
+	lines := []string{}
+	for _, message := range messages {
+		subject, _, _ := strings.Cut(message, "\n")
+		lines = append(lines, "* "+subject)
+	}
+
+	if len(lines) == 0 {
+		return title
+	}
+
+	return title + "\n\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,94 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/github"
+	go_github "github.com/google/go-github/v84/github"
+	go_gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+func Test_MergeStatus_GitHub(t *testing.T) {
+	state := "blocked"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/pulls/2":
+			fmt.Fprintf(w, `{"number": 2, "title": "Add things", "mergeable": true, "mergeable_state": %q, "head": {"sha": "abc123"}, "base": {"ref": "main"}}`, state)
+		case "/repos/octocat/hello/pulls/2/reviews":
+			fmt.Fprint(w, `[{"user": {"login": "alice"}, "state": "APPROVED"}]`)
+		case "/repos/octocat/hello":
+			fmt.Fprint(w, `{"allow_squash_merge": true}`)
+		case "/repos/octocat/hello/commits/abc123/status":
+			fmt.Fprint(w, `{"statuses": []}`)
+		case "/repos/octocat/hello/commits/abc123/check-runs":
+			fmt.Fprint(w, `{"check_runs": [{"name": "test", "status": "queued"}]}`)
+		case "/repos/octocat/hello/pulls/2/commits":
+			fmt.Fprint(w, `[{"commit": {"message": "Add a thing"}}]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := go_github.NewClient(nil)
+	ghClient.BaseURL, _ = ghClient.BaseURL.Parse(server.URL + "/")
+	gh := &gitHubClient{client: &github.GitHub{Client: ghClient}, owner: "octocat", repo: "hello"}
+
+	status, err := gh.MergeStatus(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Approvals != 1 || status.HeadSHA != "abc123" || strings.Join(status.Methods, ",") != "squash" {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	if len(status.Blockers) != 0 || len(status.Waiting) != 1 || !strings.Contains(status.Waiting[0], "test") {
+		t.Errorf("expected to wait on the test check, got %+v", status)
+	}
+
+	if status.SquashMessage != "Add things (#2)\n\n* Add a thing" {
+		t.Errorf("unexpected squash message %q", status.SquashMessage)
+	}
+
+	state = "dirty"
+	status, err = gh.MergeStatus(2)
+	if err != nil || len(status.Blockers) != 1 || !strings.Contains(status.Blockers[0], "conflicts with main") {
+		t.Errorf("expected conflicts to block merging, got %+v (%v)", status, err)
+	}
+}
+
+func Test_gitLabMethods(t *testing.T) {
+	tests := []struct {
+		method   go_gitlab.MergeMethodValue
+		squash   go_gitlab.SquashOptionValue
+		expected string
+	}{
+		{method: go_gitlab.NoFastForwardMerge, squash: go_gitlab.SquashOptionDefaultOff, expected: "merge,squash"},
+		{method: go_gitlab.FastForwardMerge, squash: go_gitlab.SquashOptionNever, expected: "rebase"},
+		{method: go_gitlab.RebaseMerge, squash: go_gitlab.SquashOptionDefaultOn, expected: "rebase,squash"},
+		{method: go_gitlab.NoFastForwardMerge, squash: go_gitlab.SquashOptionAlways, expected: "squash"},
+	}
+
+	for _, test := range tests {
+		if actual := strings.Join(gitLabMethods(test.method, test.squash), ","); actual != test.expected {
+			t.Errorf("expected %s for %s and %s, got %s", test.expected, test.method, test.squash, actual)
+		}
+	}
+}
+
+func Test_squashMessage(t *testing.T) {
+	message := squashMessage("Add things (#2)", []string{"Add a thing\n\nBecause.", "Add another thing"})
+	expected := "Add things (#2)\n\n* Add a thing\n* Add another thing"
+	if message != expected {
+		t.Errorf("expected %q, got %q", expected, message)
+	}
+
+	if message := squashMessage("Add things", nil); message != "Add things" {
+		t.Errorf("expected just the title, got %q", message)
+	}
+}
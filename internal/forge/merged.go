@@ -0,0 +1,61 @@
+package forge
+
+import (
+	"fmt"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/stack"
+)
+
+// CheckPushed refuses to delete the branch when it has commits that weren't
+// part of the merged code request.
+func CheckPushed(g *git.Git, branch string, cr *CodeRequest) error {
+	since := cr.HeadSHA
+	if since == "" {
+		since = "origin/" + branch
+	}
+
+	count, err := g.UnpushedCommits(branch, since)
+	if err != nil {
+		return fmt.Errorf("could not compare %s with what was merged in %s: %w", branch, cr.URL, err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("%s has %d commit(s) that weren't part of %s, push them somewhere before deleting it", branch, count, cr.URL)
+	}
+
+	return nil
+}
+
+// MoveChildren stacks any branches stacked on the merged branch on its parent
+// instead, since its stack config is deleted along with it, and retargets
+// their open code requests.
+func MoveChildren(debug bool, executor executor.ExecutorInterface, client Client, branch, defaultBranch string) {
+	st := stack.NewStack(debug, executor)
+	parent := st.Parent(branch)
+	if parent == "" {
+		parent = defaultBranch
+	}
+
+	for _, child := range st.Children(branch) {
+		err := st.SetParent(child, parent)
+		if err != nil {
+			fmt.Printf("Could not move %s onto %s: %v\n", child, parent, err)
+			continue
+		}
+		fmt.Printf("%s is now stacked on %s, run git-helper stack restack from it to rebase it\n", child, parent)
+
+		cr, err := client.FindCodeRequest(child)
+		if err != nil || cr == nil || cr.State != StateOpen || cr.Base != branch {
+			continue
+		}
+
+		err = client.Retarget(cr.Number, parent)
+		if err != nil {
+			fmt.Printf("Could not retarget %s to %s: %v\n", cr.URL, parent, err)
+			continue
+		}
+		fmt.Printf("Retargeted %s to %s\n", cr.URL, parent)
+	}
+}
@@ -0,0 +1,91 @@
+package forge
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/git"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Debug   bool
+	Outputs map[string]string
+	Run     []string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+// fakeClient embeds Client so it only has to implement what's used.
+type fakeClient struct {
+	Client
+	requests   map[string]*CodeRequest
+	retargeted map[int]string
+}
+
+func (c *fakeClient) FindCodeRequest(branch string) (*CodeRequest, error) {
+	return c.requests[branch], nil
+}
+
+func (c *fakeClient) Retarget(number int, base string) error {
+	c.retargeted[number] = base
+	return nil
+}
+
+func Test_CheckPushed(t *testing.T) {
+	tests := []struct {
+		cr       *CodeRequest
+		outputs  map[string]string
+		expected string
+	}{
+		{cr: &CodeRequest{HeadSHA: "abc123", URL: "#1"}, outputs: map[string]string{"git rev-list --count abc123..feature": "0\n"}},
+		{cr: &CodeRequest{URL: "#1"}, outputs: map[string]string{"git rev-list --count origin/feature..feature": "0\n"}},
+		{cr: &CodeRequest{HeadSHA: "abc123", URL: "#1"}, outputs: map[string]string{"git rev-list --count abc123..feature": "2\n"}, expected: "2 commit(s) that weren't part of #1"},
+		{cr: &CodeRequest{HeadSHA: "abc123", URL: "#1"}, outputs: map[string]string{}, expected: "could not compare feature"},
+	}
+
+	for _, test := range tests {
+		g := git.NewGit(true, &MockExecutor{Debug: true, Outputs: test.outputs})
+		err := CheckPushed(g, "feature", test.cr)
+		if test.expected == "" && err != nil || test.expected != "" && (err == nil || !strings.Contains(err.Error(), test.expected)) {
+			t.Errorf("expected an error containing %q, got %v", test.expected, err)
+		}
+	}
+}
+
+func Test_MoveChildren(t *testing.T) {
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		`git config --get-regexp ^branch\..*\.git-helper-parent$`: "branch.feature.git-helper-parent main\nbranch.child.git-helper-parent feature\n",
+		"git config --get branch.feature.git-helper-parent":       "main\n",
+		"git config branch.child.git-helper-parent main":          "",
+	}}
+	client := &fakeClient{
+		requests:   map[string]*CodeRequest{"child": {Base: "feature", Number: 2, State: StateOpen}},
+		retargeted: map[int]string{},
+	}
+
+	MoveChildren(true, executor, client, "feature", "main")
+
+	if client.retargeted[2] != "main" {
+		t.Errorf("expected child's code request to be retargeted to main, got %v", client.retargeted)
+	}
+
+	found := false
+	for _, line := range executor.Run {
+		found = found || line == "git config branch.child.git-helper-parent main"
+	}
+	if !found {
+		t.Errorf("expected child to move onto main, got %v", executor.Run)
+	}
+}
@@ -0,0 +1,306 @@
+package forge
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/github"
+	"github.com/emmahsax/go-git-helper/internal/gitlab"
+	go_gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+// Check states.
+const (
+	CheckFailure = "failure"
+	CheckPending = "pending"
+	CheckSuccess = "success"
+)
+
+// Review decisions.
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+	ReviewRequired         = "review_required"
+)
+
+// Roles the user has on a code request.
+const (
+	RoleAuthor   = "author"
+	RoleReviewer = "reviewer"
+)
+
+// Check is a GitHub check run or commit status, or a GitLab pipeline job.
+type Check struct {
+	ID    int64  `json:"id,omitempty"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Status is everything about a code request that's worth a glance before
+// merging it.
+type Status struct {
+	Checks            []Check  `json:"checks"`
+	Draft             bool     `json:"draft"`
+	MergeProblems     []string `json:"merge_problems,omitempty"`
+	Mergeable         bool     `json:"mergeable"`
+	Number            int      `json:"number"`
+	Review            string   `json:"review,omitempty"`
+	State             string   `json:"state"`
+	Title             string   `json:"title"`
+	UnresolvedThreads int      `json:"unresolved_threads"`
+	URL               string   `json:"url"`
+}
+
+// Summary is one line of the user's dashboard.
+type Summary struct {
+	Draft  bool   `json:"draft"`
+	Host   string `json:"host"`
+	Number int    `json:"number"`
+	Repo   string `json:"repo"`
+	Role   string `json:"role"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+}
+
+func newStatus(cr *CodeRequest) *Status {
+	return &Status{
+		Checks: []Check{},
+		Draft:  cr.Draft,
+		Number: cr.Number,
+		State:  cr.State,
+		Title:  cr.Title,
+		URL:    cr.URL,
+	}
+}
+
+// setMergeability only reports on open code requests, since there's nothing
+// to merge otherwise.
+func (s *Status) setMergeability(blockers, waiting []string) {
+	if s.State != StateOpen {
+		return
+	}
+
+	s.MergeProblems = append(blockers, waiting...)
+	s.Mergeable = len(s.MergeProblems) == 0
+}
+
+func (c *gitHubClient) Status(cr *CodeRequest) (*Status, error) {
+	status := newStatus(cr)
+	pr, err := c.client.PullRequest(c.owner, c.repo, cr.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	checks, err := c.client.Checks(c.owner, c.repo, pr.GetHead().GetSHA())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, check := range checks {
+		status.Checks = append(status.Checks, Check{ID: check.ID, Name: check.Name, State: check.State, URL: check.URL})
+	}
+
+	review, err := c.client.Review(c.owner, c.repo, cr.Number)
+	if err != nil {
+		return nil, err
+	}
+	status.Review = strings.ToLower(review.Decision)
+	status.UnresolvedThreads = review.UnresolvedThreads
+
+	blockers, waiting, _ := gitHubMergeability(pr, checks)
+	status.setMergeability(blockers, waiting)
+
+	return status, nil
+}
+
+func (c *gitLabClient) Status(cr *CodeRequest) (*Status, error) {
+	status := newStatus(cr)
+	iid := int64(cr.Number)
+	mr, err := c.client.MergeRequest(c.project, iid)
+	if err != nil {
+		return nil, err
+	}
+
+	if mr.HeadPipeline != nil {
+		jobs, err := c.client.Jobs(c.project, mr.HeadPipeline.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, job := range jobs {
+			status.Checks = append(status.Checks, Check{ID: job.ID, Name: job.Name, State: gitLabJobState(job), URL: job.WebURL})
+		}
+	}
+
+	approvals, err := c.client.Approvals(c.project, iid)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case mr.DetailedMergeStatus == "requested_changes":
+		status.Review = ReviewChangesRequested
+	case approvals.ApprovalsLeft > 0:
+		status.Review = ReviewRequired
+	case approvals.Approved && len(approvals.ApprovedBy) > 0:
+		status.Review = ReviewApproved
+	}
+
+	status.UnresolvedThreads, err = c.client.UnresolvedThreads(c.project, iid)
+	if err != nil {
+		return nil, err
+	}
+
+	blockers, waiting := gitLabMergeability(mr, approvals.ApprovalsLeft)
+	status.setMergeability(blockers, waiting)
+
+	return status, nil
+}
+
+// gitLabJobState counts jobs that are allowed to fail as passing, since they
+// don't fail the pipeline.
+func gitLabJobState(job *go_gitlab.Job) string {
+	switch job.Status {
+	case "success", "skipped", "manual":
+		return CheckSuccess
+	case "failed":
+		if job.AllowFailure {
+			return CheckSuccess
+		}
+		return CheckFailure
+	case "canceled":
+		return CheckFailure
+	default:
+		return CheckPending
+	}
+}
+
+// Mine returns the open code requests the user created or was asked to
+// review, on each forge they're logged in to, along with an error for each
+// forge that couldn't be asked. It's a variable so tests can swap it out.
+var Mine = func(debug bool) ([]Summary, []error) {
+	cf := configfile.NewConfigFile(debug)
+	config, err := cf.Load()
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	creds := credentials.NewCredentials(debug, cf, executor.NewExecutor(debug))
+	summaries := []Summary{}
+	errs := []error{}
+	seen := map[string]bool{}
+	covered := map[string]bool{}
+
+	add := func(forge, host, token string) {
+		var found []Summary
+		var err error
+		if forge == credentials.GitLab {
+			found, err = mineGitLab(gitlab.NewGitLabFromToken(debug, host, token), host)
+		} else {
+			found, err = mineGitHub(github.NewGitHubFromToken(debug, host, token), host)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not list code requests on %s: %w", host, err))
+			return
+		}
+
+		for _, summary := range found {
+			if !seen[summary.Role+" "+summary.URL] {
+				seen[summary.Role+" "+summary.URL] = true
+				summaries = append(summaries, summary)
+			}
+		}
+	}
+
+	for _, account := range config.Accounts {
+		covered[account.ForgeName()+"/"+account.Host] = true
+		token, _, err := creds.AccountToken(&account)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		add(account.ForgeName(), account.Host, token)
+	}
+
+	for _, forge := range []string{credentials.GitHub, credentials.GitLab} {
+		host := credentials.DefaultHost(forge)
+		if covered[forge+"/"+host] {
+			continue
+		}
+
+		token, _, err := creds.HostToken(forge, host)
+		if err != nil {
+			continue
+		}
+
+		add(forge, host, token)
+	}
+
+	return summaries, errs
+}
+
+func mineGitHub(client *github.GitHub, host string) ([]Summary, error) {
+	summaries := []Summary{}
+	queries := [][]string{
+		{RoleAuthor, "is:open is:pr archived:false author:@me"},
+		{RoleReviewer, "is:open is:pr archived:false review-requested:@me"},
+	}
+
+	for _, query := range queries {
+		issues, err := client.SearchPullRequests(query[1])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			owner, repo := path.Split(issue.GetRepositoryURL())
+			summaries = append(summaries, Summary{
+				Draft:  issue.GetDraft(),
+				Host:   host,
+				Number: issue.GetNumber(),
+				Repo:   path.Base(owner) + "/" + repo,
+				Role:   query[0],
+				Title:  issue.GetTitle(),
+				URL:    issue.GetHTMLURL(),
+			})
+		}
+	}
+
+	return summaries, nil
+}
+
+func mineGitLab(client *gitlab.GitLab, host string) ([]Summary, error) {
+	summaries := []Summary{}
+	for _, role := range []string{RoleAuthor, RoleReviewer} {
+		mrs, err := client.OpenMergeRequests(role == RoleReviewer)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mr := range mrs {
+			repo := ""
+			if mr.References != nil {
+				repo, _, _ = strings.Cut(mr.References.Full, "!")
+			}
+
+			summaries = append(summaries, Summary{
+				Draft:  mr.Draft,
+				Host:   host,
+				Number: int(mr.IID),
+				Repo:   repo,
+				Role:   role,
+				Title:  mr.Title,
+				URL:    mr.WebURL,
+			})
+		}
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,94 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/github"
+	"github.com/emmahsax/go-git-helper/internal/gitlab"
+	go_github "github.com/google/go-github/v84/github"
+	go_gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+func Test_Status_GitLab(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/projects/group/project/merge_requests/4":
+			fmt.Fprint(w, `{"iid": 4, "detailed_merge_status": "not_approved", "head_pipeline": {"id": 7, "status": "running"}}`)
+		case "/api/v4/projects/group/project/pipelines/7/jobs":
+			fmt.Fprint(w, `[{"name": "test", "status": "running"}, {"name": "lint", "status": "failed", "allow_failure": true}]`)
+		case "/api/v4/projects/group/project/merge_requests/4/approvals":
+			fmt.Fprint(w, `{"approvals_left": 1, "approved": false}`)
+		case "/api/v4/projects/group/project/merge_requests/4/discussions":
+			fmt.Fprint(w, `[{"id": "a", "notes": [{"resolvable": true, "resolved": false}]}]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	glClient, _ := go_gitlab.NewClient("", go_gitlab.WithBaseURL(server.URL))
+	gl := &gitLabClient{client: &gitlab.GitLab{Client: glClient}, project: "group/project"}
+
+	status, err := gl.Status(&CodeRequest{Number: 4, State: StateOpen, Title: "Fix things"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Review != ReviewRequired || status.UnresolvedThreads != 1 || status.Mergeable || len(status.MergeProblems) != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	if len(status.Checks) != 2 || status.Checks[0].State != CheckPending || status.Checks[1].State != CheckSuccess {
+		t.Errorf("expected a running job and a job that's allowed to fail, got %+v", status.Checks)
+	}
+}
+
+func Test_mineGitHub(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Query().Get("q"), "review-requested:@me") {
+			fmt.Fprint(w, `{"items": []}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"items": [{"number": 2, "title": "Add things", "draft": true, "repository_url": "https://api.github.com/repos/octocat/hello", "html_url": "https://github.com/octocat/hello/pull/2"}]}`)
+	}))
+	defer server.Close()
+
+	ghClient := go_github.NewClient(nil)
+	ghClient.BaseURL, _ = ghClient.BaseURL.Parse(server.URL + "/")
+
+	summaries, err := mineGitHub(&github.GitHub{Client: ghClient}, "github.com")
+	if err != nil || len(summaries) != 1 {
+		t.Fatalf("expected one pull request, got %+v (%v)", summaries, err)
+	}
+
+	expected := Summary{Draft: true, Host: "github.com", Number: 2, Repo: "octocat/hello", Role: RoleAuthor, Title: "Add things", URL: "https://github.com/octocat/hello/pull/2"}
+	if summaries[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, summaries[0])
+	}
+}
+
+func Test_mineGitLab(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v4/user":
+			fmt.Fprint(w, `{"username": "octocat"}`)
+		case r.URL.Query().Get("reviewer_username") == "octocat":
+			fmt.Fprint(w, `[{"iid": 4, "title": "Fix things", "references": {"full": "group/project!4"}, "web_url": "https://gitlab.com/group/project/-/merge_requests/4"}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer server.Close()
+
+	glClient, _ := go_gitlab.NewClient("", go_gitlab.WithBaseURL(server.URL))
+
+	summaries, err := mineGitLab(&gitlab.GitLab{Client: glClient}, "gitlab.com")
+	if err != nil || len(summaries) != 1 || summaries[0].Role != RoleReviewer || summaries[0].Repo != "group/project" || summaries[0].Number != 4 {
+		t.Errorf("expected a review request for group/project!4, got %+v (%v)", summaries, err)
+	}
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"strings"
+)
+
+// BranchExists reports whether there's a local branch called branch.
+func (g *Git) BranchExists(branch string) bool {
+	_, err := g.Executor.Exec("actionAndOutput", "git", "rev-parse", "--verify", "-q", "refs/heads/"+branch)
+	return err == nil
+}
+
+// CreateBranchAt creates branch at start without checking it out.
+func (g *Git) CreateBranchAt(branch, start string) error {
+	_, err := g.Executor.Exec("waitAndStdout", "git", "branch", "--no-track", branch, start)
+	return err
+}
+
+// FastForward moves the current branch up to ref, failing if it has commits
+// that ref doesn't.
+func (g *Git) FastForward(ref string) error {
+	_, err := g.Executor.Exec("waitAndStdout", "git", "merge", "--ff-only", ref)
+	return err
+}
+
+// FetchRef fetches ref from remote into FETCH_HEAD. The remote can be a name
+// or a URL.
+func (g *Git) FetchRef(remote, ref string) error {
+	_, err := g.Executor.Exec("waitAndStdout", "git", "fetch", remote, ref)
+	return err
+}
+
+// SetUpstream makes branch pull from, and push to, merge on remote.
+func (g *Git) SetUpstream(branch, remote, merge string) error {
+	_, err := g.Executor.Exec("actionAndOutput", "git", "config", "branch."+branch+".remote", remote)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.Executor.Exec("actionAndOutput", "git", "config", "branch."+branch+".merge", merge)
+	return err
+}
+
+// Upstream returns the remote and ref branch pulls from, which are empty when
+// it doesn't track anything.
+func (g *Git) Upstream(branch string) (string, string) {
+	return g.branchConfig(branch, "remote"), g.branchConfig(branch, "merge")
+}
+
+func (g *Git) branchConfig(branch, key string) string {
+	output, err := g.Executor.Exec("actionAndOutput", "git", "config", "--get", "branch."+branch+"."+key)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,37 @@
+package git
+
+import (
+	"testing"
+)
+
+func Test_BranchExists(t *testing.T) {
+	g := NewGit(true, &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git rev-parse --verify -q refs/heads/feature": "abc123\n",
+	}})
+
+	if !g.BranchExists("feature") || g.BranchExists("other") {
+		t.Error("expected only feature to exist")
+	}
+}
+
+func Test_Upstream(t *testing.T) {
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git config branch.feature.remote origin":          "",
+		"git config branch.feature.merge refs/pull/2/head": "",
+		"git config --get branch.feature.remote":           "origin\n",
+		"git config --get branch.feature.merge":            "refs/pull/2/head\n",
+	}}
+	g := NewGit(true, executor)
+
+	if err := g.SetUpstream("feature", "origin", "refs/pull/2/head"); err != nil {
+		t.Fatal(err)
+	}
+
+	if remote, merge := g.Upstream("feature"); remote != "origin" || merge != "refs/pull/2/head" {
+		t.Errorf("expected origin and refs/pull/2/head, got %q and %q", remote, merge)
+	}
+
+	if remote, merge := g.Upstream("other"); remote != "" || merge != "" {
+		t.Errorf("expected no upstream, got %q and %q", remote, merge)
+	}
+}
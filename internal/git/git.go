@@ -3,9 +3,12 @@ package git
 import (
 	"errors"
 	"fmt"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/emmahsax/go-git-helper/internal/commandline"
 	"github.com/emmahsax/go-git-helper/internal/executor"
 	"github.com/emmahsax/go-git-helper/internal/utils"
 )
@@ -90,14 +93,25 @@ func (g *Git) CurrentBranch() string {
 	return ""
 }
 
+// DefaultBranch returns the branch origin/HEAD points to. When it isn't set,
+// it discovers origin's default branch instead, and offers to set it.
 func (g *Git) DefaultBranch() string {
 	output, err := g.Executor.Exec("actionAndOutput", "git", "symbolic-ref", "refs/remotes/origin/HEAD")
 	if err != nil {
-		if strings.Contains(err.Error(), "fatal: ") {
+		branch, discoverErr := g.DiscoverDefaultBranch(false)
+		if discoverErr != nil {
 			fmt.Printf("\nYour symbolic ref is not set up properly. Please run:\n  git-helper set-head-ref [defaultBranch]\n\nAnd then try your command again.\n\n")
+			utils.HandleError(err, g.Debug, nil)
+			return ""
 		}
-		utils.HandleError(err, g.Debug, nil)
-		return ""
+
+		err = g.repairDefaultBranch(branch)
+		if err != nil {
+			utils.HandleError(err, g.Debug, nil)
+			return ""
+		}
+
+		return branch
 	}
 
 	branch := strings.SplitN(strings.TrimSpace(string(output)), "/", 4)
@@ -110,6 +124,79 @@ func (g *Git) DefaultBranch() string {
 	return branch[3]
 }
 
+// repairDefaultBranch offers to point origin/HEAD at the discovered default
+// branch. git remote set-head refuses branches origin doesn't have. Without a
+// terminal to ask on, like in scripts, it only warns, so the discovered branch
+// is still used.
+func (g *Git) repairDefaultBranch(branch string) error {
+	if !commandline.StdinIsTerminal() {
+		fmt.Fprintf(os.Stderr, "Warning: origin/HEAD isn't set, using origin's default branch %s. Set it with:\n  git remote set-head origin %s\n", branch, branch)
+		return nil
+	}
+
+	if !commandline.AskYesNoQuestion(fmt.Sprintf("Your symbolic ref is not set up, but origin's default branch is %s. Set origin/HEAD to it?", branch)) {
+		return nil
+	}
+
+	_, err := g.Executor.Exec("waitAndStdout", "git", "remote", "set-head", "origin", branch)
+	if err != nil {
+		return fmt.Errorf("could not set origin/HEAD to %s: %w", branch, err)
+	}
+
+	return nil
+}
+
+// ForgeDefaultBranch asks the remote's forge for the repository's default
+// branch. It's set by main, so this package doesn't depend on the forge
+// clients.
+var ForgeDefaultBranch func(debug bool, remote *Remote) (string, error)
+
+// DeleteBranch deletes the local branch, even if git doesn't consider it
+// merged, which is the case for squash merges.
+func (g *Git) DeleteBranch(branch string) error {
+	_, err := g.Executor.Exec("waitAndStdout", "git", "branch", "-D", branch)
+	return err
+}
+
+func (g *Git) DeleteRemoteBranch(branch string) error {
+	_, err := g.Executor.Exec("waitAndStdout", "git", "push", "origin", "--delete", branch)
+	return err
+}
+
+// DiscoverDefaultBranch finds origin's default branch by asking the remote,
+// and then the forge's API. With setHead, it tries `git remote set-head
+// origin --auto` first, which also sets origin/HEAD.
+func (g *Git) DiscoverDefaultBranch(setHead bool) (string, error) {
+	if setHead {
+		_, err := g.Executor.Exec("actionAndOutput", "git", "remote", "set-head", "origin", "--auto")
+		if err == nil {
+			output, err := g.Executor.Exec("actionAndOutput", "git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+			if branch := strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/"); err == nil && branch != "" {
+				return branch, nil
+			}
+		}
+	}
+
+	output, err := g.Executor.Exec("actionAndOutput", "git", "ls-remote", "--symref", "origin", "HEAD")
+	if err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			ref, found := strings.CutPrefix(line, "ref: refs/heads/")
+			if found && strings.HasSuffix(ref, "\tHEAD") {
+				return strings.TrimSuffix(ref, "\tHEAD"), nil
+			}
+		}
+	}
+
+	if remote, err := g.Remote("origin"); err == nil && ForgeDefaultBranch != nil {
+		branch, err := ForgeDefaultBranch(g.Debug, remote)
+		if err == nil && branch != "" {
+			return branch, nil
+		}
+	}
+
+	return "", errors.New("could not discover origin's default branch")
+}
+
 func (g *Git) Fetch() {
 	_, err := g.Executor.Exec("waitAndStdout", "git", "fetch", "-p")
 	if err != nil {
@@ -128,6 +215,16 @@ func (g *Git) GetGitRootDir() string {
 	return strings.TrimSpace(string(output))
 }
 
+// HeadCommit returns the SHA of the commit HEAD points to.
+func (g *Git) HeadCommit() (string, error) {
+	output, err := g.Executor.Exec("actionAndOutput", "git", "rev-parse", "HEAD")
+	if err != nil {
+		return "", errors.New("could not find the HEAD commit: " + strings.TrimSpace(string(output)))
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
 func (g *Git) Pull() {
 	_, err := g.Executor.Exec("waitAndStdout", "git", "pull")
 	if err != nil {
@@ -145,23 +242,19 @@ func (g *Git) PushBranch(branch string) {
 }
 
 func (g *Git) RepoName() string {
-	output, err := g.Executor.Exec("actionAndOutput", "git", "remote", "-v")
-	if err != nil {
-		utils.HandleError(err, g.Debug, nil)
+	remotes := g.PushRemotes()
+	if len(remotes) == 0 {
+		utils.HandleError(errors.New("no match found"), g.Debug, nil)
 		return ""
 	}
 
-	remoteURL := string(output)
-	re := regexp.MustCompile(`\S\s*\S+\.com\S{1}(\S*) \(push\)`)
-	match := re.FindStringSubmatch(remoteURL)
-	if len(match) >= 2 {
-		return strings.Split(match[1], ".git")[0]
-	} else {
-		err = errors.New("no match found")
-		utils.HandleError(err, g.Debug, nil)
-	}
+	return remotes[0].FullName()
+}
 
-	return ""
+// RemoteBranchExists reports whether origin still has the branch.
+func (g *Git) RemoteBranchExists(branch string) bool {
+	output, err := g.Executor.Exec("actionAndOutput", "git", "ls-remote", "--heads", "origin", "refs/heads/"+branch)
+	return err == nil && strings.TrimSpace(string(output)) != ""
 }
 
 func (g *Git) Remotes() []string {
@@ -207,3 +300,13 @@ func (g *Git) Stash() {
 func (g *Git) StashDrop() {
 	_, _ = g.Executor.Exec("waitAndStdout", "git", "stash", "drop")
 }
+
+// UnpushedCommits returns how many commits branch has that since doesn't.
+func (g *Git) UnpushedCommits(branch, since string) (int, error) {
+	output, err := g.Executor.Exec("actionAndOutput", "git", "rev-list", "--count", since+".."+branch)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
@@ -1,20 +1,39 @@
 package git
 
 import (
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/emmahsax/go-git-helper/internal/commandline"
 )
 
+// MockExecutor returns Output for every command, unless Outputs is set, in
+// which case it returns the output for each command line in Outputs and an
+// error for any command line that isn't there.
 type MockExecutor struct {
 	Args    []string
 	Command string
 	Debug   bool
 	Output  []byte
+	Outputs map[string]string
+	Run     []string
 }
 
 func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
 	me.Command = command
 	me.Args = args
-	return me.Output, nil
+	if me.Outputs == nil {
+		return me.Output, nil
+	}
+
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
 }
 
 func Test_Checkout(t *testing.T) {
@@ -505,6 +524,131 @@ func Test_Reset(t *testing.T) {
 	}
 }
 
+func Test_DefaultBranch_Repair(t *testing.T) {
+	originalAskYesNoQuestion := commandline.AskYesNoQuestion
+	originalStdinIsTerminal := commandline.StdinIsTerminal
+	t.Cleanup(func() {
+		commandline.AskYesNoQuestion = originalAskYesNoQuestion
+		commandline.StdinIsTerminal = originalStdinIsTerminal
+	})
+	commandline.StdinIsTerminal = func() bool {
+		return true
+	}
+
+	for _, repair := range []bool{true, false} {
+		commandline.AskYesNoQuestion = func(question string) bool {
+			return repair
+		}
+
+		executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+			"git ls-remote --symref origin HEAD": "ref: refs/heads/trunk\tHEAD\n0123abc\tHEAD\n",
+			"git remote set-head origin trunk":   "",
+		}}
+
+		if branch := NewGit(true, executor).DefaultBranch(); branch != "trunk" {
+			t.Errorf("expected the discovered branch trunk, got %s", branch)
+		}
+
+		repaired := strings.Contains(strings.Join(executor.Run, "\n"), "git remote set-head origin trunk")
+		if repaired != repair {
+			t.Errorf("expected repairing to be %t, got %v", repair, executor.Run)
+		}
+	}
+}
+
+func Test_repairDefaultBranch(t *testing.T) {
+	originalAskYesNoQuestion := commandline.AskYesNoQuestion
+	originalStdinIsTerminal := commandline.StdinIsTerminal
+	t.Cleanup(func() {
+		commandline.AskYesNoQuestion = originalAskYesNoQuestion
+		commandline.StdinIsTerminal = originalStdinIsTerminal
+	})
+	commandline.AskYesNoQuestion = func(question string) bool {
+		return true
+	}
+
+	commandline.StdinIsTerminal = func() bool {
+		return false
+	}
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{}}
+	err := NewGit(true, executor).repairDefaultBranch("trunk")
+	if err != nil || len(executor.Run) > 0 {
+		t.Errorf("expected only a warning without a terminal and nothing run, got %v after %v", err, executor.Run)
+	}
+
+	executor = &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git ls-remote --symref origin HEAD": "ref: refs/heads/trunk\tHEAD\n0123abc\tHEAD\n",
+	}}
+	if branch := NewGit(true, executor).DefaultBranch(); branch != "trunk" {
+		t.Errorf("expected the discovered branch trunk without a terminal, got %s", branch)
+	}
+
+	commandline.StdinIsTerminal = func() bool {
+		return true
+	}
+	err = NewGit(true, executor).repairDefaultBranch("missing")
+	if err == nil || !strings.Contains(err.Error(), "could not set origin/HEAD to missing") {
+		t.Errorf("expected a branch origin doesn't have to be refused, got %v", err)
+	}
+}
+
+func Test_DiscoverDefaultBranch(t *testing.T) {
+	originalForgeDefaultBranch := ForgeDefaultBranch
+	t.Cleanup(func() {
+		ForgeDefaultBranch = originalForgeDefaultBranch
+	})
+	ForgeDefaultBranch = func(debug bool, remote *Remote) (string, error) {
+		if remote.FullName() != "octocat/hello" {
+			return "", errors.New("unexpected remote " + remote.FullName())
+		}
+		return "from-api", nil
+	}
+
+	tests := []struct {
+		name     string
+		setHead  bool
+		outputs  map[string]string
+		expected string
+		err      bool
+	}{
+		{
+			name:    "set-head --auto",
+			setHead: true,
+			outputs: map[string]string{
+				"git remote set-head origin --auto":                 "origin/HEAD set to main\n",
+				"git symbolic-ref --short refs/remotes/origin/HEAD": "origin/main\n",
+			},
+			expected: "main",
+		},
+		{
+			name:     "ls-remote",
+			setHead:  true,
+			outputs:  map[string]string{"git ls-remote --symref origin HEAD": "ref: refs/heads/release/v2\tHEAD\n0123abc\tHEAD\n"},
+			expected: "release/v2",
+		},
+		{
+			name:     "forge API",
+			outputs:  map[string]string{"git remote get-url --push origin": "git@github.com:octocat/hello.git\n"},
+			expected: "from-api",
+		},
+		{
+			name:    "nothing works",
+			outputs: map[string]string{},
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executor := &MockExecutor{Debug: true, Outputs: test.outputs}
+			branch, err := NewGit(true, executor).DiscoverDefaultBranch(test.setHead)
+			if (err != nil) != test.err || branch != test.expected {
+				t.Errorf("expected %q, got %q (%v)", test.expected, branch, err)
+			}
+		})
+	}
+}
+
 func Test_SetHeadRef(t *testing.T) {
 	tests := []struct {
 		expectedArgs []string
@@ -591,3 +735,26 @@ func Test_StashDrop(t *testing.T) {
 		}
 	}
 }
+
+func Test_RemoteBranchExists(t *testing.T) {
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git ls-remote --heads origin refs/heads/feature": "abc123\trefs/heads/feature\n",
+		"git ls-remote --heads origin refs/heads/gone":    "",
+	}}
+	g := NewGit(true, executor)
+
+	if !g.RemoteBranchExists("feature") || g.RemoteBranchExists("gone") {
+		t.Errorf("expected only feature to exist on origin")
+	}
+}
+
+func Test_UnpushedCommits(t *testing.T) {
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git rev-list --count origin/feature..feature": "3\n",
+	}}
+
+	count, err := NewGit(true, executor).UnpushedCommits("feature", "origin/feature")
+	if err != nil || count != 3 {
+		t.Errorf("expected 3 unpushed commits, got %d (%v)", count, err)
+	}
+}
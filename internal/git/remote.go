@@ -0,0 +1,107 @@
+package git
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Remote is a git remote's URL broken into the parts the forges care about.
+// For GitLab subgroups, Owner holds the full namespace, like group/subgroup.
+type Remote struct {
+	Host     string
+	Name     string
+	Owner    string
+	Protocol string
+	Repo     string
+	URL      string
+}
+
+// FullName returns the remote's owner/repo path.
+func (r *Remote) FullName() string {
+	return r.Owner + "/" + r.Repo
+}
+
+// ParseRemoteURL parses SSH URLs like git@github.com:owner/repo.git or
+// ssh://git@host:2222/owner/repo, and HTTP(S) URLs like
+// https://host/owner/repo.git.
+func ParseRemoteURL(remoteURL string) (*Remote, error) {
+	remote := &Remote{URL: remoteURL}
+	var repoPath string
+
+	if strings.Contains(remoteURL, "://") {
+		u, err := url.Parse(remoteURL)
+		if err != nil {
+			return nil, errors.New("could not parse remote URL " + remoteURL + ": " + err.Error())
+		}
+
+		remote.Protocol = strings.TrimPrefix(u.Scheme, "git+")
+		remote.Host = u.Host
+		if remote.Protocol == "ssh" {
+			remote.Host = u.Hostname()
+		}
+		repoPath = u.Path
+	} else {
+		hostPart, pathPart, found := strings.Cut(remoteURL, ":")
+		if !found {
+			return nil, errors.New("could not parse remote URL " + remoteURL)
+		}
+
+		remote.Protocol = "ssh"
+		remote.Host = hostPart[strings.LastIndex(hostPart, "@")+1:]
+		repoPath = pathPart
+	}
+
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	slash := strings.LastIndex(repoPath, "/")
+	if remote.Host == "" || slash <= 0 || slash == len(repoPath)-1 {
+		return nil, errors.New("could not find an owner and repository in remote URL " + remoteURL)
+	}
+
+	remote.Owner = repoPath[:slash]
+	remote.Repo = repoPath[slash+1:]
+	return remote, nil
+}
+
+// PushRemotes returns every remote whose push URL can be parsed, with origin
+// first.
+func (g *Git) PushRemotes() []*Remote {
+	remotes := []*Remote{}
+
+	for _, line := range g.Remotes() {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[2] != "(push)" {
+			continue
+		}
+
+		remote, err := ParseRemoteURL(fields[1])
+		if err != nil {
+			continue
+		}
+
+		remote.Name = fields[0]
+		if remote.Name == "origin" {
+			remotes = append([]*Remote{remote}, remotes...)
+		} else {
+			remotes = append(remotes, remote)
+		}
+	}
+
+	return remotes
+}
+
+// Remote returns the remote called name, parsed from its push URL.
+func (g *Git) Remote(name string) (*Remote, error) {
+	output, err := g.Executor.Exec("actionAndOutput", "git", "remote", "get-url", "--push", name)
+	if err != nil {
+		return nil, errors.New("no remote called " + name)
+	}
+
+	remote, err := ParseRemoteURL(strings.TrimSpace(string(output)))
+	if err != nil {
+		return nil, err
+	}
+
+	remote.Name = name
+	return remote, nil
+}
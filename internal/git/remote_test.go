@@ -0,0 +1,62 @@
+package git
+
+import (
+	"testing"
+)
+
+func Test_ParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected Remote
+	}{
+		{url: "git@github.com:emmahsax/go-git-helper.git", expected: Remote{Host: "github.com", Owner: "emmahsax", Protocol: "ssh", Repo: "go-git-helper"}},
+		{url: "github.com:emmahsax/go-git-helper", expected: Remote{Host: "github.com", Owner: "emmahsax", Protocol: "ssh", Repo: "go-git-helper"}},
+		{url: "ssh://git@git.example.com:2222/acme/tools.git", expected: Remote{Host: "git.example.com", Owner: "acme", Protocol: "ssh", Repo: "tools"}},
+		{url: "https://github.com/emmahsax/go-git-helper", expected: Remote{Host: "github.com", Owner: "emmahsax", Protocol: "https", Repo: "go-git-helper"}},
+		{url: "https://user@gitlab.example.com:8443/group/subgroup/project.git/", expected: Remote{Host: "gitlab.example.com:8443", Owner: "group/subgroup", Protocol: "https", Repo: "project"}},
+	}
+
+	for _, test := range tests {
+		remote, err := ParseRemoteURL(test.url)
+		if err != nil {
+			t.Errorf("unexpected error parsing %s: %v", test.url, err)
+			continue
+		}
+
+		test.expected.URL = test.url
+		if *remote != test.expected {
+			t.Errorf("expected %+v, got %+v", test.expected, *remote)
+		}
+	}
+
+	for _, url := range []string{"/local/path/repo", "https://github.com/onlyowner", "git@github.com:"} {
+		if _, err := ParseRemoteURL(url); err == nil {
+			t.Errorf("expected an error parsing %s", url)
+		}
+	}
+}
+
+func Test_PushRemotes(t *testing.T) {
+	executor := &MockExecutor{
+		Debug: true,
+		Output: []byte(`fork  git@github.com:someone/go-git-helper.git (fetch)
+fork  git@github.com:someone/go-git-helper.git (push)
+local  /tmp/repo (push)
+origin  git@github.com:emmahsax/go-git-helper.git (fetch)
+origin  git@github.com:emmahsax/go-git-helper.git (push)`),
+	}
+
+	remotes := NewGit(true, executor).PushRemotes()
+
+	if len(remotes) != 2 {
+		t.Fatalf("expected 2 remotes, got %d", len(remotes))
+	}
+
+	if remotes[0].Name != "origin" || remotes[0].FullName() != "emmahsax/go-git-helper" {
+		t.Errorf("expected origin first, got %+v", remotes[0])
+	}
+
+	if remotes[1].Name != "fork" || remotes[1].Owner != "someone" {
+		t.Errorf("unexpected second remote %+v", remotes[1])
+	}
+}
@@ -0,0 +1,64 @@
+package git
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FindRepos returns the git repositories at or under root, without looking
+// inside repositories or hidden directories. A maxDepth of 1 only looks at
+// root's own subdirectories, and 0 has no limit.
+func FindRepos(root string, maxDepth int) ([]string, error) {
+	repos := []string{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return fs.SkipDir
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			return fs.SkipDir
+		}
+
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			repos = append(repos, path)
+			return fs.SkipDir
+		}
+
+		if maxDepth > 0 && depth(root, path) >= maxDepth {
+			return fs.SkipDir
+		}
+
+		return nil
+	})
+
+	return repos, err
+}
+
+// RepoName is the repository's path under baseDir, with forward slashes.
+func RepoName(baseDir, dir string) string {
+	rel, err := filepath.Rel(baseDir, dir)
+	if err != nil || rel == "." {
+		return filepath.Base(dir)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+func depth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
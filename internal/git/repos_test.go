@@ -0,0 +1,56 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_FindRepos(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{
+		"hello/.git",
+		"hello/vendor/nested/.git",
+		"work/api/.git",
+		"work/deep/er/tool/.git",
+		".cache/hidden/.git",
+		"notes",
+	} {
+		os.MkdirAll(filepath.Join(root, dir), 0755)
+	}
+	os.WriteFile(filepath.Join(root, "work", "worktree"), nil, 0644)
+	os.MkdirAll(filepath.Join(root, "work", "linked"), 0755)
+	os.WriteFile(filepath.Join(root, "work", "linked", ".git"), []byte("gitdir: elsewhere\n"), 0644)
+
+	tests := []struct {
+		depth    int
+		expected []string
+	}{
+		{depth: 0, expected: []string{"hello", "work/api", "work/deep/er/tool", "work/linked"}},
+		{depth: 1, expected: []string{"hello"}},
+		{depth: 2, expected: []string{"hello", "work/api", "work/linked"}},
+	}
+
+	for _, test := range tests {
+		repos, err := FindRepos(root, test.depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := []string{}
+		for _, repo := range repos {
+			rel, _ := filepath.Rel(root, repo)
+			actual = append(actual, filepath.ToSlash(rel))
+		}
+
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("depth %d: expected %v, got %v", test.depth, test.expected, actual)
+		}
+	}
+
+	repos, err := FindRepos(filepath.Join(root, "hello"), 0)
+	if err != nil || len(repos) != 1 || repos[0] != filepath.Join(root, "hello") {
+		t.Errorf("expected the root to be returned when it's a repository, got %v (%v)", repos, err)
+	}
+}
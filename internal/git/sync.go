@@ -0,0 +1,140 @@
+package git
+
+import (
+	"strings"
+)
+
+// syncStashKey is the git config key recording the stash a sync made, while
+// the sync is stopped on conflicts.
+const syncStashKey = "git-helper.sync-stash"
+
+// Operations git can stop in the middle of, waiting on conflicts to be
+// resolved.
+const (
+	OperationMerge  = "merge"
+	OperationRebase = "rebase"
+)
+
+// Abort abandons the merge or rebase in progress.
+func (g *Git) Abort(operation string) error {
+	_, err := g.Executor.Exec("waitAndStdout", "git", operation, "--abort")
+	return err
+}
+
+// ConflictedFiles returns the files with unresolved conflicts.
+func (g *Git) ConflictedFiles() []string {
+	output, err := g.Executor.Exec("actionAndOutput", "git", "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return []string{}
+	}
+
+	return strings.Fields(string(output))
+}
+
+// Continue carries on with the merge or rebase in progress, keeping the
+// commit messages git suggests instead of opening an editor.
+func (g *Git) Continue(operation string) error {
+	_, err := g.Executor.Exec("waitAndStdout", "git", "-c", "core.editor=true", operation, "--continue")
+	return err
+}
+
+// ForcePushBranch pushes the branch to origin, refusing to overwrite commits
+// on origin that haven't been fetched.
+func (g *Git) ForcePushBranch(branch string) error {
+	_, err := g.Executor.Exec("waitAndStdout", "git", "push", "--force-with-lease", "--set-upstream", "origin", branch)
+	return err
+}
+
+// InProgress returns the merge or rebase that stopped on conflicts, or "" when
+// there isn't one.
+func (g *Git) InProgress() string {
+	if _, err := g.Executor.Exec("actionAndOutput", "git", "rev-parse", "-q", "--verify", "REBASE_HEAD"); err == nil {
+		return OperationRebase
+	}
+
+	if _, err := g.Executor.Exec("actionAndOutput", "git", "rev-parse", "-q", "--verify", "MERGE_HEAD"); err == nil {
+		return OperationMerge
+	}
+
+	return ""
+}
+
+// ClearSyncStash forgets the stash a sync made.
+func (g *Git) ClearSyncStash() {
+	_, _ = g.Executor.Exec("actionAndOutput", "git", "config", "--unset", syncStashKey)
+}
+
+func (g *Git) Merge(ref string) error {
+	_, err := g.Executor.Exec("waitAndStdout", "git", "merge", "--no-edit", ref)
+	return err
+}
+
+func (g *Git) Rebase(ref string) error {
+	_, err := g.Executor.Exec("waitAndStdout", "git", "rebase", ref)
+	return err
+}
+
+// SetSyncStash records the stash a sync made, so finishing or aborting the
+// sync later restores that stash and no other.
+func (g *Git) SetSyncStash(sha string) error {
+	_, err := g.Executor.Exec("actionAndOutput", "git", "config", syncStashKey, sha)
+	return err
+}
+
+// StashPop restores the stash whose commit is sha, and returns its stash@{n}
+// ref, or "" when it's no longer in the stash list. Other stashes, even ones
+// with the same message, are left alone.
+func (g *Git) StashPop(sha string) (string, error) {
+	output, err := g.Executor.Exec("actionAndOutput", "git", "stash", "list", "--format=%gd %H")
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		ref, commit, _ := strings.Cut(line, " ")
+		if sha == "" || commit != sha {
+			continue
+		}
+
+		_, err = g.Executor.Exec("waitAndStdout", "git", "stash", "pop", ref)
+		return ref, err
+	}
+
+	return "", nil
+}
+
+// SyncStash returns the commit of the stash a sync made, or "" when it didn't
+// make one.
+func (g *Git) SyncStash() string {
+	output, err := g.Executor.Exec("actionAndOutput", "git", "config", "--get", syncStashKey)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+// StashPush stashes the changes to tracked files with message, and returns the
+// new stash's commit, or "" when there was nothing to stash.
+func (g *Git) StashPush(message string) (string, error) {
+	output, err := g.Executor.Exec("actionAndOutput", "git", "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(string(output)) == "" {
+		return "", nil
+	}
+
+	_, err = g.Executor.Exec("waitAndStdout", "git", "stash", "push", "-m", message)
+	if err != nil {
+		return "", err
+	}
+
+	output, err = g.Executor.Exec("actionAndOutput", "git", "rev-parse", "-q", "--verify", "refs/stash")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
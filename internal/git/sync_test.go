@@ -0,0 +1,96 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_InProgress(t *testing.T) {
+	tests := []struct {
+		outputs  map[string]string
+		expected string
+	}{
+		{outputs: map[string]string{"git rev-parse -q --verify REBASE_HEAD": "abc123\n"}, expected: OperationRebase},
+		{outputs: map[string]string{"git rev-parse -q --verify MERGE_HEAD": "abc123\n"}, expected: OperationMerge},
+		{outputs: map[string]string{}, expected: ""},
+	}
+
+	for _, test := range tests {
+		g := NewGit(true, &MockExecutor{Debug: true, Outputs: test.outputs})
+		if operation := g.InProgress(); operation != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, operation)
+		}
+	}
+}
+
+func Test_ConflictedFiles(t *testing.T) {
+	g := NewGit(true, &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git diff --name-only --diff-filter=U": "README.md\nmain.go\n",
+	}})
+
+	if files := g.ConflictedFiles(); !reflect.DeepEqual(files, []string{"README.md", "main.go"}) {
+		t.Errorf("expected both conflicted files, got %v", files)
+	}
+}
+
+func Test_StashPush(t *testing.T) {
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git status --porcelain --untracked-files=no": "",
+	}}
+	sha, err := NewGit(true, executor).StashPush("git-helper sync")
+	if sha != "" || err != nil || len(executor.Run) != 1 {
+		t.Errorf("expected nothing to be stashed, got '%s' (%v) after %v", sha, err, executor.Run)
+	}
+
+	executor = &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git status --porcelain --untracked-files=no": " M main.go\n",
+		"git stash push -m git-helper sync":           "",
+		"git rev-parse -q --verify refs/stash":        "def456\n",
+	}}
+	sha, err = NewGit(true, executor).StashPush("git-helper sync")
+	if sha != "def456" || err != nil {
+		t.Errorf("expected the new stash's commit, got '%s' (%v) after %v", sha, err, executor.Run)
+	}
+}
+
+func Test_StashPop(t *testing.T) {
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git stash list --format=%gd %H": "stash@{0} abc123\nstash@{1} def456\nstash@{2} 789abc\n",
+		"git stash pop stash@{1}":        "",
+	}}
+
+	ref, err := NewGit(true, executor).StashPop("def456")
+	if ref != "stash@{1}" || err != nil || executor.Run[len(executor.Run)-1] != "git stash pop stash@{1}" {
+		t.Errorf("expected only the sync's own stash to be popped, got '%s' (%v) after %v", ref, err, executor.Run)
+	}
+
+	for _, sha := range []string{"fff000", ""} {
+		ref, err = NewGit(true, executor).StashPop(sha)
+		if ref != "" || err != nil {
+			t.Errorf("expected no stash to be popped for '%s', got '%s' (%v)", sha, ref, err)
+		}
+	}
+}
+
+func Test_SyncStash(t *testing.T) {
+	executor := &MockExecutor{Debug: true, Outputs: map[string]string{
+		"git config git-helper.sync-stash def456":  "",
+		"git config --get git-helper.sync-stash":   "def456\n",
+		"git config --unset git-helper.sync-stash": "",
+	}}
+	g := NewGit(true, executor)
+
+	if err := g.SetSyncStash("def456"); err != nil {
+		t.Fatal(err)
+	}
+
+	if sha := g.SyncStash(); sha != "def456" {
+		t.Errorf("expected the recorded stash, got '%s'", sha)
+	}
+
+	g.ClearSyncStash()
+	delete(executor.Outputs, "git config --get git-helper.sync-stash")
+	if sha := g.SyncStash(); sha != "" || executor.Run[len(executor.Run)-2] != "git config --unset git-helper.sync-stash" {
+		t.Errorf("expected the stash to be forgotten, got '%s' after %v", sha, executor.Run)
+	}
+}
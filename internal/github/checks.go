@@ -0,0 +1,139 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v84/github"
+)
+
+// Check states, shared by commit statuses and check runs.
+const (
+	CheckFailure = "failure"
+	CheckPending = "pending"
+	CheckSuccess = "success"
+)
+
+// Check is one commit status or check run on a commit. Only check runs have
+// an ID.
+type Check struct {
+	ID    int64
+	Name  string
+	State string
+	URL   string
+}
+
+// RateLimit is how many API requests are left until the limit resets.
+type RateLimit struct {
+	Remaining int
+	Reset     time.Time
+}
+
+// Checks returns the commit statuses and check runs for ref.
+func (c *GitHub) Checks(owner, repo, ref string) ([]Check, error) {
+	checks := []Check{}
+	ctx := context.Background()
+
+	status, resp, err := c.Client.Repositories.GetCombinedStatus(ctx, owner, repo, ref, &github.ListOptions{PerPage: 100})
+	c.recordRate(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range status.Statuses {
+		state := s.GetState()
+		if state == "error" {
+			state = CheckFailure
+		}
+		checks = append(checks, Check{Name: s.GetContext(), State: state, URL: s.GetTargetURL()})
+	}
+
+	runs, resp, err := c.Client.Checks.ListCheckRunsForRef(ctx, owner, repo, ref, &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}})
+	c.recordRate(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, run := range runs.CheckRuns {
+		checks = append(checks, Check{ID: run.GetID(), Name: run.GetName(), State: checkRunState(run), URL: run.GetHTMLURL()})
+	}
+
+	return checks, nil
+}
+
+func checkRunState(run *github.CheckRun) string {
+	if run.GetStatus() != "completed" {
+		return CheckPending
+	}
+
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return CheckSuccess
+	default:
+		return CheckFailure
+	}
+}
+
+// JobLog returns the log of the GitHub Actions job behind a check run. Check
+// runs from other apps don't have logs GitHub can return.
+func (c *GitHub) JobLog(owner, repo string, jobID int64) (string, error) {
+	logURL, resp, err := c.Client.Actions.GetWorkflowJobLogs(context.Background(), owner, repo, jobID, 3)
+	c.recordRate(resp)
+	if err != nil {
+		return "", err
+	}
+
+	// The log is on a pre-signed URL, which mustn't be sent the token.
+	logResp, err := http.Get(logURL.String())
+	if err != nil {
+		return "", err
+	}
+	defer logResp.Body.Close()
+
+	if logResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not download the log: %s", logResp.Status)
+	}
+
+	log, err := io.ReadAll(logResp.Body)
+	return string(log), err
+}
+
+// RateLimit returns the rate limit from the last response, or false when
+// there hasn't been one.
+func (c *GitHub) RateLimit() (RateLimit, bool) {
+	if c.rate == nil {
+		return RateLimit{}, false
+	}
+
+	return *c.rate, true
+}
+
+// RetryAfter returns when to try again after err, when it's a rate limit
+// error.
+func RetryAfter(err error) (time.Time, bool) {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return rateErr.Rate.Reset.Time, true
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		wait := abuseErr.GetRetryAfter()
+		if wait <= 0 {
+			wait = time.Minute
+		}
+		return time.Now().Add(wait), true
+	}
+
+	return time.Time{}, false
+}
+
+func (c *GitHub) recordRate(resp *github.Response) {
+	if resp != nil && resp.Rate.Limit > 0 {
+		c.rate = &RateLimit{Remaining: resp.Rate.Remaining, Reset: resp.Rate.Reset.Time}
+	}
+}
@@ -0,0 +1,120 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v84/github"
+)
+
+func Test_Checks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/commits/abc123/status":
+			fmt.Fprint(w, `{"statuses": [{"context": "ci/lint", "state": "error"}, {"context": "ci/docs", "state": "success"}]}`)
+		case "/repos/octocat/hello/commits/abc123/check-runs":
+			fmt.Fprint(w, `{"check_runs": [
+				{"name": "test", "status": "in_progress"},
+				{"name": "build", "status": "completed", "conclusion": "skipped"},
+				{"name": "deploy", "status": "completed", "conclusion": "timed_out"}
+			]}`)
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	checks, err := gh.Checks("octocat", "hello", "abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"ci/lint": CheckFailure, "ci/docs": CheckSuccess, "test": CheckPending, "build": CheckSuccess, "deploy": CheckFailure}
+	if len(checks) != len(expected) {
+		t.Fatalf("Expected %d checks, got %v", len(expected), checks)
+	}
+
+	for _, check := range checks {
+		if expected[check.Name] != check.State {
+			t.Errorf("Expected %s to be %s, got %s", check.Name, expected[check.Name], check.State)
+		}
+	}
+}
+
+func Test_Checks_RateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	if _, ok := gh.RateLimit(); ok {
+		t.Error("Expected no rate limit before any requests")
+	}
+
+	if _, err := gh.Checks("octocat", "hello", "abc123"); err != nil {
+		t.Fatal(err)
+	}
+
+	rate, ok := gh.RateLimit()
+	if !ok || rate.Remaining != 42 || !rate.Reset.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Expected the rate limit from the headers, got %+v", rate)
+	}
+}
+
+func Test_JobLog(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/actions/jobs/9/logs":
+			http.Redirect(w, r, server.URL+"/signed/log.txt", http.StatusFound)
+		case "/signed/log.txt":
+			if r.Header.Get("Authorization") != "" {
+				t.Error("Expected the log to be downloaded without the token")
+			}
+			fmt.Fprint(w, "step 1\nstep 2\n")
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	log, err := gh.JobLog("octocat", "hello", 9)
+	if err != nil || log != "step 1\nstep 2\n" {
+		t.Errorf("Expected the job's log, got %q (%v)", log, err)
+	}
+}
+
+func Test_RetryAfter(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	rateErr := &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}}
+	if until, ok := RetryAfter(fmt.Errorf("wrapped: %w", rateErr)); !ok || !until.Equal(reset) {
+		t.Errorf("Expected to retry at %v, got %v %v", reset, until, ok)
+	}
+
+	if until, ok := RetryAfter(&github.AbuseRateLimitError{}); !ok || until.Before(time.Now()) {
+		t.Errorf("Expected to retry later, got %v %v", until, ok)
+	}
+
+	if _, ok := RetryAfter(errors.New("404 Not Found")); ok {
+		t.Error("Expected other errors not to be retried")
+	}
+}
@@ -0,0 +1,44 @@
+package github
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v84/github"
+)
+
+// ConvertToDraft turns the pull request back into a draft. GitHub only offers
+// this over GraphQL.
+func (c *GitHub) ConvertToDraft(nodeID string) error {
+	mutation := `mutation($input: ConvertPullRequestToDraftInput!) {
+  convertPullRequestToDraft(input: $input) { clientMutationId }
+}`
+
+	return c.GraphQL(mutation, map[string]any{"input": map[string]any{"pullRequestId": nodeID}}, nil)
+}
+
+// MarkReadyForReview takes the pull request out of draft. GitHub only offers
+// this over GraphQL.
+func (c *GitHub) MarkReadyForReview(nodeID string) error {
+	mutation := `mutation($input: MarkPullRequestReadyForReviewInput!) {
+  markPullRequestReadyForReview(input: $input) { clientMutationId }
+}`
+
+	return c.GraphQL(mutation, map[string]any{"input": map[string]any{"pullRequestId": nodeID}}, nil)
+}
+
+// RequestReviewers asks each reviewer to review the pull request. Reviewers
+// written as org/team are requested as teams.
+func (c *GitHub) RequestReviewers(owner, repo string, number int, reviewers []string) error {
+	request := github.ReviewersRequest{}
+	for _, reviewer := range reviewers {
+		if _, team, ok := strings.Cut(reviewer, "/"); ok {
+			request.TeamReviewers = append(request.TeamReviewers, team)
+		} else {
+			request.Reviewers = append(request.Reviewers, reviewer)
+		}
+	}
+
+	_, _, err := c.Client.PullRequests.RequestReviewers(context.Background(), owner, repo, number, request)
+	return err
+}
@@ -0,0 +1,74 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v84/github"
+)
+
+func Test_MarkReadyForReview(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string `json:"query"`
+			Variables struct {
+				Input map[string]string `json:"input"`
+			} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Variables.Input["pullRequestId"] != "PR_1" {
+			t.Errorf("Unexpected variables %v", body.Variables)
+		}
+
+		queries = append(queries, body.Query)
+		fmt.Fprint(w, `{"data": {}}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	if err := gh.MarkReadyForReview("PR_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gh.ConvertToDraft("PR_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(queries) != 2 || !strings.Contains(queries[0], "markPullRequestReadyForReview") || !strings.Contains(queries[1], "convertPullRequestToDraft") {
+		t.Errorf("Unexpected mutations %v", queries)
+	}
+}
+
+func Test_RequestReviewers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/repos/octocat/hello/pulls/2/requested_reviewers" {
+			t.Errorf("Unexpected request to %s %s", r.Method, r.URL.Path)
+		}
+
+		var body github.ReviewersRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if !reflect.DeepEqual(body.Reviewers, []string{"hubot"}) || !reflect.DeepEqual(body.TeamReviewers, []string{"reviewers"}) {
+			t.Errorf("Unexpected reviewers %+v", body)
+		}
+
+		fmt.Fprint(w, `{"number": 2}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	if err := gh.RequestReviewers("octocat", "hello", 2, []string{"hubot", "octo-org/reviewers"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,10 +2,15 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/executor"
 	"github.com/emmahsax/go-git-helper/internal/utils"
 	"github.com/google/go-github/v84/github"
 	"golang.org/x/oauth2"
@@ -14,11 +19,35 @@ import (
 type GitHub struct {
 	Debug  bool
 	Client *github.Client
+	rate   *RateLimit
 }
 
-func NewGitHub(debugB bool) *GitHub {
+type TokenInfo struct {
+	ExpiresAt *time.Time
+	Scopes    []string
+	Username  string
+}
+
+// NewGitHub returns a client for host, authenticated as the account for
+// repositories owned by owner.
+func NewGitHub(debugB bool, host, owner string) *GitHub {
 	cf := configfile.NewConfigFile(debugB)
-	c := newGitHubClient(cf.GitHubToken())
+	token, _, err := credentials.NewCredentials(debugB, cf, executor.NewExecutor(debugB)).Token(credentials.GitHub, host, owner)
+	if err != nil {
+		utils.HandleError(err, debugB, nil)
+		return nil
+	}
+
+	return NewGitHubFromToken(debugB, host, token)
+}
+
+func NewGitHubFromToken(debugB bool, host, token string) *GitHub {
+	c, err := newGitHubClient(host, token)
+	if err != nil {
+		customErr := errors.New("could not create GitHub client: " + err.Error())
+		utils.HandleError(customErr, debugB, nil)
+		return nil
+	}
 
 	return &GitHub{
 		Debug:  debugB,
@@ -48,10 +77,92 @@ func (c *GitHub) CreatePullRequest(owner, repo string, options *github.NewPullRe
 	return pr, nil
 }
 
-func newGitHubClient(token string) *github.Client {
+// DefaultBranch returns the repository's default branch.
+func (c *GitHub) DefaultBranch(owner, repo string) (string, error) {
+	repository, _, err := c.Client.Repositories.Get(context.Background(), owner, repo)
+	if err != nil {
+		return "", err
+	}
+
+	return repository.GetDefaultBranch(), nil
+}
+
+// PullRequestForBranch returns the newest pull request from branch, preferring
+// an open one, or nil when there isn't one.
+func (c *GitHub) PullRequestForBranch(owner, repo, branch string) (*github.PullRequest, error) {
+	options := &github.PullRequestListOptions{
+		Direction: "desc",
+		Head:      owner + ":" + branch,
+		Sort:      "created",
+		State:     "all",
+	}
+
+	prs, _, err := c.Client.PullRequests.List(context.Background(), owner, repo, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pr := range prs {
+		if pr.GetState() == "open" {
+			return pr, nil
+		}
+	}
+
+	if len(prs) == 0 {
+		return nil, nil
+	}
+
+	return prs[0], nil
+}
+
+// UpdatePullRequestBase changes the branch the pull request merges into.
+func (c *GitHub) UpdatePullRequestBase(owner, repo string, number int, base string) error {
+	pr := &github.PullRequest{Base: &github.PullRequestBranch{Ref: github.Ptr(base)}}
+	_, _, err := c.Client.PullRequests.Edit(context.Background(), owner, repo, number, pr)
+	return err
+}
+
+// TokenInfo returns who the client's token belongs to, along with its scopes
+// and expiry when GitHub reports them. Fine-grained tokens have no scopes, so
+// Scopes is nil for them.
+func (c *GitHub) TokenInfo() (*TokenInfo, error) {
+	user, resp, err := c.Client.Users.Get(context.Background(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &TokenInfo{Username: user.GetLogin()}
+
+	if header, ok := resp.Header[http.CanonicalHeaderKey("X-OAuth-Scopes")]; ok {
+		info.Scopes = []string{}
+		for _, scope := range strings.Split(strings.Join(header, ","), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				info.Scopes = append(info.Scopes, scope)
+			}
+		}
+	}
+
+	if expiry := resp.Header.Get("GitHub-Authentication-Token-Expiration"); expiry != "" {
+		expiresAt, err := time.Parse("2006-01-02 15:04:05 MST", expiry)
+		if err == nil {
+			info.ExpiresAt = &expiresAt
+		}
+	}
+
+	return info, nil
+}
+
+// newGitHubClient talks to the GitHub Enterprise Server API for any host
+// other than github.com.
+func newGitHubClient(host, token string) (*github.Client, error) {
 	ctx := context.Background()
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
 	tc := oauth2.NewClient(ctx, ts)
 	git := github.NewClient(tc)
-	return git
+
+	if host == "" || host == "github.com" {
+		return git, nil
+	}
+
+	return git.WithEnterpriseURLs("https://"+host+"/api/v3/", "https://"+host+"/api/uploads/")
 }
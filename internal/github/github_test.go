@@ -2,9 +2,11 @@ package github
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/google/go-github/v84/github"
@@ -18,7 +20,7 @@ func Test_NewGitHub(t *testing.T) {
 
 	// This test requires a valid config file with GitHub token
 	// We'll test that the struct is created properly
-	gh := NewGitHub(false)
+	gh := NewGitHub(false, "github.com", "")
 
 	if gh == nil {
 		t.Fatal("Expected NewGitHub to return a non-nil GitHub struct")
@@ -39,7 +41,7 @@ func Test_NewGitHub_WithDebug(t *testing.T) {
 		t.Skip("Skipping test: config file not found")
 	}
 
-	gh := NewGitHub(true)
+	gh := NewGitHub(true, "github.com", "")
 
 	if gh == nil {
 		t.Fatal("Expected NewGitHub to return a non-nil GitHub struct")
@@ -160,9 +162,9 @@ func Test_CreatePullRequest_Error(t *testing.T) {
 
 func Test_newGitHubClient(t *testing.T) {
 	token := "test-token-123"
-	client := newGitHubClient(token)
+	client, err := newGitHubClient("github.com", token)
 
-	if client == nil {
+	if err != nil || client == nil {
 		t.Fatal("Expected client to be non-nil")
 	}
 
@@ -174,9 +176,151 @@ func Test_newGitHubClient(t *testing.T) {
 }
 
 func Test_newGitHubClient_EmptyToken(t *testing.T) {
-	client := newGitHubClient("")
+	client, _ := newGitHubClient("github.com", "")
 
 	if client == nil {
 		t.Error("Expected client to be non-nil even with empty token")
 	}
 }
+
+func Test_newGitHubClient_Enterprise(t *testing.T) {
+	client, err := newGitHubClient("github.example.com", "test-token-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.BaseURL.String() != "https://github.example.com/api/v3/" {
+		t.Errorf("Expected the enterprise API URL, got %s", client.BaseURL)
+	}
+}
+
+func Test_TokenInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" {
+			t.Errorf("Expected request to /user, got %s", r.URL.Path)
+		}
+
+		w.Header().Set("X-OAuth-Scopes", "repo, workflow")
+		w.Header().Set("GitHub-Authentication-Token-Expiration", "2026-11-01 12:00:00 UTC")
+		fmt.Fprint(w, `{"login": "octocat"}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	info, err := gh.TokenInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Username != "octocat" {
+		t.Errorf("Expected username 'octocat', got '%s'", info.Username)
+	}
+
+	if len(info.Scopes) != 2 || info.Scopes[0] != "repo" || info.Scopes[1] != "workflow" {
+		t.Errorf("Expected scopes [repo workflow], got %v", info.Scopes)
+	}
+
+	if info.ExpiresAt == nil || info.ExpiresAt.Format("2006-01-02") != "2026-11-01" {
+		t.Errorf("Expected expiry 2026-11-01, got %v", info.ExpiresAt)
+	}
+}
+
+func Test_TokenInfo_FineGrained(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "octocat"}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	info, err := gh.TokenInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Scopes != nil || info.ExpiresAt != nil {
+		t.Errorf("Expected no scopes or expiry, got %v and %v", info.Scopes, info.ExpiresAt)
+	}
+}
+
+func Test_TokenInfo_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message": "Bad credentials"}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	_, err := gh.TokenInfo()
+	if err == nil {
+		t.Error("Expected an error for a bad token, got nil")
+	}
+}
+
+func Test_DefaultBranch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/octocat/hello" {
+			t.Errorf("Expected request to /repos/octocat/hello, got %s", r.URL.Path)
+		}
+
+		fmt.Fprint(w, `{"name": "hello", "default_branch": "trunk"}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	branch, err := gh.DefaultBranch("octocat", "hello")
+	if err != nil || branch != "trunk" {
+		t.Errorf("Expected default branch 'trunk', got '%s' (%v)", branch, err)
+	}
+}
+
+func Test_PullRequestForBranch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/octocat/hello/pulls" || r.URL.Query().Get("head") != "octocat:feature" || r.URL.Query().Get("state") != "all" {
+			t.Errorf("Unexpected request to %s", r.URL)
+		}
+
+		fmt.Fprint(w, `[{"number": 3, "state": "closed"}, {"number": 2, "state": "open"}]`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	pr, err := gh.PullRequestForBranch("octocat", "hello", "feature")
+	if err != nil || pr.GetNumber() != 2 {
+		t.Errorf("Expected the open pull request #2, got %v (%v)", pr, err)
+	}
+}
+
+func Test_UpdatePullRequestBase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPatch || r.URL.Path != "/repos/octocat/hello/pulls/2" || !strings.Contains(string(body), `"base":"main"`) {
+			t.Errorf("Unexpected request %s %s %s", r.Method, r.URL.Path, body)
+		}
+
+		fmt.Fprint(w, `{"number": 2}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	if err := gh.UpdatePullRequestBase("octocat", "hello", 2, "main"); err != nil {
+		t.Error(err)
+	}
+}
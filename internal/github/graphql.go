@@ -0,0 +1,57 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// GraphQL runs a query or mutation against GitHub's GraphQL API, and decodes
+// the response's data into data.
+func (c *GitHub) GraphQL(query string, variables map[string]any, data any) error {
+	body := map[string]any{"query": query, "variables": variables}
+	req, err := c.Client.NewRequest("POST", graphQLURL(c.Client.BaseURL), body)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	_, err = c.Client.Do(context.Background(), req, &response)
+	if err != nil {
+		return err
+	}
+
+	if len(response.Errors) > 0 {
+		messages := []string{}
+		for _, e := range response.Errors {
+			messages = append(messages, e.Message)
+		}
+		return errors.New(strings.Join(messages, "; "))
+	}
+
+	if data == nil || len(response.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(response.Data, data)
+}
+
+// graphQLURL returns the GraphQL endpoint next to the REST API, which is
+// /api/graphql on GitHub Enterprise Server rather than /api/v3/graphql.
+func graphQLURL(base *url.URL) string {
+	u := *base
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+		return u.String()
+	}
+
+	return u.ResolveReference(&url.URL{Path: "graphql"}).String()
+}
@@ -0,0 +1,67 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v84/github"
+)
+
+func Test_GraphQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/graphql" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		fmt.Fprint(w, `{"data": {"viewer": {"login": "octocat"}}}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	var data struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+
+	err := gh.GraphQL("query { viewer { login } }", nil, &data)
+	if err != nil || data.Viewer.Login != "octocat" {
+		t.Errorf("Expected login 'octocat', got '%s' (%v)", data.Viewer.Login, err)
+	}
+}
+
+func Test_GraphQL_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"errors": [{"message": "Pull request is in clean status"}]}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	err := gh.GraphQL("mutation { enablePullRequestAutoMerge }", nil, nil)
+	if err == nil || err.Error() != "Pull request is in clean status" {
+		t.Errorf("Expected the GraphQL error, got %v", err)
+	}
+}
+
+func Test_graphQLURL(t *testing.T) {
+	tests := map[string]string{
+		"https://api.github.com/":            "https://api.github.com/graphql",
+		"https://github.example.com/api/v3/": "https://github.example.com/api/graphql",
+	}
+
+	for base, expected := range tests {
+		u, _ := url.Parse(base)
+		if actual := graphQLURL(u); actual != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, base, actual)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v84/github"
+)
+
+// Approvals returns how many reviewers currently approve the pull request,
+// going by each reviewer's latest approving, dismissed or change-requesting
+// review.
+func (c *GitHub) Approvals(owner, repo string, number int) (int, error) {
+	reviews, _, err := c.Client.PullRequests.ListReviews(context.Background(), owner, repo, number, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return 0, err
+	}
+
+	latest := map[string]string{}
+	for _, review := range reviews {
+		switch state := review.GetState(); state {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[review.GetUser().GetLogin()] = state
+		}
+	}
+
+	approvals := 0
+	for _, state := range latest {
+		if state == "APPROVED" {
+			approvals++
+		}
+	}
+
+	return approvals, nil
+}
+
+// CommitMessages returns the messages of the pull request's commits, oldest
+// first.
+func (c *GitHub) CommitMessages(owner, repo string, number int) ([]string, error) {
+	commits, _, err := c.Client.PullRequests.ListCommits(context.Background(), owner, repo, number, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []string{}
+	for _, commit := range commits {
+		messages = append(messages, commit.GetCommit().GetMessage())
+	}
+
+	return messages, nil
+}
+
+// EnableAutoMerge merges the pull request with method once its requirements
+// are met. GitHub only offers this over GraphQL.
+func (c *GitHub) EnableAutoMerge(nodeID, method, title, message string) error {
+	mutation := `mutation($input: EnablePullRequestAutoMergeInput!) {
+  enablePullRequestAutoMerge(input: $input) { clientMutationId }
+}`
+
+	input := map[string]any{"pullRequestId": nodeID, "mergeMethod": strings.ToUpper(method)}
+	if title != "" {
+		input["commitHeadline"] = title
+		input["commitBody"] = message
+	}
+
+	return c.GraphQL(mutation, map[string]any{"input": input}, nil)
+}
+
+// MergeMethods returns the merge methods the repository allows, out of merge,
+// squash and rebase.
+func (c *GitHub) MergeMethods(owner, repo string) ([]string, error) {
+	repository, _, err := c.Client.Repositories.Get(context.Background(), owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := []string{}
+	if repository.GetAllowMergeCommit() {
+		methods = append(methods, "merge")
+	}
+	if repository.GetAllowSquashMerge() {
+		methods = append(methods, "squash")
+	}
+	if repository.GetAllowRebaseMerge() {
+		methods = append(methods, "rebase")
+	}
+
+	return methods, nil
+}
+
+// MergePullRequest merges the pull request with method, as long as its head
+// is still sha. An empty title and message keep GitHub's defaults.
+func (c *GitHub) MergePullRequest(owner, repo string, number int, method, sha, title, message string) error {
+	options := &github.PullRequestOptions{CommitTitle: title, MergeMethod: method, SHA: sha}
+	_, _, err := c.Client.PullRequests.Merge(context.Background(), owner, repo, number, message, options)
+	return err
+}
+
+// PullRequest returns the pull request, including whether it can be merged.
+func (c *GitHub) PullRequest(owner, repo string, number int) (*github.PullRequest, error) {
+	pr, _, err := c.Client.PullRequests.Get(context.Background(), owner, repo, number)
+	return pr, err
+}
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v84/github"
+)
+
+func Test_Approvals(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/octocat/hello/pulls/2/reviews" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+
+		fmt.Fprint(w, `[
+			{"user": {"login": "alice"}, "state": "APPROVED"},
+			{"user": {"login": "bob"}, "state": "APPROVED"},
+			{"user": {"login": "bob"}, "state": "COMMENTED"},
+			{"user": {"login": "carol"}, "state": "APPROVED"},
+			{"user": {"login": "carol"}, "state": "CHANGES_REQUESTED"}
+		]`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	approvals, err := gh.Approvals("octocat", "hello", 2)
+	if err != nil || approvals != 2 {
+		t.Errorf("Expected 2 approvals, got %d (%v)", approvals, err)
+	}
+}
+
+func Test_EnableAutoMerge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables struct {
+				Input map[string]string `json:"input"`
+			} `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		input := body.Variables.Input
+		if input["pullRequestId"] != "PR_abc" || input["mergeMethod"] != "SQUASH" || input["commitHeadline"] != "Add things (#2)" {
+			t.Errorf("Unexpected input %v", input)
+		}
+
+		fmt.Fprint(w, `{"data": {"enablePullRequestAutoMerge": {"clientMutationId": null}}}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	if err := gh.EnableAutoMerge("PR_abc", "squash", "Add things (#2)", "* Add a thing"); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_MergeMethods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"allow_merge_commit": false, "allow_squash_merge": true, "allow_rebase_merge": true}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	methods, err := gh.MergeMethods("octocat", "hello")
+	if err != nil || strings.Join(methods, ",") != "squash,rebase" {
+		t.Errorf("Expected squash and rebase, got %v (%v)", methods, err)
+	}
+}
+
+func Test_MergePullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		if r.Method != http.MethodPut || r.URL.Path != "/repos/octocat/hello/pulls/2/merge" || body["merge_method"] != "rebase" || body["sha"] != "abc123" {
+			t.Errorf("Unexpected request %s %s %v", r.Method, r.URL.Path, body)
+		}
+
+		fmt.Fprint(w, `{"merged": true}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	if err := gh.MergePullRequest("octocat", "hello", 2, "rebase", "abc123", "", ""); err != nil {
+		t.Error(err)
+	}
+}
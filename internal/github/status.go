@@ -0,0 +1,68 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v84/github"
+)
+
+// Review is a pull request's review decision, which is APPROVED,
+// CHANGES_REQUESTED, REVIEW_REQUIRED or empty, and how many of its review
+// threads are unresolved.
+type Review struct {
+	Decision          string
+	UnresolvedThreads int
+}
+
+// Review returns the pull request's review decision and unresolved threads,
+// which are only available over GraphQL.
+func (c *GitHub) Review(owner, repo string, number int) (*Review, error) {
+	query := `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      reviewDecision
+      reviewThreads(first: 100) { nodes { isResolved } }
+    }
+  }
+}`
+
+	var data struct {
+		Repository struct {
+			PullRequest struct {
+				ReviewDecision string `json:"reviewDecision"`
+				ReviewThreads  struct {
+					Nodes []struct {
+						IsResolved bool `json:"isResolved"`
+					} `json:"nodes"`
+				} `json:"reviewThreads"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+
+	err := c.GraphQL(query, map[string]any{"owner": owner, "repo": repo, "number": number}, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := data.Repository.PullRequest
+	review := &Review{Decision: pr.ReviewDecision}
+	for _, thread := range pr.ReviewThreads.Nodes {
+		if !thread.IsResolved {
+			review.UnresolvedThreads++
+		}
+	}
+
+	return review, nil
+}
+
+// SearchPullRequests returns the pull requests matching query, such as
+// "is:open is:pr author:@me", most recently updated first.
+func (c *GitHub) SearchPullRequests(query string) ([]*github.Issue, error) {
+	options := &github.SearchOptions{Sort: "updated", ListOptions: github.ListOptions{PerPage: 100}}
+	result, _, err := c.Client.Search.Issues(context.Background(), query, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Issues, nil
+}
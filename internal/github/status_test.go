@@ -0,0 +1,49 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/v84/github"
+)
+
+func Test_Review(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"repository": {"pullRequest": {
+			"reviewDecision": "CHANGES_REQUESTED",
+			"reviewThreads": {"nodes": [{"isResolved": true}, {"isResolved": false}, {"isResolved": false}]}
+		}}}}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	review, err := gh.Review("octocat", "hello", 2)
+	if err != nil || review.Decision != "CHANGES_REQUESTED" || review.UnresolvedThreads != 2 {
+		t.Errorf("Expected changes requested with 2 unresolved threads, got %+v (%v)", review, err)
+	}
+}
+
+func Test_SearchPullRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/issues" || r.URL.Query().Get("q") != "is:open is:pr author:@me" {
+			t.Errorf("Unexpected request to %s", r.URL)
+		}
+
+		fmt.Fprint(w, `{"total_count": 1, "items": [{"number": 2, "title": "Add things"}]}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+	gh := &GitHub{Debug: false, Client: client}
+
+	issues, err := gh.SearchPullRequests("is:open is:pr author:@me")
+	if err != nil || len(issues) != 1 || issues[0].GetNumber() != 2 {
+		t.Errorf("Expected pull request #2, got %v (%v)", issues, err)
+	}
+}
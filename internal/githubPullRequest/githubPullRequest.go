@@ -20,6 +20,7 @@ type GitHubPullRequest struct {
 	Debug           bool
 	Draft           string
 	GitRootDir      string
+	Host            string
 	InteractiveMode bool
 	LocalBranch     string
 	LocalRepo       string
@@ -32,6 +33,7 @@ func NewGitHubPullRequest(options map[string]string, debug, interactiveMode bool
 		Debug:           debug,
 		Draft:           options["draft"],
 		GitRootDir:      options["gitRootDir"],
+		Host:            options["host"],
 		InteractiveMode: interactiveMode,
 		LocalBranch:     options["localBranch"],
 		LocalRepo:       options["localRepo"],
@@ -172,5 +174,6 @@ func (pr *GitHubPullRequest) prTemplateOptions() []string {
 }
 
 func (pr *GitHubPullRequest) github() *github.GitHub {
-	return github.NewGitHub(pr.Debug)
+	owner := strings.Split(pr.LocalRepo, "/")[0]
+	return github.NewGitHub(pr.Debug, pr.Host, owner)
 }
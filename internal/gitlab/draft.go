@@ -0,0 +1,45 @@
+package gitlab
+
+import (
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+// AddReviewers asks each user to review the merge request, keeping the
+// reviewers it already has.
+func (c *GitLab) AddReviewers(projectName string, iid int64, usernames []string) error {
+	mr, err := c.MergeRequest(projectName, iid)
+	if err != nil {
+		return err
+	}
+
+	ids := []int64{}
+	for _, reviewer := range mr.Reviewers {
+		ids = append(ids, reviewer.ID)
+	}
+
+	for _, username := range usernames {
+		users, _, err := c.Client.Users.ListUsers(&gitlab.ListUsersOptions{Username: gitlab.Ptr(username)})
+		if err != nil {
+			return err
+		}
+
+		if len(users) == 0 {
+			return fmt.Errorf("no GitLab user is called %s", username)
+		}
+
+		ids = append(ids, users[0].ID)
+	}
+
+	options := &gitlab.UpdateMergeRequestOptions{ReviewerIDs: &ids}
+	_, _, err = c.Client.MergeRequests.UpdateMergeRequest(projectName, iid, options)
+	return err
+}
+
+// UpdateMergeRequestTitle changes the merge request's title.
+func (c *GitLab) UpdateMergeRequestTitle(projectName string, iid int64, title string) error {
+	options := &gitlab.UpdateMergeRequestOptions{Title: gitlab.Ptr(title)}
+	_, _, err := c.Client.MergeRequests.UpdateMergeRequest(projectName, iid, options)
+	return err
+}
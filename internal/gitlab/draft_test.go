@@ -0,0 +1,71 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+func Test_AddReviewers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v4/projects/group/project/merge_requests/4":
+			fmt.Fprint(w, `{"iid": 4, "reviewers": [{"id": 1, "username": "octocat"}]}`)
+		case r.URL.Path == "/api/v4/users":
+			if r.URL.Query().Get("username") != "hubot" {
+				fmt.Fprint(w, `[]`)
+				return
+			}
+			fmt.Fprint(w, `[{"id": 2, "username": "hubot"}]`)
+		case r.Method == "PUT" && r.URL.Path == "/api/v4/projects/group/project/merge_requests/4":
+			var body struct {
+				ReviewerIDs []int64 `json:"reviewer_ids"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if !reflect.DeepEqual(body.ReviewerIDs, []int64{1, 2}) {
+				t.Errorf("Expected the existing and new reviewers, got %v", body.ReviewerIDs)
+			}
+			fmt.Fprint(w, `{"iid": 4}`)
+		default:
+			t.Errorf("Unexpected request to %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	if err := gl.AddReviewers("group/project", 4, []string{"hubot"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gl.AddReviewers("group/project", 4, []string{"nobody"}); err == nil {
+		t.Error("Expected an error for a user that doesn't exist")
+	}
+}
+
+func Test_UpdateMergeRequestTitle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Title string `json:"title"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != "PUT" || body.Title != "Draft: Fix things" {
+			t.Errorf("Unexpected request %s %s with title %q", r.Method, r.URL.Path, body.Title)
+		}
+		fmt.Fprint(w, `{"iid": 4}`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	if err := gl.UpdateMergeRequestTitle("group/project", 4, "Draft: Fix things"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,8 +2,11 @@ package gitlab
 
 import (
 	"errors"
+	"time"
 
 	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/credentials"
+	"github.com/emmahsax/go-git-helper/internal/executor"
 	"github.com/emmahsax/go-git-helper/internal/utils"
 	gitlab "gitlab.com/gitlab-org/api/client-go/v2"
 )
@@ -11,11 +14,30 @@ import (
 type GitLab struct {
 	Debug  bool
 	Client *gitlab.Client
+	rate   *RateLimit
 }
 
-func NewGitLab(debugB bool) *GitLab {
+type TokenInfo struct {
+	ExpiresAt *time.Time
+	Scopes    []string
+	Username  string
+}
+
+// NewGitLab returns a client for host, authenticated as the account for
+// projects under the owner namespace.
+func NewGitLab(debugB bool, host, owner string) *GitLab {
 	cf := configfile.NewConfigFile(debugB)
-	c, err := newGitLabClient(cf.GitLabToken(), debugB)
+	token, _, err := credentials.NewCredentials(debugB, cf, executor.NewExecutor(debugB)).Token(credentials.GitLab, host, owner)
+	if err != nil {
+		utils.HandleError(err, debugB, nil)
+		return nil
+	}
+
+	return NewGitLabFromToken(debugB, host, token)
+}
+
+func NewGitLabFromToken(debugB bool, host, token string) *GitLab {
+	c, err := newGitLabClient(host, token, debugB)
 	if err != nil {
 		customErr := errors.New("could not create GitLab client: " + err.Error())
 		utils.HandleError(customErr, debugB, nil)
@@ -38,8 +60,84 @@ func (c *GitLab) CreateMergeRequest(projectName string, options *gitlab.CreateMe
 	return mr, nil
 }
 
-func newGitLabClient(token string, debugB bool) (*gitlab.Client, error) {
-	git, err := gitlab.NewClient(token)
+// DefaultBranch returns the project's default branch.
+func (c *GitLab) DefaultBranch(projectName string) (string, error) {
+	project, _, err := c.Client.Projects.GetProject(projectName, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return project.DefaultBranch, nil
+}
+
+// MergeRequestForBranch returns the newest merge request from branch,
+// preferring an open one, or nil when there isn't one.
+func (c *GitLab) MergeRequestForBranch(projectName, branch string) (*gitlab.BasicMergeRequest, error) {
+	options := &gitlab.ListProjectMergeRequestsOptions{
+		OrderBy:      gitlab.Ptr("created_at"),
+		Sort:         gitlab.Ptr("desc"),
+		SourceBranch: gitlab.Ptr(branch),
+	}
+
+	mrs, _, err := c.Client.MergeRequests.ListProjectMergeRequests(projectName, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mr := range mrs {
+		if mr.State == "opened" {
+			return mr, nil
+		}
+	}
+
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+
+	return mrs[0], nil
+}
+
+// UpdateMergeRequestTarget changes the branch the merge request merges into.
+func (c *GitLab) UpdateMergeRequestTarget(projectName string, iid int64, target string) error {
+	options := &gitlab.UpdateMergeRequestOptions{TargetBranch: gitlab.Ptr(target)}
+	_, _, err := c.Client.MergeRequests.UpdateMergeRequest(projectName, iid, options)
+	return err
+}
+
+// TokenInfo returns who the client's token belongs to, along with its scopes
+// and expiry. Older GitLab versions can't describe the token itself, in which
+// case Scopes is nil.
+func (c *GitLab) TokenInfo() (*TokenInfo, error) {
+	user, _, err := c.Client.Users.CurrentUser()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &TokenInfo{Username: user.Username}
+
+	token, _, err := c.Client.PersonalAccessTokens.GetSinglePersonalAccessToken()
+	if err != nil {
+		return info, nil
+	}
+
+	info.Scopes = token.Scopes
+	if token.ExpiresAt != nil {
+		expiresAt := time.Time(*token.ExpiresAt)
+		info.ExpiresAt = &expiresAt
+	}
+
+	return info, nil
+}
+
+// newGitLabClient talks to a self-managed instance's API for any host other
+// than gitlab.com.
+func newGitLabClient(host, token string, debugB bool) (*gitlab.Client, error) {
+	options := []gitlab.ClientOptionFunc{}
+	if host != "" && host != "gitlab.com" {
+		options = append(options, gitlab.WithBaseURL("https://"+host+"/api/v4"))
+	}
+
+	git, err := gitlab.NewClient(token, options...)
 	if err != nil {
 		utils.HandleError(err, debugB, nil)
 		return nil, err
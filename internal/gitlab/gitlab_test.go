@@ -2,9 +2,11 @@ package gitlab
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	gitlab "gitlab.com/gitlab-org/api/client-go/v2"
@@ -18,7 +20,7 @@ func Test_NewGitLab(t *testing.T) {
 
 	// This test requires a valid config file with GitLab token
 	// We'll test that the struct is created properly
-	gl := NewGitLab(false)
+	gl := NewGitLab(false, "gitlab.com", "")
 
 	if gl == nil {
 		t.Fatal("Expected NewGitLab to return a non-nil GitLab struct")
@@ -39,7 +41,7 @@ func Test_NewGitLab_WithDebug(t *testing.T) {
 		t.Skip("Skipping test: config file not found")
 	}
 
-	gl := NewGitLab(true)
+	gl := NewGitLab(true, "gitlab.com", "")
 
 	if gl == nil {
 		t.Fatal("Expected NewGitLab to return a non-nil GitLab struct")
@@ -104,7 +106,7 @@ func Test_CreateMergeRequest_Error(t *testing.T) {
 
 func Test_newGitLabClient(t *testing.T) {
 	token := "test-token-123"
-	client, err := newGitLabClient(token, false)
+	client, err := newGitLabClient("gitlab.com", token, false)
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -121,7 +123,7 @@ func Test_newGitLabClient(t *testing.T) {
 }
 
 func Test_newGitLabClient_EmptyToken(t *testing.T) {
-	client, err := newGitLabClient("", false)
+	client, err := newGitLabClient("gitlab.com", "", false)
 
 	if err != nil {
 		t.Errorf("Expected no error with empty token, got %v", err)
@@ -132,9 +134,20 @@ func Test_newGitLabClient_EmptyToken(t *testing.T) {
 	}
 }
 
+func Test_newGitLabClient_SelfManaged(t *testing.T) {
+	client, err := newGitLabClient("gitlab.example.com", "test-token-123", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.BaseURL().String() != "https://gitlab.example.com/api/v4/" {
+		t.Errorf("Expected the self-managed API URL, got %s", client.BaseURL())
+	}
+}
+
 func Test_newGitLabClient_WithDebug(t *testing.T) {
 	token := "test-token-debug"
-	client, err := newGitLabClient(token, true)
+	client, err := newGitLabClient("gitlab.com", token, true)
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -144,3 +157,110 @@ func Test_newGitLabClient_WithDebug(t *testing.T) {
 		t.Error("Expected client to be non-nil")
 	}
 }
+
+func Test_TokenInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/user":
+			fmt.Fprint(w, `{"id": 1, "username": "tanuki"}`)
+		case "/api/v4/personal_access_tokens/self":
+			fmt.Fprint(w, `{"id": 2, "scopes": ["api", "read_user"], "expires_at": "2026-11-01"}`)
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	info, err := gl.TokenInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Username != "tanuki" {
+		t.Errorf("Expected username 'tanuki', got '%s'", info.Username)
+	}
+
+	if len(info.Scopes) != 2 || info.Scopes[0] != "api" {
+		t.Errorf("Expected scopes [api read_user], got %v", info.Scopes)
+	}
+
+	if info.ExpiresAt == nil || info.ExpiresAt.Format("2006-01-02") != "2026-11-01" {
+		t.Errorf("Expected expiry 2026-11-01, got %v", info.ExpiresAt)
+	}
+}
+
+func Test_TokenInfo_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message": "401 Unauthorized"}`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	_, err := gl.TokenInfo()
+	if err == nil {
+		t.Error("Expected an error for a bad token, got nil")
+	}
+}
+
+func Test_DefaultBranch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fsubgroup%2Fproject" {
+			t.Errorf("Unexpected request to %s", r.URL.EscapedPath())
+		}
+
+		fmt.Fprint(w, `{"id": 1, "default_branch": "develop"}`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	branch, err := gl.DefaultBranch("group/subgroup/project")
+	if err != nil || branch != "develop" {
+		t.Errorf("Expected default branch 'develop', got '%s' (%v)", branch, err)
+	}
+}
+
+func Test_MergeRequestForBranch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/merge_requests" || r.URL.Query().Get("source_branch") != "feature" {
+			t.Errorf("Unexpected request to %s", r.URL)
+		}
+
+		fmt.Fprint(w, `[{"iid": 3, "state": "merged"}, {"iid": 2, "state": "opened"}]`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	mr, err := gl.MergeRequestForBranch("group/project", "feature")
+	if err != nil || mr == nil || mr.IID != 2 {
+		t.Errorf("Expected the open merge request !2, got %v (%v)", mr, err)
+	}
+}
+
+func Test_UpdateMergeRequestTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPut || r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/merge_requests/2" || !strings.Contains(string(body), `"target_branch":"main"`) {
+			t.Errorf("Unexpected request %s %s %s", r.Method, r.URL.EscapedPath(), body)
+		}
+
+		fmt.Fprint(w, `{"iid": 2}`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	if err := gl.UpdateMergeRequestTarget("group/project", 2, "main"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,62 @@
+package gitlab
+
+import (
+	gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+// AcceptMergeRequest merges the merge request, or sets it to merge
+// automatically when options.AutoMerge is set.
+func (c *GitLab) AcceptMergeRequest(projectName string, iid int64, options *gitlab.AcceptMergeRequestOptions) error {
+	_, _, err := c.Client.MergeRequests.AcceptMergeRequest(projectName, iid, options)
+	return err
+}
+
+// Approvals returns the merge request's approval state, including how many
+// approvals are still needed.
+func (c *GitLab) Approvals(projectName string, iid int64) (*gitlab.MergeRequestApprovals, error) {
+	approvals, _, err := c.Client.MergeRequestApprovals.GetConfiguration(projectName, iid)
+	return approvals, err
+}
+
+// CommitMessages returns the messages of the merge request's commits, oldest
+// first.
+func (c *GitLab) CommitMessages(projectName string, iid int64) ([]string, error) {
+	commits, _, err := c.Client.MergeRequests.GetMergeRequestCommits(projectName, iid, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []string{}
+	for i := len(commits) - 1; i >= 0; i-- {
+		messages = append(messages, commits[i].Message)
+	}
+
+	return messages, nil
+}
+
+// MergeRequest returns the merge request, including whether it can be merged.
+func (c *GitLab) MergeRequest(projectName string, iid int64) (*gitlab.MergeRequest, error) {
+	mr, _, err := c.Client.MergeRequests.GetMergeRequest(projectName, iid, nil)
+	return mr, err
+}
+
+// MergeSettings returns the project's merge method and squash option.
+func (c *GitLab) MergeSettings(projectName string) (gitlab.MergeMethodValue, gitlab.SquashOptionValue, error) {
+	project, _, err := c.Client.Projects.GetProject(projectName, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	return project.MergeMethod, project.SquashOption, nil
+}
+
+// ProjectPath returns the full path of the project with id, like
+// group/project.
+func (c *GitLab) ProjectPath(id int64) (string, error) {
+	project, _, err := c.Client.Projects.GetProject(id, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return project.PathWithNamespace, nil
+}
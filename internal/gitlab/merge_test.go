@@ -0,0 +1,67 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+func Test_AcceptMergeRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		if r.Method != http.MethodPut || r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/merge_requests/2/merge" || body["squash"] != true || body["auto_merge"] != true {
+			t.Errorf("Unexpected request %s %s %v", r.Method, r.URL.EscapedPath(), body)
+		}
+
+		fmt.Fprint(w, `{"iid": 2}`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	err := gl.AcceptMergeRequest("group/project", 2, &gitlab.AcceptMergeRequestOptions{AutoMerge: gitlab.Ptr(true), Squash: gitlab.Ptr(true)})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_CommitMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"message": "Second"}, {"message": "First"}]`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	messages, err := gl.CommitMessages("group/project", 2)
+	if err != nil || len(messages) != 2 || messages[0] != "First" {
+		t.Errorf("Expected the oldest commit first, got %v (%v)", messages, err)
+	}
+}
+
+func Test_MergeSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject" {
+			t.Errorf("Unexpected request to %s", r.URL.EscapedPath())
+		}
+
+		fmt.Fprint(w, `{"merge_method": "ff", "squash_option": "always"}`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	method, squash, err := gl.MergeSettings("group/project")
+	if err != nil || method != gitlab.FastForwardMerge || squash != gitlab.SquashOptionAlways {
+		t.Errorf("Expected ff and always, got %s and %s (%v)", method, squash, err)
+	}
+}
@@ -0,0 +1,125 @@
+package gitlab
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+// RateLimit is how many API requests are left until the limit resets.
+type RateLimit struct {
+	Remaining int
+	Reset     time.Time
+}
+
+// Jobs returns the latest attempt at each of the pipeline's jobs.
+func (c *GitLab) Jobs(projectName string, pipelineID int64) ([]*gitlab.Job, error) {
+	options := &gitlab.ListJobsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	jobs, resp, err := c.Client.Jobs.ListPipelineJobs(projectName, pipelineID, options)
+	c.recordRate(resp)
+	return jobs, err
+}
+
+// OpenMergeRequests returns the open merge requests the current user created,
+// or when reviewer is set, the ones they've been asked to review.
+func (c *GitLab) OpenMergeRequests(reviewer bool) ([]*gitlab.BasicMergeRequest, error) {
+	options := &gitlab.ListMergeRequestsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+		OrderBy:     gitlab.Ptr("updated_at"),
+		Scope:       gitlab.Ptr("created_by_me"),
+		State:       gitlab.Ptr("opened"),
+	}
+
+	if reviewer {
+		user, _, err := c.Client.Users.CurrentUser()
+		if err != nil {
+			return nil, err
+		}
+
+		options.Scope = gitlab.Ptr("all")
+		options.ReviewerUsername = gitlab.Ptr(user.Username)
+	}
+
+	mrs, _, err := c.Client.MergeRequests.ListMergeRequests(options)
+	return mrs, err
+}
+
+// UnresolvedThreads returns how many of the merge request's resolvable threads
+// are unresolved.
+func (c *GitLab) UnresolvedThreads(projectName string, iid int64) (int, error) {
+	options := &gitlab.ListMergeRequestDiscussionsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	discussions, _, err := c.Client.Discussions.ListMergeRequestDiscussions(projectName, iid, options)
+	if err != nil {
+		return 0, err
+	}
+
+	unresolved := 0
+	for _, discussion := range discussions {
+		if len(discussion.Notes) > 0 && discussion.Notes[0].Resolvable && !discussion.Notes[0].Resolved {
+			unresolved++
+		}
+	}
+
+	return unresolved, nil
+}
+
+// JobLog returns the job's log.
+func (c *GitLab) JobLog(projectName string, jobID int64) (string, error) {
+	trace, resp, err := c.Client.Jobs.GetTraceFile(projectName, jobID)
+	c.recordRate(resp)
+	if err != nil {
+		return "", err
+	}
+
+	log, err := io.ReadAll(trace)
+	return string(log), err
+}
+
+// LatestPipeline returns the newest pipeline for sha, or nil when there isn't
+// one.
+func (c *GitLab) LatestPipeline(projectName, sha string) (*gitlab.PipelineInfo, error) {
+	options := &gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 1},
+		OrderBy:     gitlab.Ptr("id"),
+		SHA:         gitlab.Ptr(sha),
+		Sort:        gitlab.Ptr("desc"),
+	}
+
+	pipelines, resp, err := c.Client.Pipelines.ListProjectPipelines(projectName, options)
+	c.recordRate(resp)
+	if err != nil || len(pipelines) == 0 {
+		return nil, err
+	}
+
+	return pipelines[0], nil
+}
+
+// RateLimit returns the rate limit from the last response that had one, or
+// false when there hasn't been one.
+func (c *GitLab) RateLimit() (RateLimit, bool) {
+	if c.rate == nil {
+		return RateLimit{}, false
+	}
+
+	return *c.rate, true
+}
+
+func (c *GitLab) recordRate(resp *gitlab.Response) {
+	if resp == nil || resp.Response == nil {
+		return
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get("RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	reset, err := strconv.ParseInt(resp.Header.Get("RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	c.rate = &RateLimit{Remaining: remaining, Reset: time.Unix(reset, 0)}
+}
@@ -0,0 +1,140 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+)
+
+func Test_Jobs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/pipelines/7/jobs" {
+			t.Errorf("Unexpected request to %s", r.URL.EscapedPath())
+		}
+
+		fmt.Fprint(w, `[{"id": 1, "name": "test", "status": "failed"}]`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	jobs, err := gl.Jobs("group/project", 7)
+	if err != nil || len(jobs) != 1 || jobs[0].Name != "test" {
+		t.Errorf("Expected the test job, got %v (%v)", jobs, err)
+	}
+}
+
+func Test_OpenMergeRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/user":
+			fmt.Fprint(w, `{"id": 1, "username": "octocat"}`)
+		case "/api/v4/merge_requests":
+			query := r.URL.Query()
+			if query.Get("state") != "opened" || query.Get("scope") != "all" || query.Get("reviewer_username") != "octocat" {
+				t.Errorf("Unexpected request to %s", r.URL)
+			}
+			fmt.Fprint(w, `[{"iid": 4}]`)
+		default:
+			t.Errorf("Unexpected request to %s", r.URL)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	mrs, err := gl.OpenMergeRequests(true)
+	if err != nil || len(mrs) != 1 || mrs[0].IID != 4 {
+		t.Errorf("Expected merge request !4, got %v (%v)", mrs, err)
+	}
+}
+
+func Test_UnresolvedThreads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"id": "a", "notes": [{"resolvable": true, "resolved": false}]},
+			{"id": "b", "notes": [{"resolvable": true, "resolved": true}]},
+			{"id": "c", "notes": [{"resolvable": false, "resolved": false}]}
+		]`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	unresolved, err := gl.UnresolvedThreads("group/project", 2)
+	if err != nil || unresolved != 1 {
+		t.Errorf("Expected 1 unresolved thread, got %d (%v)", unresolved, err)
+	}
+}
+
+func Test_LatestPipeline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/api/v4/projects/group/project/pipelines" || query.Get("sha") != "abc123" || query.Get("order_by") != "id" || query.Get("sort") != "desc" {
+			t.Errorf("Unexpected request to %s", r.URL)
+		}
+
+		w.Header().Set("RateLimit-Remaining", "99")
+		w.Header().Set("RateLimit-Reset", "1700000000")
+		fmt.Fprint(w, `[{"id": 8, "sha": "abc123", "status": "running"}]`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	pipeline, err := gl.LatestPipeline("group/project", "abc123")
+	if err != nil || pipeline == nil || pipeline.ID != 8 {
+		t.Errorf("Expected pipeline 8, got %v (%v)", pipeline, err)
+	}
+
+	rate, ok := gl.RateLimit()
+	if !ok || rate.Remaining != 99 || !rate.Reset.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Expected the rate limit from the headers, got %+v", rate)
+	}
+}
+
+func Test_LatestPipeline_None(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	pipeline, err := gl.LatestPipeline("group/project", "abc123")
+	if err != nil || pipeline != nil {
+		t.Errorf("Expected no pipeline, got %v (%v)", pipeline, err)
+	}
+
+	if _, ok := gl.RateLimit(); ok {
+		t.Error("Expected no rate limit without the headers")
+	}
+}
+
+func Test_JobLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v4/projects/group/project/jobs/3/trace" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+
+		fmt.Fprint(w, "$ make test\nFAIL\n")
+	}))
+	defer server.Close()
+
+	client, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	gl := &GitLab{Debug: false, Client: client}
+
+	log, err := gl.JobLog("group/project", 3)
+	if err != nil || log != "$ make test\nFAIL\n" {
+		t.Errorf("Expected the job's log, got %q (%v)", log, err)
+	}
+}
@@ -19,6 +19,7 @@ type GitLabMergeRequest struct {
 	Debug           bool
 	Draft           string
 	GitRootDir      string
+	Host            string
 	LocalBranch     string
 	InteractiveMode bool
 	LocalProject    string
@@ -31,6 +32,7 @@ func NewGitLabMergeRequest(options map[string]string, debug, interactiveMode boo
 		Debug:           debug,
 		Draft:           options["draft"],
 		GitRootDir:      options["gitRootDir"],
+		Host:            options["host"],
 		LocalBranch:     options["localBranch"],
 		InteractiveMode: interactiveMode,
 		LocalProject:    options["localProject"],
@@ -161,5 +163,6 @@ func (mr *GitLabMergeRequest) mrTemplateOptions() []string {
 }
 
 func (mr *GitLabMergeRequest) gitlab() *gitlab.GitLab {
-	return gitlab.NewGitLab(mr.Debug)
+	owner := mr.LocalProject[:max(strings.LastIndex(mr.LocalProject, "/"), 0)]
+	return gitlab.NewGitLab(mr.Debug, mr.Host, owner)
 }
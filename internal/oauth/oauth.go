@@ -0,0 +1,225 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Provider describes a forge's OAuth 2.0 device authorization endpoints (RFC
+// 8628).
+type Provider struct {
+	ClientID      string
+	DeviceCodeURL string
+	HTTPClient    *http.Client
+	Scopes        []string
+	Sleep         func(time.Duration)
+	TokenURL      string
+}
+
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+}
+
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	TokenType    string `json:"token_type"`
+}
+
+type tokenResponse struct {
+	Token
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	Interval         int    `json:"interval"`
+}
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+func GitHubProvider(host, clientID string) *Provider {
+	return &Provider{
+		ClientID:      clientID,
+		DeviceCodeURL: "https://" + host + "/login/device/code",
+		HTTPClient:    http.DefaultClient,
+		Scopes:        []string{"repo", "read:org"},
+		Sleep:         time.Sleep,
+		TokenURL:      "https://" + host + "/login/oauth/access_token",
+	}
+}
+
+func GitLabProvider(host, clientID string) *Provider {
+	return &Provider{
+		ClientID:      clientID,
+		DeviceCodeURL: "https://" + host + "/oauth/authorize_device",
+		HTTPClient:    http.DefaultClient,
+		Scopes:        []string{"api"},
+		Sleep:         time.Sleep,
+		TokenURL:      "https://" + host + "/oauth/token",
+	}
+}
+
+func (p *Provider) RequestDeviceCode() (*DeviceCode, error) {
+	body, err := p.post(p.DeviceCodeURL, url.Values{
+		"client_id": {p.ClientID},
+		"scope":     {strings.Join(p.Scopes, " ")},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	code := &DeviceCode{}
+	err = json.Unmarshal(body, code)
+	if err != nil {
+		return nil, errors.New("could not parse device code response: " + err.Error())
+	}
+
+	if code.DeviceCode == "" || code.UserCode == "" {
+		return nil, errors.New("device code response is missing the device or user code: " + string(body))
+	}
+
+	if code.Interval <= 0 {
+		code.Interval = 5
+	}
+
+	return code, nil
+}
+
+// PollToken waits for the user to approve the device code in their browser,
+// polling no faster than the server allows, until the code expires.
+func (p *Provider) PollToken(code *DeviceCode) (*Token, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	waited := time.Duration(0)
+	expiresIn := time.Duration(code.ExpiresIn) * time.Second
+
+	for expiresIn <= 0 || waited < expiresIn {
+		p.Sleep(interval)
+		waited += interval
+
+		resp, err := p.requestToken(url.Values{
+			"client_id":   {p.ClientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {deviceCodeGrantType},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		switch resp.Error {
+		case "":
+			return &resp.Token, nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			if resp.Interval > 0 {
+				interval = time.Duration(resp.Interval) * time.Second
+			} else {
+				interval += 5 * time.Second
+			}
+			continue
+		case "expired_token":
+			return nil, errors.New("the device code expired before it was approved, please try again")
+		case "access_denied":
+			return nil, errors.New("the authorization request was denied")
+		default:
+			return nil, resp.err()
+		}
+	}
+
+	return nil, errors.New("the device code expired before it was approved, please try again")
+}
+
+func (p *Provider) Refresh(refreshToken string) (*Token, error) {
+	resp, err := p.requestToken(url.Values{
+		"client_id":     {p.ClientID},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Error != "" {
+		return nil, resp.err()
+	}
+
+	return &resp.Token, nil
+}
+
+// ExpiresAt returns when the token expires, or nil when it doesn't.
+func (t *Token) ExpiresAt(now time.Time) *time.Time {
+	if t.ExpiresIn <= 0 {
+		return nil
+	}
+
+	expiresAt := now.Add(time.Duration(t.ExpiresIn) * time.Second)
+	return &expiresAt
+}
+
+func (p *Provider) requestToken(values url.Values) (*tokenResponse, error) {
+	body, err := p.post(p.TokenURL, values)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &tokenResponse{}
+	err = json.Unmarshal(body, resp)
+	if err != nil {
+		return nil, errors.New("could not parse token response: " + err.Error())
+	}
+
+	if resp.Error == "" && resp.AccessToken == "" {
+		return nil, errors.New("token response is missing the access token: " + string(body))
+	}
+
+	return resp, nil
+}
+
+// post sends a form-encoded request. OAuth errors like authorization_pending
+// come back as 400s with a JSON body, so those bodies are returned rather
+// than treated as failures.
+func (p *Provider) post(endpoint string, values url.Values) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 && !strings.Contains(string(body), `"error"`) {
+		return nil, fmt.Errorf("%s returned %s: %s", endpoint, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
+
+func (r *tokenResponse) err() error {
+	if r.ErrorDescription != "" {
+		return fmt.Errorf("%s: %s", r.Error, r.ErrorDescription)
+	}
+
+	return errors.New(r.Error)
+}
@@ -0,0 +1,189 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestProvider(t *testing.T, handler http.HandlerFunc) (*Provider, *[]time.Duration) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	slept := []time.Duration{}
+	return &Provider{
+		ClientID:      "client123",
+		DeviceCodeURL: server.URL + "/device/code",
+		HTTPClient:    server.Client(),
+		Scopes:        []string{"repo", "read:org"},
+		Sleep:         func(d time.Duration) { slept = append(slept, d) },
+		TokenURL:      server.URL + "/token",
+	}, &slept
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func Test_RequestDeviceCode(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_id") != "client123" || r.Form.Get("scope") != "repo read:org" {
+			t.Errorf("unexpected form %v", r.Form)
+		}
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("expected JSON to be requested, got %s", r.Header.Get("Accept"))
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"device_code":      "device123",
+			"expires_in":       900,
+			"user_code":        "ABCD-1234",
+			"verification_uri": "https://github.com/login/device",
+		})
+	})
+
+	code, err := p.RequestDeviceCode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if code.DeviceCode != "device123" || code.UserCode != "ABCD-1234" || code.VerificationURI != "https://github.com/login/device" {
+		t.Errorf("unexpected device code %+v", code)
+	}
+
+	if code.Interval != 5 {
+		t.Errorf("expected the interval to default to 5, got %d", code.Interval)
+	}
+}
+
+func Test_RequestDeviceCode_ServerError(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+
+	_, err := p.RequestDeviceCode()
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+}
+
+func Test_PollToken(t *testing.T) {
+	responses := []map[string]any{
+		{"error": "authorization_pending"},
+		{"error": "slow_down", "interval": 10},
+		{"access_token": "token123", "refresh_token": "refresh123", "expires_in": 3600, "token_type": "bearer"},
+	}
+	requests := 0
+
+	p, slept := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != deviceCodeGrantType || r.Form.Get("device_code") != "device123" {
+			t.Errorf("unexpected form %v", r.Form)
+		}
+		resp := responses[requests]
+		requests++
+		if _, ok := resp["error"]; ok {
+			writeJSON(w, http.StatusBadRequest, resp)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+
+	token, err := p.PollToken(&DeviceCode{DeviceCode: "device123", ExpiresIn: 900, Interval: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if token.AccessToken != "token123" || token.RefreshToken != "refresh123" {
+		t.Errorf("unexpected token %+v", token)
+	}
+
+	expected := []time.Duration{5 * time.Second, 5 * time.Second, 10 * time.Second}
+	if len(*slept) != len(expected) {
+		t.Fatalf("expected sleeps %v, got %v", expected, *slept)
+	}
+	for i := range expected {
+		if (*slept)[i] != expected[i] {
+			t.Errorf("expected sleeps %v, got %v", expected, *slept)
+		}
+	}
+}
+
+func Test_PollToken_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response map[string]any
+		expected string
+	}{
+		{name: "denied", response: map[string]any{"error": "access_denied"}, expected: "denied"},
+		{name: "expired", response: map[string]any{"error": "expired_token"}, expected: "expired"},
+		{name: "other", response: map[string]any{"error": "invalid_client", "error_description": "bad client"}, expected: "invalid_client: bad client"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusBadRequest, test.response)
+			})
+
+			_, err := p.PollToken(&DeviceCode{DeviceCode: "device123", ExpiresIn: 900, Interval: 5})
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected error containing %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func Test_PollToken_Timeout(t *testing.T) {
+	requests := 0
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "authorization_pending"})
+	})
+
+	_, err := p.PollToken(&DeviceCode{DeviceCode: "device123", ExpiresIn: 15, Interval: 5})
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("expected an expiry error, got %v", err)
+	}
+
+	if requests != 3 {
+		t.Errorf("expected 3 polls before expiry, got %d", requests)
+	}
+}
+
+func Test_Refresh(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path != "/token" || r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "refresh123" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Form)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"access_token": "token456", "refresh_token": "refresh456"})
+	})
+
+	token, err := p.Refresh("refresh123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if token.AccessToken != "token456" || token.RefreshToken != "refresh456" {
+		t.Errorf("unexpected token %+v", token)
+	}
+}
+
+func Test_ExpiresAt(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if (&Token{}).ExpiresAt(now) != nil {
+		t.Error("expected a token without expires_in to never expire")
+	}
+
+	expiresAt := (&Token{ExpiresIn: 60}).ExpiresAt(now)
+	if expiresAt == nil || !expiresAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected the token to expire a minute from now, got %v", expiresAt)
+	}
+}
@@ -0,0 +1,111 @@
+package plugins
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Executable returns the resolved path of the running git-helper binary.
+var Executable = func() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(path)
+}
+
+// Dispatch returns the arguments cobra should run with. When git-helper is
+// invoked through a git-<command> link (like git runs git-code-request for
+// git code-request), the command name is taken from argv[0].
+func Dispatch(root *cobra.Command, argv0 string, args []string) []string {
+	name := strings.TrimSuffix(filepath.Base(argv0), ".exe")
+	if !strings.HasPrefix(name, "git-") || name == root.Name() {
+		return args
+	}
+
+	command := strings.TrimPrefix(name, "git-")
+	for _, cmd := range root.Commands() {
+		if cmd.Name() == command || cmd.HasAlias(command) {
+			return append([]string{command}, args...)
+		}
+	}
+
+	return args
+}
+
+// Link symlinks git-<command> to target for each command, and removes links to
+// target for commands that no longer exist. Shims written by Install are
+// replaced, but any other existing file is left alone and reported.
+func (p *Plugins) Link(commands []string, target string) ([]string, error) {
+	wanted := map[string]bool{}
+	for _, command := range commands {
+		name := "git-" + command
+		wanted[name] = true
+		path := filepath.Join(p.Dir, name)
+
+		info, err := os.Lstat(path)
+		if err == nil {
+			if info.Mode()&os.ModeSymlink != 0 {
+				if dest, _ := os.Readlink(path); dest == target {
+					continue
+				}
+			} else if content, _ := os.ReadFile(path); !shimPattern.Match(content) {
+				return nil, errors.New("not replacing " + path + ", which isn't a Git Helper plugin")
+			}
+
+			err = os.Remove(path)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = os.Symlink(target, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	removed := []string{}
+	for _, name := range p.Links(target) {
+		if wanted[name] {
+			continue
+		}
+
+		err := os.Remove(filepath.Join(p.Dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		removed = append(removed, name)
+	}
+
+	return removed, nil
+}
+
+// Links returns the names of the git-* symlinks in the directory that point at
+// target.
+func (p *Plugins) Links(target string) []string {
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil {
+		return []string{}
+	}
+
+	links := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "git-") || entry.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		if dest, _ := os.Readlink(filepath.Join(p.Dir, name)); dest == target {
+			links = append(links, name)
+		}
+	}
+
+	return links
+}
@@ -0,0 +1,70 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func Test_Dispatch(t *testing.T) {
+	root := &cobra.Command{Use: "git-helper"}
+	root.AddCommand(&cobra.Command{Use: "code-request", Aliases: []string{"pr"}}, &cobra.Command{Use: "new-branch"})
+
+	tests := []struct {
+		argv0    string
+		args     []string
+		expected []string
+	}{
+		{argv0: "/usr/local/bin/git-helper", args: []string{"new-branch", "x"}, expected: []string{"new-branch", "x"}},
+		{argv0: "/usr/local/bin/git-new-branch", args: []string{"x", "--debug"}, expected: []string{"new-branch", "x", "--debug"}},
+		{argv0: "git-code-request.exe", args: []string{}, expected: []string{"code-request"}},
+		{argv0: "git-pr", args: []string{}, expected: []string{"pr"}},
+		{argv0: "git-unknown", args: []string{"x"}, expected: []string{"x"}},
+		{argv0: "helper", args: []string{"x"}, expected: []string{"x"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.argv0, func(t *testing.T) {
+			args := Dispatch(root, filepath.FromSlash(test.argv0), test.args)
+			if !reflect.DeepEqual(args, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, args)
+			}
+		})
+	}
+}
+
+func Test_Link(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "git-helper")
+	os.WriteFile(target, []byte("binary"), 0755)
+	os.Symlink(target, filepath.Join(dir, "git-removed"))
+	os.Symlink("/elsewhere/git-helper", filepath.Join(dir, "git-other"))
+	os.WriteFile(filepath.Join(dir, "git-new-branch"), []byte(Shim("new-branch")), 0755)
+
+	p := NewPlugins(true, dir, &MockExecutor{Debug: true})
+	removed, err := p.Link([]string{"code-request", "new-branch"}, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(removed, []string{"git-removed"}) {
+		t.Errorf("expected git-removed to be removed, got %v", removed)
+	}
+
+	expected := []string{"git-code-request", "git-new-branch"}
+	if links := p.Links(target); !reflect.DeepEqual(links, expected) {
+		t.Errorf("expected links %v, got %v", expected, links)
+	}
+
+	if _, err := os.Lstat(filepath.Join(dir, "git-other")); err != nil {
+		t.Error("expected git-other to be left alone")
+	}
+
+	os.WriteFile(filepath.Join(dir, "git-custom"), []byte("#!/bin/sh\necho custom\n"), 0755)
+	if _, err := p.Link([]string{"custom"}, target); err == nil {
+		t.Error("expected an error when a non-plugin file is in the way")
+	}
+}
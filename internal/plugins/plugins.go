@@ -0,0 +1,138 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/spf13/cobra"
+)
+
+type Plugins struct {
+	Debug    bool
+	Dir      string
+	Executor executor.ExecutorInterface
+}
+
+const header = "# Generated by git-helper setup, and regenerated by git-helper update."
+
+// shimPattern matches the shims written by Install, as well as the ones older
+// versions downloaded from GitHub, so either can be cleaned up.
+var shimPattern = regexp.MustCompile(`^#!/bin/sh\n(?:#[^\n]*\n)*\n(?:exec )?git-helper [\w-]+ (?:"\$@"|\$@)\n?$`)
+
+func NewPlugins(debug bool, dir string, executor executor.ExecutorInterface) *Plugins {
+	return &Plugins{
+		Debug:    debug,
+		Dir:      dir,
+		Executor: executor,
+	}
+}
+
+// Commands returns the names of root's subcommands that can be run as git
+// plugins. Cobra's own commands are skipped, as are commands git already has
+// built in, since git would never run a plugin with the same name.
+func (p *Plugins) Commands(root *cobra.Command) []string {
+	builtins := []string{}
+	output, err := p.Executor.Exec("actionAndOutput", "git", "--list-cmds=builtins")
+	if err == nil {
+		builtins = strings.Fields(string(output))
+	}
+
+	names := []string{}
+	for _, cmd := range root.Commands() {
+		name := cmd.Name()
+		if cmd.Hidden || name == "help" || name == "completion" || slices.Contains(builtins, name) {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// Install writes a git-<command> shim for each command, and removes shims for
+// commands that no longer exist. Files in the directory that aren't shims are
+// left alone.
+func (p *Plugins) Install(commands []string) ([]string, error) {
+	err := os.MkdirAll(p.Dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, command := range commands {
+		name := "git-" + command
+		wanted[name] = true
+
+		err = os.WriteFile(filepath.Join(p.Dir, name), []byte(Shim(command)), 0755)
+		if err != nil {
+			return nil, err
+		}
+
+		err = os.Chmod(filepath.Join(p.Dir, name), 0755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if wanted[name] || !strings.HasPrefix(name, "git-") || !entry.Type().IsRegular() {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(p.Dir, name))
+		if err != nil || !shimPattern.Match(content) {
+			continue
+		}
+
+		err = os.Remove(filepath.Join(p.Dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		removed = append(removed, name)
+	}
+
+	return removed, nil
+}
+
+// Shims returns the names of the git-* shims Install wrote in the directory.
+func (p *Plugins) Shims() []string {
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil {
+		return []string{}
+	}
+
+	shims := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "git-") || !entry.Type().IsRegular() {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(p.Dir, name))
+		if err == nil && shimPattern.Match(content) {
+			shims = append(shims, name)
+		}
+	}
+
+	return shims
+}
+
+// Shim returns the script that runs a git-helper command as git <command>.
+func Shim(command string) string {
+	return fmt.Sprintf("#!/bin/sh\n%s\n\nexec git-helper %s \"$@\"\n", header, command)
+}
@@ -0,0 +1,109 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Output  []byte
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	return me.Output, nil
+}
+
+func Test_Commands(t *testing.T) {
+	root := &cobra.Command{Use: "git-helper"}
+	for _, name := range []string{"new-branch", "config", "code-request", "completion", "secret"} {
+		root.AddCommand(&cobra.Command{Use: name, Hidden: name == "secret", Run: func(*cobra.Command, []string) {}})
+	}
+
+	executor := &MockExecutor{Debug: true, Output: []byte("add\nconfig\nstatus\n")}
+	names := NewPlugins(true, t.TempDir(), executor).Commands(root)
+
+	expected := []string{"code-request", "new-branch"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+
+	if executor.Command != "git" || executor.Args[0] != "--list-cmds=builtins" {
+		t.Errorf("unexpected command received: %s %v", executor.Command, executor.Args)
+	}
+}
+
+func Test_Shim(t *testing.T) {
+	expected := "#!/bin/sh\n" + header + "\n\nexec git-helper code-request \"$@\"\n"
+	if Shim("code-request") != expected {
+		t.Errorf("expected %q, got %q", expected, Shim("code-request"))
+	}
+}
+
+func Test_Install(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"git-removed-command": Shim("removed-command"),
+		"git-old-download":    "#!/bin/sh\n\ngit-helper old-download $@\n",
+		"git-custom":          "#!/bin/sh\n\necho custom\n",
+		"notes.txt":           "exec git-helper notes \"$@\"\n",
+		"git-new-branch":      "#!/bin/sh\n\ngit-helper new-branch $@\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0755)
+	}
+
+	removed, err := NewPlugins(true, dir, &MockExecutor{Debug: true}).Install([]string{"code-request", "new-branch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRemoved := []string{"git-old-download", "git-removed-command"}
+	if !reflect.DeepEqual(removed, expectedRemoved) {
+		t.Errorf("expected %v to be removed, got %v", expectedRemoved, removed)
+	}
+
+	for _, name := range []string{"code-request", "new-branch"} {
+		content, err := os.ReadFile(filepath.Join(dir, "git-"+name))
+		if err != nil || string(content) != Shim(name) {
+			t.Errorf("expected git-%s to be the generated shim, got %q (%v)", name, content, err)
+		}
+
+		info, _ := os.Stat(filepath.Join(dir, "git-"+name))
+		if info.Mode().Perm() != 0755 {
+			t.Errorf("expected git-%s to be executable, got %#o", name, info.Mode().Perm())
+		}
+	}
+
+	for _, name := range []string{"git-custom", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be left alone", name)
+		}
+	}
+}
+
+func Test_Shims(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"git-code-request": Shim("code-request"),
+		"git-old-download": "#!/bin/sh\n\ngit-helper old-download $@\n",
+		"git-custom":       "#!/bin/sh\n\necho custom\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0755)
+	}
+
+	shims := NewPlugins(true, dir, &MockExecutor{Debug: true}).Shims()
+	expected := []string{"git-code-request", "git-old-download"}
+	if !reflect.DeepEqual(shims, expected) {
+		t.Errorf("expected %v, got %v", expected, shims)
+	}
+}
@@ -0,0 +1,68 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CheckInterval is how long the latest release is cached between checks.
+const CheckInterval = 24 * time.Hour
+
+// RetryInterval is how long to wait before checking again after a check
+// failed, so being offline or rate limited doesn't slow every command down.
+const RetryInterval = time.Hour
+
+type cachedCheck struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Failed    bool      `json:"failed,omitempty"`
+	Latest    string    `json:"latest"`
+}
+
+// Notice returns a one-line message when a newer release than current is out,
+// or "" otherwise. The latest release is looked up at most once per
+// CheckInterval, or RetryInterval after a failed lookup, and cached in
+// cacheFile in between.
+func (c *Client) Notice(cacheFile, current string, now time.Time) (string, error) {
+	cached := cachedCheck{}
+	content, err := os.ReadFile(cacheFile)
+	if err == nil {
+		json.Unmarshal(content, &cached)
+	}
+
+	interval := CheckInterval
+	if cached.Failed {
+		interval = RetryInterval
+	}
+
+	if cached.CheckedAt.IsZero() || now.Sub(cached.CheckedAt) >= interval || now.Before(cached.CheckedAt) {
+		release, checkErr := c.Latest(false)
+		if checkErr != nil {
+			cached = cachedCheck{CheckedAt: now, Failed: true, Latest: cached.Latest}
+		} else {
+			cached = cachedCheck{CheckedAt: now, Latest: release.TagName}
+		}
+
+		content, _ = json.Marshal(cached)
+		err = os.MkdirAll(filepath.Dir(cacheFile), 0700)
+		if err == nil {
+			err = os.WriteFile(cacheFile, content, 0600)
+		}
+		if checkErr != nil {
+			return "", checkErr
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	current = "v" + strings.TrimPrefix(current, "v")
+	if cached.Latest == "" || Compare(cached.Latest, current) <= 0 {
+		return "", nil
+	}
+
+	return fmt.Sprintf("A new version of git-helper is available: %s → %s (run git-helper update to install it)", current, cached.Latest), nil
+}
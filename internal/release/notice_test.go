@@ -0,0 +1,74 @@
+package release
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Notice(t *testing.T) {
+	requests := 0
+	latest := "v0.3.0"
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"tag_name": "` + latest + `"}`))
+	})
+
+	cacheFile := filepath.Join(t.TempDir(), ".git-helper", "update-check.json")
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	notice, err := client.Notice(cacheFile, "0.2.1", now)
+	if err != nil || !strings.Contains(notice, "v0.2.1 → v0.3.0") {
+		t.Errorf("expected a notice about v0.3.0, got %q (%v)", notice, err)
+	}
+
+	latest = "v0.4.0"
+	notice, _ = client.Notice(cacheFile, "0.3.0", now.Add(time.Hour))
+	if notice != "" || requests != 1 {
+		t.Errorf("expected the cached release to be used, got %q after %d requests", notice, requests)
+	}
+
+	notice, _ = client.Notice(cacheFile, "0.3.0", now.Add(CheckInterval))
+	if !strings.Contains(notice, "v0.4.0") || requests != 2 {
+		t.Errorf("expected a fresh check after a day, got %q after %d requests", notice, requests)
+	}
+
+	notice, _ = client.Notice(cacheFile, "v0.4.0", now.Add(CheckInterval))
+	if notice != "" {
+		t.Errorf("expected no notice when up to date, got %q", notice)
+	}
+}
+
+func Test_Notice_FailedCheck(t *testing.T) {
+	requests := 0
+	failing := true
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if failing {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+			return
+		}
+		w.Write([]byte(`{"tag_name": "v0.3.0"}`))
+	})
+
+	cacheFile := filepath.Join(t.TempDir(), ".git-helper", "update-check.json")
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := client.Notice(cacheFile, "0.2.1", now); err == nil {
+		t.Errorf("expected the failed check's error")
+	}
+
+	notice, err := client.Notice(cacheFile, "0.2.1", now.Add(time.Minute))
+	if notice != "" || err != nil || requests != 1 {
+		t.Errorf("expected the failed check to be cached, got %q (%v) after %d requests", notice, err, requests)
+	}
+
+	failing = false
+	notice, _ = client.Notice(cacheFile, "0.2.1", now.Add(RetryInterval))
+	if !strings.Contains(notice, "v0.3.0") || requests != 2 {
+		t.Errorf("expected a retry after %s, got %q after %d requests", RetryInterval, notice, requests)
+	}
+}
@@ -0,0 +1,220 @@
+package release
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	ChecksumsAsset = "checksums.txt"
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// Client looks up Git Helper's releases through the GitHub REST API.
+type Client struct {
+	APIURL     string
+	HTTPClient *http.Client
+	Owner      string
+	Repository string
+}
+
+type Release struct {
+	Assets     []Asset `json:"assets"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	TagName    string  `json:"tag_name"`
+}
+
+type Asset struct {
+	DownloadURL string `json:"browser_download_url"`
+	Name        string `json:"name"`
+}
+
+func NewClient(owner, repository string) *Client {
+	return &Client{
+		APIURL:     "https://api.github.com",
+		HTTPClient: http.DefaultClient,
+		Owner:      owner,
+		Repository: repository,
+	}
+}
+
+// Latest returns the newest release. Prereleases are only considered when
+// prerelease is true.
+func (c *Client) Latest(prerelease bool) (*Release, error) {
+	if !prerelease {
+		release := &Release{}
+		return release, c.getJSON(c.repoURL("/releases/latest"), release)
+	}
+
+	releases := []Release{}
+	err := c.getJSON(c.repoURL("/releases?per_page=30"), &releases)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, release := range releases {
+		if !release.Draft {
+			return &release, nil
+		}
+	}
+
+	return nil, errors.New("no releases found")
+}
+
+// Tag returns the release for tag, with or without its v prefix.
+func (c *Client) Tag(tag string) (*Release, error) {
+	release := &Release{}
+	return release, c.getJSON(c.repoURL("/releases/tags/v"+strings.TrimPrefix(tag, "v")), release)
+}
+
+// Download returns the contents of an asset.
+func (c *Client) Download(asset *Asset) ([]byte, error) {
+	resp, err := c.HTTPClient.Get(asset.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s failed: %s", asset.Name, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (c *Client) repoURL(path string) string {
+	return strings.TrimSuffix(c.APIURL, "/") + "/repos/" + c.Owner + "/" + c.Repository + path
+}
+
+func (c *Client) getJSON(url string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.New("release not found")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("looking up releases failed: %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Asset returns the asset called name, or nil if the release doesn't have one.
+func (r *Release) Asset(name string) *Asset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+
+	return nil
+}
+
+// AssetName returns the name of the binary built for this platform.
+func AssetName() string {
+	name := "git-helper_" + runtime.GOOS + "_" + runtime.GOARCH
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	return name
+}
+
+// VerifyChecksum checks data against name's entry in a sha256sum-style
+// checksums file.
+func VerifyChecksum(data, checksums []byte, name string) error {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return errors.New("checksum mismatch for " + name)
+		}
+
+		return nil
+	}
+
+	return errors.New("no checksum found for " + name)
+}
+
+// VerifySignature checks a base64 Ed25519 signature of data against a base64
+// public key.
+func VerifySignature(data, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("update_public_key is not a base64 Ed25519 public key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(key, data, sig) {
+		return errors.New("signature verification failed for " + ChecksumsAsset)
+	}
+
+	return nil
+}
+
+// Compare compares two versions like v1.2.3 or 1.2.3-rc.1, returning -1, 0 or
+// 1. A prerelease sorts before its release.
+func Compare(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+
+	aParts := strings.Split(aCore, ".")
+	bParts := strings.Split(bCore, ".")
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		aNum, bNum := part(aParts, i), part(bParts, i)
+		if aNum != bNum {
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func part(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+
+	n, _ := strconv.Atoi(parts[i])
+	return n
+}
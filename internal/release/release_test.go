@@ -0,0 +1,153 @@
+package release
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClient("owner", "repo")
+	client.APIURL = server.URL
+	return client
+}
+
+func Test_Latest(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/releases/latest":
+			w.Write([]byte(`{"tag_name": "v1.0.0", "assets": [{"name": "checksums.txt", "browser_download_url": "http://x/checksums.txt"}]}`))
+		case "/repos/owner/repo/releases":
+			w.Write([]byte(`[{"tag_name": "v1.2.0", "draft": true}, {"tag_name": "v1.1.0-rc.1", "prerelease": true}, {"tag_name": "v1.0.0"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	release, err := client.Latest(false)
+	if err != nil || release.TagName != "v1.0.0" {
+		t.Fatalf("expected v1.0.0, got %+v (%v)", release, err)
+	}
+
+	if asset := release.Asset(ChecksumsAsset); asset == nil || asset.DownloadURL != "http://x/checksums.txt" {
+		t.Errorf("expected the checksums asset, got %+v", asset)
+	}
+
+	if release.Asset("missing") != nil {
+		t.Error("expected no asset called missing")
+	}
+
+	release, err = client.Latest(true)
+	if err != nil || release.TagName != "v1.1.0-rc.1" {
+		t.Errorf("expected v1.1.0-rc.1, got %+v (%v)", release, err)
+	}
+}
+
+func Test_Tag(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/releases/tags/v0.2.0" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write([]byte(`{"tag_name": "v0.2.0"}`))
+	})
+
+	for _, tag := range []string{"0.2.0", "v0.2.0"} {
+		release, err := client.Tag(tag)
+		if err != nil || release.TagName != "v0.2.0" {
+			t.Errorf("expected v0.2.0 for %s, got %+v (%v)", tag, release, err)
+		}
+	}
+
+	if _, err := client.Tag("v9.9.9"); err == nil {
+		t.Error("expected an error for a missing tag")
+	}
+}
+
+func Test_Download(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/binary" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write([]byte("binary"))
+	})
+
+	data, err := client.Download(&Asset{Name: "binary", DownloadURL: client.APIURL + "/binary"})
+	if err != nil || string(data) != "binary" {
+		t.Errorf("expected binary, got %q (%v)", data, err)
+	}
+
+	if _, err := client.Download(&Asset{Name: "missing", DownloadURL: client.APIURL + "/missing"}); err == nil {
+		t.Error("expected an error for a missing asset")
+	}
+}
+
+func Test_VerifyChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("binary"))
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  git-helper_linux_amd64\n" + hex.EncodeToString(sum[:]) + " *git-helper_windows_amd64.exe\n")
+
+	tests := []struct {
+		data string
+		name string
+		err  bool
+	}{
+		{data: "binary", name: "git-helper_linux_amd64"},
+		{data: "binary", name: "git-helper_windows_amd64.exe"},
+		{data: "tampered", name: "git-helper_linux_amd64", err: true},
+		{data: "binary", name: "git-helper_darwin_arm64", err: true},
+	}
+
+	for _, test := range tests {
+		err := VerifyChecksum([]byte(test.data), checksums, test.name)
+		if (err != nil) != test.err {
+			t.Errorf("unexpected error for %s %s: %v", test.data, test.name, err)
+		}
+	}
+}
+
+func Test_VerifySignature(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(nil)
+	encodedKey := base64.StdEncoding.EncodeToString(publicKey)
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte("checksums"))) + "\n")
+
+	if err := VerifySignature([]byte("checksums"), signature, encodedKey); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+
+	if err := VerifySignature([]byte("tampered"), signature, encodedKey); err == nil {
+		t.Error("expected an error for tampered data")
+	}
+
+	if err := VerifySignature([]byte("checksums"), signature, "not-a-key"); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
+
+func Test_Compare(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "v1.0.0", b: "1.0.0", expected: 0},
+		{a: "0.2.1", b: "v0.10.0", expected: -1},
+		{a: "1.0.0", b: "1.0.0-rc.1", expected: 1},
+		{a: "1.0.0-rc.1", b: "1.0.0-rc.2", expected: -1},
+		{a: "2.0", b: "1.9.9", expected: 1},
+	}
+
+	for _, test := range tests {
+		if result := Compare(test.a, test.b); result != test.expected {
+			t.Errorf("expected Compare(%s, %s) to be %d, got %d", test.a, test.b, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package stack
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+)
+
+// A stacked branch records the branch it's built on, and the commit it was
+// last based on, under its branch.<name> git config section. git removes the
+// section along with the branch.
+const (
+	baseKey   = "git-helper-base"
+	parentKey = "git-helper-parent"
+)
+
+type Stack struct {
+	Debug    bool
+	Executor executor.ExecutorInterface
+}
+
+func NewStack(debug bool, executor executor.ExecutorInterface) *Stack {
+	return &Stack{
+		Debug:    debug,
+		Executor: executor,
+	}
+}
+
+// Base returns the commit the branch was last rebased onto, or "" when it
+// isn't known.
+func (s *Stack) Base(branch string) string {
+	return s.get(branch, baseKey)
+}
+
+// Children returns the branches stacked directly on branch, sorted by name.
+func (s *Stack) Children(branch string) []string {
+	children := []string{}
+	for child, parent := range s.Parents() {
+		if parent == branch {
+			children = append(children, child)
+		}
+	}
+
+	sort.Strings(children)
+	return children
+}
+
+// Commit returns the commit ref points to.
+func (s *Stack) Commit(ref string) (string, error) {
+	output, err := s.Executor.Exec("actionAndOutput", "git", "rev-parse", "--verify", "-q", ref)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Contains reports whether branch already has every commit in ref.
+func (s *Stack) Contains(branch, ref string) bool {
+	_, err := s.Executor.Exec("actionAndOutput", "git", "merge-base", "--is-ancestor", ref, branch)
+	return err == nil
+}
+
+// Descendants returns every branch stacked on branch, directly or not, with
+// parents before their children.
+func (s *Stack) Descendants(branch string) []string {
+	descendants := []string{}
+	queue := s.Children(branch)
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		descendants = append(descendants, next)
+		queue = append(queue, s.Children(next)...)
+	}
+
+	return descendants
+}
+
+// Lineage returns the stacked branches from the bottom of the stack up to and
+// including branch. It's empty when branch isn't stacked on anything.
+func (s *Stack) Lineage(branch string) []string {
+	parents := s.Parents()
+	lineage := []string{}
+	seen := map[string]bool{}
+
+	for current := branch; !seen[current]; {
+		parent, ok := parents[current]
+		if !ok {
+			break
+		}
+
+		seen[current] = true
+		lineage = append([]string{current}, lineage...)
+		current = parent
+	}
+
+	return lineage
+}
+
+// Parent returns the branch that branch is stacked on, or "" when it isn't
+// stacked.
+func (s *Stack) Parent(branch string) string {
+	return s.get(branch, parentKey)
+}
+
+// Parents maps every stacked branch to its parent.
+func (s *Stack) Parents() map[string]string {
+	parents := map[string]string{}
+	output, err := s.Executor.Exec("actionAndOutput", "git", "config", "--get-regexp", `^branch\..*\.`+parentKey+`$`)
+	if err != nil {
+		return parents
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		key, parent, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+
+		branch := strings.TrimSuffix(strings.TrimPrefix(key, "branch."), "."+parentKey)
+		parents[branch] = parent
+	}
+
+	return parents
+}
+
+// Rebase moves branch's own commits, the ones after its recorded base, onto
+// onto, and records onto as its new base.
+func (s *Stack) Rebase(branch, onto string) error {
+	commit, err := s.Commit(onto)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"rebase", onto, branch}
+	if base := s.Base(branch); base != "" {
+		args = []string{"rebase", "--onto", onto, base, branch}
+	}
+
+	_, err = s.Executor.Exec("waitAndStdout", "git", args...)
+	if err != nil {
+		return err
+	}
+
+	return s.SetBase(branch, commit)
+}
+
+// Remove forgets that branch is stacked on anything.
+func (s *Stack) Remove(branch string) {
+	_, _ = s.Executor.Exec("actionAndOutput", "git", "config", "--unset", configKey(branch, parentKey))
+	_, _ = s.Executor.Exec("actionAndOutput", "git", "config", "--unset", configKey(branch, baseKey))
+}
+
+// SetBase records the commit branch was last rebased onto.
+func (s *Stack) SetBase(branch, base string) error {
+	_, err := s.Executor.Exec("actionAndOutput", "git", "config", configKey(branch, baseKey), base)
+	return err
+}
+
+// SetParent records that branch is stacked on parent, keeping its base.
+func (s *Stack) SetParent(branch, parent string) error {
+	_, err := s.Executor.Exec("actionAndOutput", "git", "config", configKey(branch, parentKey), parent)
+	return err
+}
+
+// Track records that branch is stacked on parent, based on parent's current
+// commit.
+func (s *Stack) Track(branch, parent string) error {
+	base, err := s.Commit(parent)
+	if err != nil {
+		return err
+	}
+
+	err = s.SetParent(branch, parent)
+	if err != nil {
+		return err
+	}
+
+	return s.SetBase(branch, base)
+}
+
+func (s *Stack) get(branch, key string) string {
+	output, err := s.Executor.Exec("actionAndOutput", "git", "config", "--get", configKey(branch, key))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+func configKey(branch, key string) string {
+	return "branch." + branch + "." + key
+}
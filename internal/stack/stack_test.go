@@ -0,0 +1,120 @@
+package stack
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// MockExecutor returns the output for each command line in Outputs, and an
+// error for any command line that isn't there.
+type MockExecutor struct {
+	Args    []string
+	Command string
+	Debug   bool
+	Outputs map[string]string
+	Run     []string
+}
+
+func (me *MockExecutor) Exec(execType string, command string, args ...string) ([]byte, error) {
+	me.Command = command
+	me.Args = args
+	line := strings.Join(append([]string{command}, args...), " ")
+	me.Run = append(me.Run, line)
+
+	output, ok := me.Outputs[line]
+	if !ok {
+		return []byte("fatal: not found"), errors.New("exit status 128")
+	}
+	return []byte(output), nil
+}
+
+const parentsLine = `git config --get-regexp ^branch\..*\.git-helper-parent$`
+
+func newTestStack(outputs map[string]string) (*Stack, *MockExecutor) {
+	if _, ok := outputs[parentsLine]; !ok {
+		outputs[parentsLine] = "branch.feature-a.git-helper-parent main\nbranch.feature-b.git-helper-parent feature-a\nbranch.feature-c.git-helper-parent feature-a\nbranch.fix.v2.git-helper-parent feature-b\n"
+	}
+
+	executor := &MockExecutor{Debug: true, Outputs: outputs}
+	return NewStack(true, executor), executor
+}
+
+func Test_Parents(t *testing.T) {
+	s, _ := newTestStack(map[string]string{})
+	expected := map[string]string{"feature-a": "main", "feature-b": "feature-a", "feature-c": "feature-a", "fix.v2": "feature-b"}
+
+	if parents := s.Parents(); !reflect.DeepEqual(parents, expected) {
+		t.Errorf("expected %v, got %v", expected, parents)
+	}
+}
+
+func Test_Descendants(t *testing.T) {
+	s, _ := newTestStack(map[string]string{})
+
+	if descendants := s.Descendants("main"); !reflect.DeepEqual(descendants, []string{"feature-a", "feature-b", "feature-c", "fix.v2"}) {
+		t.Errorf("expected every branch, parents first, got %v", descendants)
+	}
+
+	if descendants := s.Descendants("feature-c"); len(descendants) != 0 {
+		t.Errorf("expected no descendants, got %v", descendants)
+	}
+}
+
+func Test_Lineage(t *testing.T) {
+	s, _ := newTestStack(map[string]string{})
+
+	if lineage := s.Lineage("fix.v2"); !reflect.DeepEqual(lineage, []string{"feature-a", "feature-b", "fix.v2"}) {
+		t.Errorf("expected the stack from the bottom up, got %v", lineage)
+	}
+
+	if lineage := s.Lineage("main"); len(lineage) != 0 {
+		t.Errorf("expected main not to be stacked, got %v", lineage)
+	}
+
+	s, _ = newTestStack(map[string]string{parentsLine: "branch.a.git-helper-parent b\nbranch.b.git-helper-parent a\n"})
+	if lineage := s.Lineage("a"); len(lineage) != 2 {
+		t.Errorf("expected a cycle to stop, got %v", lineage)
+	}
+}
+
+func Test_Track(t *testing.T) {
+	s, executor := newTestStack(map[string]string{
+		"git rev-parse --verify -q feature-a":                     "abc123\n",
+		"git config branch.feature-b.git-helper-parent feature-a": "",
+		"git config branch.feature-b.git-helper-base abc123":      "",
+	})
+
+	if err := s.Track("feature-b", "feature-a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(executor.Run) != 3 {
+		t.Errorf("expected the parent and base to be recorded, got %v", executor.Run)
+	}
+}
+
+func Test_Rebase(t *testing.T) {
+	s, executor := newTestStack(map[string]string{
+		"git rev-parse --verify -q feature-a":                "def456\n",
+		"git config --get branch.feature-b.git-helper-base":  "abc123\n",
+		"git rebase --onto feature-a abc123 feature-b":       "",
+		"git config branch.feature-b.git-helper-base def456": "",
+	})
+
+	if err := s.Rebase("feature-b", "feature-a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if executor.Run[len(executor.Run)-1] != "git config branch.feature-b.git-helper-base def456" {
+		t.Errorf("expected the new base to be recorded, got %v", executor.Run)
+	}
+
+	s, executor = newTestStack(map[string]string{
+		"git rev-parse --verify -q feature-a": "def456\n",
+	})
+	if err := s.Rebase("feature-b", "feature-a"); err == nil || executor.Run[len(executor.Run)-1] != "git rebase feature-a feature-b" {
+		t.Errorf("expected a plain rebase without a base to fail here, got %v after %v", err, executor.Run)
+	}
+}
@@ -20,3 +20,12 @@ func HandleError(err error, debugB bool, logger Logger) {
 		logger.Fatal(err)
 	}
 }
+
+// ValueOr returns value, or fallback when value is empty.
+func ValueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
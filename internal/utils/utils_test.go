@@ -22,3 +22,13 @@ func TestHandleError(t *testing.T) {
 		t.Errorf("Expected Fatal to be called on logger")
 	}
 }
+
+func TestValueOr(t *testing.T) {
+	if value := ValueOr("", "fallback"); value != "fallback" {
+		t.Errorf("Expected the fallback for an empty value, got %s", value)
+	}
+
+	if value := ValueOr("value", "fallback"); value != "value" {
+		t.Errorf("Expected the value, got %s", value)
+	}
+}
@@ -1,57 +1,225 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/emmahsax/go-git-helper/cmd/auth"
 	"github.com/emmahsax/go-git-helper/cmd/changeRemote"
 	"github.com/emmahsax/go-git-helper/cmd/checkoutDefault"
+	"github.com/emmahsax/go-git-helper/cmd/checkoutPr"
+	"github.com/emmahsax/go-git-helper/cmd/checks"
 	"github.com/emmahsax/go-git-helper/cmd/cleanBranches"
 	"github.com/emmahsax/go-git-helper/cmd/codeRequest"
+	"github.com/emmahsax/go-git-helper/cmd/config"
+	"github.com/emmahsax/go-git-helper/cmd/doctor"
+	"github.com/emmahsax/go-git-helper/cmd/done"
+	"github.com/emmahsax/go-git-helper/cmd/draft"
+	"github.com/emmahsax/go-git-helper/cmd/each"
 	"github.com/emmahsax/go-git-helper/cmd/emptyCommit"
 	"github.com/emmahsax/go-git-helper/cmd/forgetLocalChanges"
 	"github.com/emmahsax/go-git-helper/cmd/forgetLocalCommits"
+	"github.com/emmahsax/go-git-helper/cmd/installLinks"
+	"github.com/emmahsax/go-git-helper/cmd/merge"
 	"github.com/emmahsax/go-git-helper/cmd/newBranch"
+	"github.com/emmahsax/go-git-helper/cmd/ready"
 	"github.com/emmahsax/go-git-helper/cmd/setHeadRef"
 	"github.com/emmahsax/go-git-helper/cmd/setup"
+	"github.com/emmahsax/go-git-helper/cmd/stack"
+	"github.com/emmahsax/go-git-helper/cmd/status"
+	"github.com/emmahsax/go-git-helper/cmd/sync"
 	"github.com/emmahsax/go-git-helper/cmd/update"
 	"github.com/emmahsax/go-git-helper/cmd/version"
+	"github.com/emmahsax/go-git-helper/internal/configfile"
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/emmahsax/go-git-helper/internal/forge"
+	"github.com/emmahsax/go-git-helper/internal/git"
+	"github.com/emmahsax/go-git-helper/internal/plugins"
+	"github.com/emmahsax/go-git-helper/internal/release"
 	"github.com/spf13/cobra"
 )
 
+// packageVersion, packageCommit and packageBuildDate are set at build time
+// with -ldflags, and otherwise come from the binary's build info, with
+// packageVersion falling back to the version in the source.
 var (
 	packageOwner      = "emmahsax"
 	packageRepository = "go-git-helper"
 	packageVersion    = "0.2.1"
+	packageCommit     = ""
+	packageBuildDate  = ""
 )
 
+// noticeWait is how long to wait for the update check once the command is
+// done, so a slow network never holds up the command.
+const noticeWait = time.Second
+
 func main() {
-	rootCmd := newCommand()
+	git.ForgeDefaultBranch = forge.DefaultBranch
+	packageVersion = version.NewBuild(packageCommit, packageBuildDate, packageVersion).Version
+	update.RemoveOldBinary()
+
+	notifier := newUpdateNotifier()
+	rootCmd := newCommand(notifier)
+	args := plugins.Dispatch(rootCmd, os.Args[0], os.Args[1:])
+	rootCmd.SetArgs(args)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+
+	if message := notifier.wait(noticeWait); message != "" {
+		fmt.Fprintf(os.Stderr, "\n%s\n", message)
+	}
 }
 
-func newCommand() *cobra.Command {
+func newCommand(notifier *updateNotifier) *cobra.Command {
+	var (
+		dir string
+	)
+
 	cmd := &cobra.Command{
 		Use:   "git-helper",
 		Short: "Making it easier to work with git on the command-line",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			err := setWorkingDir(dir)
+			if err != nil {
+				return err
+			}
+
+			notifier.start(cmd)
+			return nil
+		},
 	}
 
+	cmd.PersistentFlags().StringVarP(&dir, "directory", "C", "", "run as if git-helper was started in this path")
+
+	cmd.AddCommand(auth.NewCommand())
 	cmd.AddCommand(changeRemote.NewCommand())
 	cmd.AddCommand(checkoutDefault.NewCommand())
+	cmd.AddCommand(checkoutPr.NewCommand())
+	cmd.AddCommand(checks.NewCommand())
 	cmd.AddCommand(cleanBranches.NewCommand())
 	cmd.AddCommand(codeRequest.NewCommand())
+	cmd.AddCommand(config.NewCommand())
+	cmd.AddCommand(doctor.NewCommand())
+	cmd.AddCommand(done.NewCommand())
+	cmd.AddCommand(draft.NewCommand())
+	cmd.AddCommand(each.NewCommand())
 	cmd.AddCommand(emptyCommit.NewCommand())
 	cmd.AddCommand(forgetLocalChanges.NewCommand())
 	cmd.AddCommand(forgetLocalCommits.NewCommand())
+	cmd.AddCommand(installLinks.NewCommand())
+	cmd.AddCommand(merge.NewCommand())
 	cmd.AddCommand(newBranch.NewCommand())
+	cmd.AddCommand(ready.NewCommand())
 	cmd.AddCommand(setHeadRef.NewCommand())
-	cmd.AddCommand(setup.NewCommand(packageOwner, packageRepository))
-	cmd.AddCommand(update.NewCommand(packageOwner, packageRepository))
-	cmd.AddCommand(version.NewCommand(packageVersion))
+	cmd.AddCommand(setup.NewCommand())
+	cmd.AddCommand(stack.NewCommand())
+	cmd.AddCommand(status.NewCommand())
+	cmd.AddCommand(sync.NewCommand())
+	cmd.AddCommand(update.NewCommand(packageOwner, packageRepository, packageVersion))
+	cmd.AddCommand(version.NewCommand(packageOwner, packageRepository, version.Build{Commit: packageCommit, Date: packageBuildDate, Version: packageVersion}))
 
 	return cmd
 }
+
+// updateNotifier checks for a newer release in the background while the
+// command runs. It's started once the command's flags and working directory
+// are set, and doesn't check when the output isn't a terminal, when running
+// update itself, or when disable_update_notifier is set.
+type updateNotifier struct {
+	check       func() (string, error)
+	interactive bool
+	notice      chan string
+}
+
+func newUpdateNotifier() *updateNotifier {
+	return &updateNotifier{
+		check:       checkForUpdate,
+		interactive: isTerminal(os.Stdout) && isTerminal(os.Stderr),
+	}
+}
+
+func (n *updateNotifier) start(cmd *cobra.Command) {
+	if !n.interactive || cmd.Hidden || cmd.Name() == "update" {
+		return
+	}
+
+	n.notice = make(chan string, 1)
+	go func() {
+		defer close(n.notice)
+
+		message, err := n.check()
+		if err == nil {
+			n.notice <- message
+		}
+	}()
+}
+
+// wait returns the notice, or nothing when there isn't one or the check
+// doesn't finish within timeout.
+func (n *updateNotifier) wait(timeout time.Duration) string {
+	if n.notice == nil {
+		return ""
+	}
+
+	select {
+	case message := <-n.notice:
+		return message
+	case <-time.After(timeout):
+		return ""
+	}
+}
+
+// checkForUpdate only reads config outside the repository, and never migrates
+// the global config file, since the command running alongside it may be
+// writing it.
+func checkForUpdate() (string, error) {
+	cf := configfile.NewConfigFile(false)
+	config, err := cf.ReadGlobal()
+	if err != nil {
+		return "", err
+	}
+
+	if config.DisableUpdateNotifier {
+		return "", nil
+	}
+
+	client := release.NewClient(packageOwner, packageRepository)
+	client.HTTPClient = &http.Client{Timeout: 2 * time.Second}
+	return client.Notice(filepath.Join(cf.ConfigDir(), "update-check.json"), packageVersion, time.Now())
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func setWorkingDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return errors.New(dir + " is not a directory")
+	}
+
+	executor.WorkingDir = absDir
+	return nil
+}
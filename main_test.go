@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emmahsax/go-git-helper/internal/executor"
+	"github.com/spf13/cobra"
+)
+
+func newTestRoot(t *testing.T, notifier *updateNotifier) *cobra.Command {
+	originalWorkingDir := executor.WorkingDir
+	t.Cleanup(func() {
+		executor.WorkingDir = originalWorkingDir
+	})
+
+	root := newCommand(notifier)
+	root.AddCommand(&cobra.Command{Use: "noop", RunE: func(cmd *cobra.Command, args []string) error { return nil }})
+	return root
+}
+
+func Test_updateNotifier_StartsAfterWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	seen := make(chan string, 1)
+	notifier := &updateNotifier{
+		check: func() (string, error) {
+			seen <- executor.WorkingDir
+			return "A new version is available", nil
+		},
+		interactive: true,
+	}
+
+	root := newTestRoot(t, notifier)
+	root.SetArgs([]string{"-C", dir, "noop"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if message := notifier.wait(time.Second); message != "A new version is available" {
+		t.Errorf("expected the notice, got '%s'", message)
+	}
+
+	if workingDir := <-seen; workingDir != dir {
+		t.Errorf("expected the check to start after the working directory was set to %s, got '%s'", dir, workingDir)
+	}
+}
+
+func Test_updateNotifier_Skipped(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		interactive bool
+	}{
+		{name: "not a terminal", args: []string{"noop"}},
+		{name: "invalid directory", args: []string{"-C", "/does/not/exist", "noop"}, interactive: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notifier := &updateNotifier{
+				check: func() (string, error) {
+					t.Error("expected no update check")
+					return "", nil
+				},
+				interactive: test.interactive,
+			}
+
+			root := newTestRoot(t, notifier)
+			root.SetArgs(test.args)
+			root.SilenceErrors = true
+			root.SilenceUsage = true
+			root.Execute()
+
+			if message := notifier.wait(10 * time.Millisecond); message != "" {
+				t.Errorf("expected no notice, got '%s'", message)
+			}
+		})
+	}
+}
+
+func Test_updateNotifier_SkipsUpdate(t *testing.T) {
+	notifier := &updateNotifier{
+		check: func() (string, error) {
+			t.Error("expected no update check")
+			return "", nil
+		},
+		interactive: true,
+	}
+
+	notifier.start(&cobra.Command{Use: "update"})
+	if message := notifier.wait(10 * time.Millisecond); message != "" {
+		t.Errorf("expected no notice, got '%s'", message)
+	}
+}